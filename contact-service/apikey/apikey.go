@@ -0,0 +1,50 @@
+package apikey
+
+import "time"
+
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+	ScopeAdmin = "admin"
+)
+
+// APIKey authenticates a machine client. Only the hash of the key is stored, Prefix is kept so operators can tell
+// keys apart without the secret.
+type APIKey struct {
+	ID     string
+	Name   string
+	Prefix string
+	Hash   string
+	// UserID restricts the key to the contacts of one user, an empty UserID grants access to every user
+	UserID string
+	// TenantID restricts the key to one tenant, an empty TenantID leaves the tenant to the request
+	TenantID  string
+	Scopes    []string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+// IsActive tells whether the key is neither revoked nor expired at now, a zero ExpiresAt never expires
+func (k APIKey) IsActive(now time.Time) bool {
+	if !k.RevokedAt.IsZero() {
+		return false
+	}
+
+	return k.ExpiresAt.IsZero() || now.Before(k.ExpiresAt)
+}
+
+// HasScope tells whether scopes grant scope, the admin scope grants every scope
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope || s == ScopeAdmin {
+			return true
+		}
+	}
+
+	return false
+}
+
+func IsValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite || scope == ScopeAdmin
+}
@@ -0,0 +1,128 @@
+package apikeymanaging
+
+import (
+	"context"
+	"fmt"
+	"infrastructure/myerror"
+	"strings"
+	"time"
+
+	"contact-service/apikey"
+)
+
+type Service interface {
+	CreateKey(ctx context.Context, k apikey.APIKey) (string, apikey.APIKey, error)
+	ListKeys(ctx context.Context) ([]apikey.APIKey, error)
+	RevokeKey(ctx context.Context, keyID string) error
+}
+
+// Create
+
+type createKeyRequest struct {
+	Name      string
+	UserID    string
+	TenantID  string
+	Scopes    []string
+	ExpiresAt time.Time
+}
+
+func (r createKeyRequest) Validate() error {
+	var errorMessages []string
+
+	if r.Name == "" {
+		errorMessages = append(errorMessages, "name is required")
+	}
+
+	if len(r.Scopes) == 0 {
+		errorMessages = append(errorMessages, "scopes is required")
+	}
+	for _, scope := range r.Scopes {
+		if !apikey.IsValidScope(scope) {
+			errorMessages = append(errorMessages, fmt.Sprintf("scope %q must be one of read, write, admin", scope))
+		}
+	}
+
+	if !r.ExpiresAt.IsZero() && !r.ExpiresAt.After(time.Now()) {
+		errorMessages = append(errorMessages, "expiresAt must be in the future")
+	}
+
+	if len(errorMessages) > 0 {
+		return myerror.NewBadRequestError("invalid request: %s", strings.Join(errorMessages, ", "))
+	}
+
+	return nil
+}
+
+func (r createKeyRequest) ToAPIKey() apikey.APIKey {
+	return apikey.APIKey{
+		Name:      r.Name,
+		UserID:    r.UserID,
+		TenantID:  r.TenantID,
+		Scopes:    r.Scopes,
+		ExpiresAt: r.ExpiresAt,
+	}
+}
+
+type createKeyResponse struct {
+	Key    string
+	APIKey apikey.APIKey
+}
+
+func endpointCreateKey(ctx context.Context, s Service, request createKeyRequest) (createKeyResponse, error) {
+	if err := request.Validate(); err != nil {
+		return createKeyResponse{}, myerror.Wrap(err, "endpointCreateKey")
+	}
+
+	key, k, err := s.CreateKey(ctx, request.ToAPIKey())
+	if err != nil {
+		return createKeyResponse{}, myerror.Wrap(err, "endpointCreateKey")
+	}
+
+	return createKeyResponse{
+		Key:    key,
+		APIKey: k,
+	}, nil
+}
+
+// List
+
+type listKeysResponse struct {
+	APIKeys []apikey.APIKey
+}
+
+func endpointListKeys(ctx context.Context, s Service) (listKeysResponse, error) {
+	keys, err := s.ListKeys(ctx)
+	if err != nil {
+		return listKeysResponse{}, myerror.Wrap(err, "endpointListKeys")
+	}
+
+	return listKeysResponse{
+		APIKeys: keys,
+	}, nil
+}
+
+// Revoke
+
+type revokeKeyRequest struct {
+	KeyID string
+}
+
+func (r revokeKeyRequest) Validate() error {
+	if r.KeyID == "" {
+		return myerror.NewBadRequestError("invalid request: keyID is required")
+	}
+
+	return nil
+}
+
+func endpointRevokeKey(ctx context.Context, s Service, request revokeKeyRequest) error {
+	if err := request.Validate(); err != nil {
+		return myerror.Wrap(err, "endpointRevokeKey")
+	}
+
+	if err := s.RevokeKey(ctx, request.KeyID); err != nil {
+		return myerror.Wrap(err, "endpointRevokeKey")
+	}
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package apikeymanaging
+
+import (
+	"context"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"infrastructure/myhttp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/apikey"
+)
+
+const (
+	createKeyURL = "/apikeys"
+	listKeysURL  = "/apikeys"
+	revokeKeyURL = "/apikeys/:keyID"
+
+	authorizationScheme = "ApiKey "
+)
+
+type Authenticator interface {
+	Authenticate(ctx context.Context, key string) (apikey.APIKey, error)
+}
+
+// RegisterHTTPEndpoints adds the key management routes to r, which is expected to be restricted to admins
+func RegisterHTTPEndpoints(r gin.IRouter, s Service) {
+	r.POST(createKeyURL, makeHTTPEndpointCreateKey(s))
+	r.GET(listKeysURL, makeHTTPEndpointListKeys(s))
+	r.DELETE(revokeKeyURL, makeHTTPEndpointRevokeKey(s))
+}
+
+// NewHTTPMiddleware authenticates requests sent with an "Authorization: ApiKey <key>" header and stores the key's
+// scopes in the request context. A key restricted to one user acts as that user, so it reaches the same contacts as
+// the user's token. Other requests are handed to fallback, e.g. the JWT authentication, or rejected when fallback is
+// nil.
+func NewHTTPMiddleware(authenticator Authenticator, fallback gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, authorizationScheme) {
+			if fallback != nil {
+				fallback(c)
+				return
+			}
+			myhttp.EncodeJSONError(c, myerror.NewUnauthorizedError("apikey: missing API key"))
+			c.Abort()
+			return
+		}
+
+		k, err := authenticator.Authenticate(c, strings.TrimSpace(strings.TrimPrefix(header, authorizationScheme)))
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			c.Abort()
+			return
+		}
+
+		ctx := mycontext.WithScopes(c.Request.Context(), k.Scopes)
+		if k.UserID != "" {
+			ctx = mycontext.WithSubject(ctx, k.UserID)
+		}
+		if k.TenantID != "" {
+			ctx = mycontext.WithTenantID(ctx, k.TenantID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// Create
+type createKeyHTTPRequest struct {
+	Name      string    `json:"name"`
+	UserID    string    `json:"userID"`
+	TenantID  string    `json:"tenantID"`
+	Scopes    []string  `json:"scopes"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func (r createKeyHTTPRequest) ToCreateKeyRequest() createKeyRequest {
+	return createKeyRequest{
+		Name:      r.Name,
+		UserID:    r.UserID,
+		TenantID:  r.TenantID,
+		Scopes:    r.Scopes,
+		ExpiresAt: r.ExpiresAt,
+	}
+}
+
+type apiKeyHTTPResponse struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	UserID    string     `json:"userID,omitempty"`
+	TenantID  string     `json:"tenantID,omitempty"`
+	Scopes    []string   `json:"scopes"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	RevokedAt *time.Time `json:"revokedAt,omitempty"`
+}
+
+type createKeyHTTPResponse struct {
+	apiKeyHTTPResponse
+	Key string `json:"key"`
+}
+
+func makeHTTPEndpointCreateKey(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req createKeyHTTPRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			myhttp.EncodeJSONError(c, myerror.NewBadRequestError("makeHTTPEndpointCreateKey: %v", err))
+			return
+		}
+
+		resp, err := endpointCreateKey(c, s, req.ToCreateKeyRequest())
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		myhttp.EncodeJSONSuccess(c, createKeyHTTPResponse{
+			apiKeyHTTPResponse: apiKeyToJSON(resp.APIKey),
+			Key:                resp.Key,
+		})
+	}
+}
+
+// List
+type listKeysHTTPResponse struct {
+	APIKeys []apiKeyHTTPResponse `json:"apiKeys"`
+}
+
+func makeHTTPEndpointListKeys(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		resp, err := endpointListKeys(c, s)
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		keys := make([]apiKeyHTTPResponse, 0, len(resp.APIKeys))
+		for _, k := range resp.APIKeys {
+			keys = append(keys, apiKeyToJSON(k))
+		}
+
+		myhttp.EncodeJSONSuccess(c, listKeysHTTPResponse{APIKeys: keys})
+	}
+}
+
+// Revoke
+func makeHTTPEndpointRevokeKey(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := endpointRevokeKey(c, s, revokeKeyRequest{KeyID: c.Param("keyID")}); err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		myhttp.EncodeJSONSuccess(c, struct{}{})
+	}
+}
+
+func apiKeyToJSON(k apikey.APIKey) apiKeyHTTPResponse {
+	resp := apiKeyHTTPResponse{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		UserID:    k.UserID,
+		TenantID:  k.TenantID,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt,
+	}
+	if !k.ExpiresAt.IsZero() {
+		resp.ExpiresAt = &k.ExpiresAt
+	}
+	if !k.RevokedAt.IsZero() {
+		resp.RevokedAt = &k.RevokedAt
+	}
+
+	return resp
+}
@@ -0,0 +1,86 @@
+package apikeymanaging
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/apikey"
+	"contact-service/contactmanaging"
+	"contact-service/inmem"
+	"contact-service/testutil"
+)
+
+// emptyContactsService has no contacts and deletes any contact
+type emptyContactsService struct {
+	contactmanaging.Service
+}
+
+func (emptyContactsService) DeleteContact(context.Context, string, string) error {
+	return nil
+}
+
+func TestNewHTTPMiddleware_Scopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+
+	s := NewService(inmem.NewAPIKeyRepository())
+	newKey := func(k apikey.APIKey) string {
+		key, _, err := s.CreateKey(ctx, k)
+		if err != nil {
+			t.Fatalf("CreateKey() error = %v", err)
+		}
+		return key
+	}
+	readKey := newKey(apikey.APIKey{Name: "read", Scopes: []string{apikey.ScopeRead}})
+	userKey := newKey(apikey.APIKey{Name: "user", UserID: "u1", Scopes: []string{apikey.ScopeRead, apikey.ScopeWrite}})
+	adminKey := newKey(apikey.APIKey{Name: "admin", Scopes: []string{apikey.ScopeAdmin}})
+
+	contacts := contactmanaging.NewService(inmem.NewUserRepository(), inmem.NewShareRepository(), inmem.NewLockCache(), testutil.NopLogger{})
+	handler := contactmanaging.NewHTTPHandler(emptyContactsService{contacts}, contactmanaging.HTTPOptions{
+		AccessLogger:        testutil.NopLogger{},
+		Authentication:      NewHTTPMiddleware(s, nil),
+		AdminAuthentication: NewHTTPMiddleware(s, nil),
+		AdminRoutes:         []func(gin.IRouter){func(r gin.IRouter) { RegisterHTTPEndpoints(r, s) }},
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		key        string
+		wantStatus int
+	}{
+		{name: "read key searches", method: http.MethodGet, path: "/users/u1/contacts", key: readKey, wantStatus: http.StatusOK},
+		{name: "read key cannot delete", method: http.MethodDelete, path: "/users/u1/contacts/c1", key: readKey, wantStatus: http.StatusForbidden},
+		{name: "user key deletes own contact", method: http.MethodDelete, path: "/users/u1/contacts/c1", key: userKey, wantStatus: http.StatusOK},
+		{name: "user key cannot reach other users", method: http.MethodGet, path: "/users/u2/contacts", key: userKey, wantStatus: http.StatusForbidden},
+		{name: "missing key", method: http.MethodGet, path: "/users/u1/contacts", wantStatus: http.StatusUnauthorized},
+		{name: "unknown key", method: http.MethodGet, path: "/users/u1/contacts", key: "pbk_nope", wantStatus: http.StatusUnauthorized},
+		{name: "read key cannot list keys", method: http.MethodGet, path: "/admin/apikeys", key: readKey, wantStatus: http.StatusForbidden},
+		{name: "admin key lists keys", method: http.MethodGet, path: "/admin/apikeys", key: adminKey, wantStatus: http.StatusOK},
+		{name: "admin key creates key", method: http.MethodPost, path: "/admin/apikeys", body: `{"name":"new","scopes":["read"]}`, key: adminKey, wantStatus: http.StatusOK},
+		{name: "invalid scope", method: http.MethodPost, path: "/admin/apikeys", body: `{"name":"new","scopes":["root"]}`, key: adminKey, wantStatus: http.StatusBadRequest},
+		{name: "admin key reaches contacts", method: http.MethodDelete, path: "/users/u1/contacts/c1", key: adminKey, wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.key != "" {
+				req.Header.Set("Authorization", "ApiKey "+tt.key)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package apikeymanaging
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"infrastructure/myerror"
+	"time"
+
+	"github.com/google/uuid"
+
+	"contact-service/apikey"
+)
+
+const (
+	// keyPrefix marks the keys of this service, so leaked keys are easy to spot by secret scanners
+	keyPrefix       = "pbk_"
+	keySecretBytes  = 32
+	displayedPrefix = len(keyPrefix) + 6
+)
+
+type Repository interface {
+	CreateKey(context.Context, apikey.APIKey) error
+	GetKeyByHash(ctx context.Context, hash string) (apikey.APIKey, error)
+	ListKeys(context.Context) ([]apikey.APIKey, error)
+	RevokeKey(ctx context.Context, keyID string, revokedAt time.Time) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// CreateKey generates a new key with the name, user and scopes of k. The key itself is returned only here, the
+// repository keeps its hash.
+func (s service) CreateKey(ctx context.Context, k apikey.APIKey) (string, apikey.APIKey, error) {
+	secret := make([]byte, keySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", apikey.APIKey{}, myerror.NewInternalError("service.CreateKey: %v", err)
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	k, err := s.storeKey(ctx, k, key)
+	if err != nil {
+		return "", apikey.APIKey{}, myerror.Wrap(err, "service.CreateKey")
+	}
+
+	return key, k, nil
+}
+
+// ImportKey stores a key chosen by the operator, e.g. the bootstrap admin key from the configuration. A key already
+// stored, e.g. imported before a restart, is returned as stored, so a revoked key stays revoked.
+func (s service) ImportKey(ctx context.Context, k apikey.APIKey, key string) (apikey.APIKey, error) {
+	existing, err := s.repo.GetKeyByHash(ctx, hashKey(key))
+	if err == nil {
+		return existing, nil
+	}
+	if myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		return apikey.APIKey{}, myerror.Wrap(err, "service.ImportKey")
+	}
+
+	k, err = s.storeKey(ctx, k, key)
+	if err != nil {
+		return apikey.APIKey{}, myerror.Wrap(err, "service.ImportKey")
+	}
+
+	return k, nil
+}
+
+func (s service) storeKey(ctx context.Context, k apikey.APIKey, key string) (apikey.APIKey, error) {
+	k.ID = uuid.New().String()
+	k.Hash = hashKey(key)
+	k.Prefix = key
+	if len(key) > displayedPrefix {
+		k.Prefix = key[:displayedPrefix]
+	}
+	k.CreatedAt = time.Now()
+	k.RevokedAt = time.Time{}
+
+	if err := s.repo.CreateKey(ctx, k); err != nil {
+		return apikey.APIKey{}, myerror.Wrap(err, "storeKey")
+	}
+
+	return k, nil
+}
+
+func (s service) ListKeys(ctx context.Context) ([]apikey.APIKey, error) {
+	keys, err := s.repo.ListKeys(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "service.ListKeys")
+	}
+
+	return keys, nil
+}
+
+func (s service) RevokeKey(ctx context.Context, keyID string) error {
+	if err := s.repo.RevokeKey(ctx, keyID, time.Now()); err != nil {
+		return myerror.Wrap(err, "service.RevokeKey")
+	}
+
+	return nil
+}
+
+// Authenticate returns the active key matching key
+func (s service) Authenticate(ctx context.Context, key string) (apikey.APIKey, error) {
+	k, err := s.repo.GetKeyByHash(ctx, hashKey(key))
+	if err != nil {
+		if myerror.GetParsedError(err).Type == myerror.NotFoundError {
+			return apikey.APIKey{}, myerror.NewUnauthorizedError("service.Authenticate: invalid API key")
+		}
+		return apikey.APIKey{}, myerror.Wrap(err, "service.Authenticate")
+	}
+
+	if !k.IsActive(time.Now()) {
+		return apikey.APIKey{}, myerror.NewUnauthorizedError("service.Authenticate: API key %s is revoked or expired", k.Prefix)
+	}
+
+	return k, nil
+}
+
+// hashKey uses a plain SHA-256, the keys are random 256-bit secrets so they need no salt nor a slow hash
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
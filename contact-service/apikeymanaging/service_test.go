@@ -0,0 +1,107 @@
+package apikeymanaging
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"strings"
+	"testing"
+	"time"
+
+	"contact-service/apikey"
+	"contact-service/inmem"
+)
+
+func TestService_Authenticate(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(inmem.NewAPIKeyRepository())
+
+	active, _, err := s.CreateKey(ctx, apikey.APIKey{Name: "active", Scopes: []string{apikey.ScopeRead}})
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	revoked, revokedKey, err := s.CreateKey(ctx, apikey.APIKey{Name: "revoked", Scopes: []string{apikey.ScopeRead}})
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+	if err := s.RevokeKey(ctx, revokedKey.ID); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+	expired, _, err := s.CreateKey(ctx, apikey.APIKey{Name: "expired", Scopes: []string{apikey.ScopeRead}, ExpiresAt: time.Now().Add(-time.Second)})
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		wantName string
+		wantErr  bool
+	}{
+		{name: "active", key: active, wantName: "active"},
+		{name: "revoked", key: revoked, wantErr: true},
+		{name: "expired", key: expired, wantErr: true},
+		{name: "unknown", key: "pbk_unknown", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k, err := s.Authenticate(ctx, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && myerror.GetParsedError(err).Type != myerror.UnauthorizedError {
+				t.Errorf("Authenticate() error type = %v, want unauthorized", myerror.GetParsedError(err).Type)
+			}
+			if err == nil && k.Name != tt.wantName {
+				t.Errorf("Authenticate() = %q, want %q", k.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestService_StoresOnlyHashes(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(inmem.NewAPIKeyRepository())
+
+	key, _, err := s.CreateKey(ctx, apikey.APIKey{Name: "key", Scopes: []string{apikey.ScopeRead}})
+	if err != nil {
+		t.Fatalf("CreateKey() error = %v", err)
+	}
+
+	keys, err := s.ListKeys(ctx)
+	if err != nil {
+		t.Fatalf("ListKeys() error = %v", err)
+	}
+	if len(keys) != 1 {
+		t.Fatalf("ListKeys() returned %d keys, want 1", len(keys))
+	}
+	if keys[0].Hash == key || strings.Contains(keys[0].Hash, key) || !strings.HasPrefix(key, keys[0].Prefix) {
+		t.Errorf("stored key = %+v, want only the hash and a prefix of %s", keys[0], key)
+	}
+}
+
+func TestService_ImportKey(t *testing.T) {
+	ctx := context.Background()
+	s := NewService(inmem.NewAPIKeyRepository())
+	bootstrap := apikey.APIKey{Name: "bootstrap admin", Scopes: []string{apikey.ScopeAdmin}}
+
+	imported, err := s.ImportKey(ctx, bootstrap, "pbk_bootstrap")
+	if err != nil {
+		t.Fatalf("ImportKey() error = %v", err)
+	}
+	if err := s.RevokeKey(ctx, imported.ID); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+
+	// importing again, as on every restart with a persistent store, keeps the stored key and its revocation
+	again, err := s.ImportKey(ctx, bootstrap, "pbk_bootstrap")
+	if err != nil {
+		t.Fatalf("ImportKey() of a stored key error = %v", err)
+	}
+	if again.ID != imported.ID || again.RevokedAt.IsZero() {
+		t.Errorf("ImportKey() of a stored key = %+v, want the revoked key %s", again, imported.ID)
+	}
+	if _, err := s.Authenticate(ctx, "pbk_bootstrap"); err == nil {
+		t.Errorf("Authenticate() with the revoked key expected an error")
+	}
+}
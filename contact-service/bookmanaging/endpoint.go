@@ -0,0 +1,143 @@
+package bookmanaging
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"strings"
+
+	"contact-service/contact"
+)
+
+type Service interface {
+	CreateBook(ctx context.Context, ownerID, name string) (contact.Book, error)
+	GetBook(ctx context.Context, bookID string) (contact.Book, error)
+	ListBooks(ctx context.Context, userID string) ([]contact.Book, error)
+	InviteMember(ctx context.Context, bookID, userID, role string) (contact.Member, error)
+	AcceptInvite(ctx context.Context, bookID, userID string) (contact.Member, error)
+	RemoveMember(ctx context.Context, bookID, userID string) error
+	Authorize(ctx context.Context, bookID, permission string) error
+}
+
+// Create
+
+type createBookRequest struct {
+	OwnerID string
+	Name    string
+}
+
+func (r createBookRequest) Validate() error {
+	var errorMessages []string
+
+	if r.OwnerID == "" {
+		errorMessages = append(errorMessages, "userID is required")
+	}
+
+	if r.Name == "" {
+		errorMessages = append(errorMessages, "name is required")
+	}
+
+	if len(errorMessages) > 0 {
+		return myerror.NewBadRequestError("invalid request: %s", strings.Join(errorMessages, ", "))
+	}
+
+	return nil
+}
+
+func endpointCreateBook(ctx context.Context, s Service, request createBookRequest) (contact.Book, error) {
+	if err := request.Validate(); err != nil {
+		return contact.Book{}, myerror.Wrap(err, "endpointCreateBook")
+	}
+
+	b, err := s.CreateBook(ctx, request.OwnerID, request.Name)
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "endpointCreateBook")
+	}
+
+	return b, nil
+}
+
+// Get
+
+func endpointGetBook(ctx context.Context, s Service, bookID string) (contact.Book, error) {
+	b, err := s.GetBook(ctx, bookID)
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "endpointGetBook")
+	}
+
+	return b, nil
+}
+
+// List
+
+func endpointListBooks(ctx context.Context, s Service, userID string) ([]contact.Book, error) {
+	books, err := s.ListBooks(ctx, userID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "endpointListBooks")
+	}
+
+	return books, nil
+}
+
+// Invite
+
+type inviteMemberRequest struct {
+	BookID string
+	UserID string
+	Role   string
+}
+
+func (r inviteMemberRequest) Validate() error {
+	var errorMessages []string
+
+	if r.BookID == "" {
+		errorMessages = append(errorMessages, "bookID is required")
+	}
+
+	if r.UserID == "" {
+		errorMessages = append(errorMessages, "userID is required")
+	}
+
+	if !contact.IsValidMemberRole(r.Role) {
+		errorMessages = append(errorMessages, "role must be editor or viewer")
+	}
+
+	if len(errorMessages) > 0 {
+		return myerror.NewBadRequestError("invalid request: %s", strings.Join(errorMessages, ", "))
+	}
+
+	return nil
+}
+
+func endpointInviteMember(ctx context.Context, s Service, request inviteMemberRequest) (contact.Member, error) {
+	if err := request.Validate(); err != nil {
+		return contact.Member{}, myerror.Wrap(err, "endpointInviteMember")
+	}
+
+	m, err := s.InviteMember(ctx, request.BookID, request.UserID, request.Role)
+	if err != nil {
+		return contact.Member{}, myerror.Wrap(err, "endpointInviteMember")
+	}
+
+	return m, nil
+}
+
+// Accept
+
+func endpointAcceptInvite(ctx context.Context, s Service, bookID, userID string) (contact.Member, error) {
+	m, err := s.AcceptInvite(ctx, bookID, userID)
+	if err != nil {
+		return contact.Member{}, myerror.Wrap(err, "endpointAcceptInvite")
+	}
+
+	return m, nil
+}
+
+// Remove
+
+func endpointRemoveMember(ctx context.Context, s Service, bookID, userID string) error {
+	if err := s.RemoveMember(ctx, bookID, userID); err != nil {
+		return myerror.Wrap(err, "endpointRemoveMember")
+	}
+
+	return nil
+}
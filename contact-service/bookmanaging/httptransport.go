@@ -0,0 +1,210 @@
+package bookmanaging
+
+import (
+	"infrastructure/myerror"
+	"infrastructure/myhttp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/apikey"
+	"contact-service/contact"
+	"contact-service/contactmanaging"
+)
+
+const (
+	createBookURL   = "/users/:userID/books"
+	listBooksURL    = "/users/:userID/books"
+	getBookURL      = "/books/:bookID"
+	inviteMemberURL = "/books/:bookID/members"
+	acceptInviteURL = "/books/:bookID/members/:memberID/accept"
+	removeMemberURL = "/books/:bookID/members/:memberID"
+)
+
+// RegisterHTTPEndpoints adds the address book and membership routes to r, and serves the contacts of every book with
+// the contacts service to its members
+func RegisterHTTPEndpoints(r gin.IRouter, s Service, contacts contactmanaging.Service) {
+	r.POST(createBookURL, contactmanaging.RequireScope(apikey.ScopeWrite), contactmanaging.AuthorizeUser(), makeHTTPEndpointCreateBook(s))
+	r.GET(listBooksURL, contactmanaging.RequireScope(apikey.ScopeRead), contactmanaging.AuthorizeUser(), makeHTTPEndpointListBooks(s))
+	r.GET(getBookURL, contactmanaging.RequireScope(apikey.ScopeRead), makeHTTPEndpointGetBook(s))
+	r.POST(inviteMemberURL, contactmanaging.RequireScope(apikey.ScopeWrite), makeHTTPEndpointInviteMember(s))
+	r.POST(acceptInviteURL, contactmanaging.RequireScope(apikey.ScopeWrite), makeHTTPEndpointAcceptInvite(s))
+	r.DELETE(removeMemberURL, contactmanaging.RequireScope(apikey.ScopeWrite), makeHTTPEndpointRemoveMember(s))
+
+	contactmanaging.RegisterBookHTTPEndpoints(r, contacts, makeHTTPAuthorizeMember(s))
+}
+
+// makeHTTPAuthorizeMember only lets the members of the :bookID whose role grants permission reach its contacts
+func makeHTTPAuthorizeMember(s Service) func(permission string) gin.HandlerFunc {
+	return func(permission string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			if err := s.Authorize(c, c.Param("bookID"), permission); err != nil {
+				myhttp.EncodeJSONError(c, err)
+				c.Abort()
+				return
+			}
+
+			c.Next()
+		}
+	}
+}
+
+type memberHTTPResponse struct {
+	UserID    string     `json:"userID"`
+	Role      string     `json:"role"`
+	Status    string     `json:"status"`
+	InvitedAt time.Time  `json:"invitedAt"`
+	JoinedAt  *time.Time `json:"joinedAt,omitempty"`
+}
+
+type bookHTTPResponse struct {
+	ID        string               `json:"id"`
+	Name      string               `json:"name"`
+	OwnerID   string               `json:"ownerID"`
+	Members   []memberHTTPResponse `json:"members"`
+	CreatedAt time.Time            `json:"createdAt"`
+}
+
+type booksHTTPResponse struct {
+	Books []bookHTTPResponse `json:"books"`
+}
+
+// Create
+type createBookHTTPRequest struct {
+	Name string `json:"name"`
+}
+
+func makeHTTPEndpointCreateBook(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req createBookHTTPRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			myhttp.EncodeJSONError(c, myerror.NewBadRequestError("makeHTTPEndpointCreateBook: %v", err))
+			return
+		}
+
+		b, err := endpointCreateBook(c, s, createBookRequest{
+			OwnerID: c.Param("userID"),
+			Name:    req.Name,
+		})
+		encodeBookResponse(c, b, err)
+	}
+}
+
+// Get
+func makeHTTPEndpointGetBook(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		b, err := endpointGetBook(c, s, c.Param("bookID"))
+		encodeBookResponse(c, b, err)
+	}
+}
+
+// List
+func makeHTTPEndpointListBooks(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		books, err := endpointListBooks(c, s, c.Param("userID"))
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		jsonResponse := booksHTTPResponse{
+			Books: make([]bookHTTPResponse, 0, len(books)),
+		}
+		for _, b := range books {
+			jsonResponse.Books = append(jsonResponse.Books, bookToJSON(b))
+		}
+
+		myhttp.EncodeJSONSuccess(c, jsonResponse)
+	}
+}
+
+// Invite
+type inviteMemberHTTPRequest struct {
+	UserID string `json:"userID"`
+	Role   string `json:"role"`
+}
+
+func makeHTTPEndpointInviteMember(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req inviteMemberHTTPRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			myhttp.EncodeJSONError(c, myerror.NewBadRequestError("makeHTTPEndpointInviteMember: %v", err))
+			return
+		}
+
+		m, err := endpointInviteMember(c, s, inviteMemberRequest{
+			BookID: c.Param("bookID"),
+			UserID: req.UserID,
+			Role:   req.Role,
+		})
+		encodeMemberResponse(c, m, err)
+	}
+}
+
+// Accept
+func makeHTTPEndpointAcceptInvite(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		m, err := endpointAcceptInvite(c, s, c.Param("bookID"), c.Param("memberID"))
+		encodeMemberResponse(c, m, err)
+	}
+}
+
+// Remove
+func makeHTTPEndpointRemoveMember(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := endpointRemoveMember(c, s, c.Param("bookID"), c.Param("memberID")); err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		myhttp.EncodeJSONSuccess(c, struct{}{})
+	}
+}
+
+func encodeBookResponse(c *gin.Context, b contact.Book, err error) {
+	if err != nil {
+		myhttp.EncodeJSONError(c, err)
+		return
+	}
+
+	myhttp.EncodeJSONSuccess(c, bookToJSON(b))
+}
+
+func encodeMemberResponse(c *gin.Context, m contact.Member, err error) {
+	if err != nil {
+		myhttp.EncodeJSONError(c, err)
+		return
+	}
+
+	myhttp.EncodeJSONSuccess(c, memberToJSON(m))
+}
+
+func bookToJSON(b contact.Book) bookHTTPResponse {
+	members := make([]memberHTTPResponse, 0, len(b.Members))
+	for _, m := range b.Members {
+		members = append(members, memberToJSON(m))
+	}
+
+	return bookHTTPResponse{
+		ID:        b.ID,
+		Name:      b.Name,
+		OwnerID:   b.OwnerID,
+		Members:   members,
+		CreatedAt: b.CreatedAt,
+	}
+}
+
+func memberToJSON(m contact.Member) memberHTTPResponse {
+	resp := memberHTTPResponse{
+		UserID:    m.UserID,
+		Role:      m.Role,
+		Status:    "invited",
+		InvitedAt: m.InvitedAt,
+	}
+	if m.IsActive() {
+		resp.Status = "active"
+		resp.JoinedAt = &m.JoinedAt
+	}
+
+	return resp
+}
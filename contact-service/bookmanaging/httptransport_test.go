@@ -0,0 +1,92 @@
+package bookmanaging
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/contact"
+	"contact-service/testutil"
+)
+
+var (
+	joinedAt      = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	invitedViewer = contact.Member{UserID: "guest", Role: contact.RoleViewer, InvitedAt: joinedAt}
+	viewer        = contact.Member{UserID: "guest", Role: contact.RoleViewer, InvitedAt: joinedAt, JoinedAt: joinedAt}
+	editor        = contact.Member{UserID: "guest", Role: contact.RoleEditor, InvitedAt: joinedAt, JoinedAt: joinedAt}
+)
+
+// newTestHandler serves the contacts and book routes over the book b1 of owner, which holds the contacts c1 and c2
+// and has the members besides its owner
+func newTestHandler(t *testing.T, members ...contact.Member) http.Handler {
+	t.Helper()
+
+	owner := contact.Member{UserID: "owner", Role: contact.RoleOwner, InvitedAt: joinedAt, JoinedAt: joinedAt}
+	s := testutil.NewStores(t, testutil.Fixture{
+		Contacts: []contact.Contact{
+			{UserID: contact.BookOwnerID("b1"), ID: "c1", Phone: "123", FirstName: "a", LastName: "b", Address: "c"},
+			{UserID: contact.BookOwnerID("b1"), ID: "c2", Phone: "456", FirstName: "d", LastName: "e", Address: "f"},
+		},
+		Books: []contact.Book{
+			{ID: "b1", Name: "sales", OwnerID: "owner", Members: append([]contact.Member{owner}, members...), CreatedAt: joinedAt},
+		},
+	})
+
+	return testutil.NewHandler(s, []func(gin.IRouter){
+		func(r gin.IRouter) { RegisterHTTPEndpoints(r, NewService(s.Books), s.Service) },
+	}, nil)
+}
+
+func TestRegisterHTTPEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContact := `{"phone":"789","firstName":"g","lastName":"h","address":"i"}`
+
+	tests := []struct {
+		name       string
+		members    []contact.Member
+		user       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "owner creates a book", user: "owner", method: http.MethodPost, path: "/users/owner/books", body: `{"name":"support"}`, wantStatus: http.StatusOK},
+		{name: "owner adds a contact", user: "owner", method: http.MethodPost, path: "/books/b1/contacts", body: newContact, wantStatus: http.StatusOK},
+		{name: "owner searches a page", user: "owner", method: http.MethodGet, path: "/books/b1/contacts?limit=1", wantStatus: http.StatusOK, wantBody: `"next":"/books/b1/contacts?`},
+		{name: "stranger cannot search", user: "guest", method: http.MethodGet, path: "/books/b1/contacts", wantStatus: http.StatusForbidden},
+		{name: "stranger cannot invite", user: "guest", method: http.MethodPost, path: "/books/b1/members", body: `{"userID":"guest","role":"editor"}`, wantStatus: http.StatusForbidden},
+		{name: "invalid role", user: "owner", method: http.MethodPost, path: "/books/b1/members", body: `{"userID":"guest","role":"owner"}`, wantStatus: http.StatusBadRequest},
+		{name: "owner invites a viewer", user: "owner", method: http.MethodPost, path: "/books/b1/members", body: `{"userID":"guest","role":"viewer"}`, wantStatus: http.StatusOK},
+		{name: "invited user cannot search yet", members: []contact.Member{invitedViewer}, user: "guest", method: http.MethodGet, path: "/books/b1/contacts", wantStatus: http.StatusForbidden},
+		{name: "invite accepted by another user", members: []contact.Member{invitedViewer}, user: "other", method: http.MethodPost, path: "/books/b1/members/guest/accept", wantStatus: http.StatusForbidden},
+		{name: "invited user accepts", members: []contact.Member{invitedViewer}, user: "guest", method: http.MethodPost, path: "/books/b1/members/guest/accept", wantStatus: http.StatusOK},
+		{name: "viewer searches", members: []contact.Member{viewer}, user: "guest", method: http.MethodGet, path: "/books/b1/contacts", wantStatus: http.StatusOK, wantBody: `"id":"c1"`},
+		{name: "viewer reads the book", members: []contact.Member{viewer}, user: "guest", method: http.MethodGet, path: "/books/b1", wantStatus: http.StatusOK},
+		{name: "viewer cannot add a contact", members: []contact.Member{viewer}, user: "guest", method: http.MethodPost, path: "/books/b1/contacts", body: newContact, wantStatus: http.StatusForbidden},
+		{name: "owner makes the viewer an editor", members: []contact.Member{viewer}, user: "owner", method: http.MethodPost, path: "/books/b1/members", body: `{"userID":"guest","role":"editor"}`, wantStatus: http.StatusOK},
+		{name: "editor adds a contact", members: []contact.Member{editor}, user: "guest", method: http.MethodPost, path: "/books/b1/contacts", body: newContact, wantStatus: http.StatusOK},
+		{name: "book contacts are not reachable as a user", user: "", method: http.MethodGet, path: "/users/book:b1/contacts", wantStatus: http.StatusNotFound},
+		{name: "member cannot remove the owner", members: []contact.Member{editor}, user: "guest", method: http.MethodDelete, path: "/books/b1/members/owner", wantStatus: http.StatusForbidden},
+		{name: "owner cannot leave", user: "owner", method: http.MethodDelete, path: "/books/b1/members/owner", wantStatus: http.StatusBadRequest},
+		{name: "member leaves", members: []contact.Member{viewer}, user: "guest", method: http.MethodDelete, path: "/books/b1/members/guest", wantStatus: http.StatusOK},
+		{name: "owner removes a member", members: []contact.Member{viewer}, user: "owner", method: http.MethodDelete, path: "/books/b1/members/guest", wantStatus: http.StatusOK},
+		{name: "missing book", user: "owner", method: http.MethodGet, path: "/books/missing/contacts", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := testutil.Do(newTestHandler(t, tt.members...), tt.user, tt.method, tt.path, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package bookmanaging
+
+import (
+	"context"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"time"
+
+	"github.com/google/uuid"
+
+	"contact-service/contact"
+)
+
+type Repository interface {
+	CreateBook(context.Context, contact.Book) error
+	GetBook(ctx context.Context, bookID string) (contact.Book, error)
+	ListBooksByMember(ctx context.Context, userID string) ([]contact.Book, error)
+	SaveMember(ctx context.Context, bookID string, m contact.Member) error
+	DeleteMember(ctx context.Context, bookID, userID string) error
+}
+
+type service struct {
+	repo Repository
+}
+
+func NewService(repo Repository) *service {
+	return &service{
+		repo: repo,
+	}
+}
+
+// CreateBook creates an address book owned by the user, who is its first member
+func (s service) CreateBook(ctx context.Context, ownerID, name string) (contact.Book, error) {
+	now := time.Now()
+	b := contact.Book{
+		ID:        uuid.New().String(),
+		Name:      name,
+		OwnerID:   ownerID,
+		Members:   []contact.Member{{UserID: ownerID, Role: contact.RoleOwner, InvitedAt: now, JoinedAt: now}},
+		CreatedAt: now,
+	}
+
+	if err := s.repo.CreateBook(ctx, b); err != nil {
+		return contact.Book{}, myerror.Wrap(err, "service.CreateBook")
+	}
+
+	return b, nil
+}
+
+func (s service) GetBook(ctx context.Context, bookID string) (contact.Book, error) {
+	b, err := s.repo.GetBook(ctx, bookID)
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "service.GetBook")
+	}
+
+	if err := authorizeMember(ctx, b, contact.PermissionRead); err != nil {
+		return contact.Book{}, myerror.Wrap(err, "service.GetBook")
+	}
+
+	return b, nil
+}
+
+// ListBooks returns the books the user belongs to, and those the user is invited to
+func (s service) ListBooks(ctx context.Context, userID string) ([]contact.Book, error) {
+	books, err := s.repo.ListBooksByMember(ctx, userID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "service.ListBooks")
+	}
+
+	return books, nil
+}
+
+// InviteMember lets the owner invite a user as an editor or a viewer, inviting a member again changes its role
+func (s service) InviteMember(ctx context.Context, bookID, userID, role string) (contact.Member, error) {
+	b, err := s.repo.GetBook(ctx, bookID)
+	if err != nil {
+		return contact.Member{}, myerror.Wrap(err, "service.InviteMember")
+	}
+
+	if err := authorizeOwner(ctx, b); err != nil {
+		return contact.Member{}, myerror.Wrap(err, "service.InviteMember")
+	}
+	if userID == b.OwnerID {
+		return contact.Member{}, myerror.NewBadRequestError("service.InviteMember: the owner of book %s cannot be invited", bookID)
+	}
+
+	m, ok := b.Member(userID)
+	if !ok {
+		m = contact.Member{UserID: userID, InvitedAt: time.Now()}
+	}
+	m.Role = role
+
+	if err := s.repo.SaveMember(ctx, bookID, m); err != nil {
+		return contact.Member{}, myerror.Wrap(err, "service.InviteMember")
+	}
+
+	return m, nil
+}
+
+// AcceptInvite makes an invited user an active member of the book
+func (s service) AcceptInvite(ctx context.Context, bookID, userID string) (contact.Member, error) {
+	if caller := mycontext.Subject(ctx); caller != "" && caller != userID {
+		return contact.Member{}, myerror.NewForbiddenError("service.AcceptInvite: user %s may not accept the invite of user %s", caller, userID)
+	}
+
+	b, err := s.repo.GetBook(ctx, bookID)
+	if err != nil {
+		return contact.Member{}, myerror.Wrap(err, "service.AcceptInvite")
+	}
+
+	m, ok := b.Member(userID)
+	if !ok {
+		return contact.Member{}, myerror.NewNotFoundError("service.AcceptInvite: user %s is not invited to book %s", userID, bookID)
+	}
+	if m.IsActive() {
+		return m, nil
+	}
+
+	m.JoinedAt = time.Now()
+	if err := s.repo.SaveMember(ctx, bookID, m); err != nil {
+		return contact.Member{}, myerror.Wrap(err, "service.AcceptInvite")
+	}
+
+	return m, nil
+}
+
+// RemoveMember lets the owner remove a member or cancel an invite, and members leave or decline by removing
+// themselves. The owner cannot be removed.
+func (s service) RemoveMember(ctx context.Context, bookID, userID string) error {
+	b, err := s.repo.GetBook(ctx, bookID)
+	if err != nil {
+		return myerror.Wrap(err, "service.RemoveMember")
+	}
+
+	if caller := mycontext.Subject(ctx); caller != userID {
+		if err := authorizeOwner(ctx, b); err != nil {
+			return myerror.Wrap(err, "service.RemoveMember")
+		}
+	}
+	if userID == b.OwnerID {
+		return myerror.NewBadRequestError("service.RemoveMember: the owner of book %s cannot be removed", bookID)
+	}
+
+	if err := s.repo.DeleteMember(ctx, bookID, userID); err != nil {
+		return myerror.Wrap(err, "service.RemoveMember")
+	}
+
+	return nil
+}
+
+// Authorize tells whether the caller may use the contacts of the book with the permission
+func (s service) Authorize(ctx context.Context, bookID, permission string) error {
+	b, err := s.repo.GetBook(ctx, bookID)
+	if err != nil {
+		return myerror.Wrap(err, "service.Authorize")
+	}
+
+	if err := authorizeMember(ctx, b, permission); err != nil {
+		return myerror.Wrap(err, "service.Authorize")
+	}
+
+	return nil
+}
+
+// authorizeMember lets active members reach the book as their role allows, requests without an authenticated
+// caller are not restricted
+func authorizeMember(ctx context.Context, b contact.Book, permission string) error {
+	caller := mycontext.Subject(ctx)
+	if caller == "" || b.Allows(caller, permission) {
+		return nil
+	}
+
+	return myerror.NewForbiddenError("authorizeMember: user %s has no %s permission on book %s", caller, permission, b.ID)
+}
+
+func authorizeOwner(ctx context.Context, b contact.Book) error {
+	caller := mycontext.Subject(ctx)
+	if caller == "" || caller == b.OwnerID {
+		return nil
+	}
+
+	return myerror.NewForbiddenError("authorizeOwner: only the owner manages the members of book %s", b.ID)
+}
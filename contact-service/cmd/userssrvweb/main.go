@@ -1,18 +1,273 @@
 package main
 
 import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	goredis "github.com/redis/go-redis/v9"
+
+	"contact-service/apikey"
+	"contact-service/apikeymanaging"
+	"contact-service/bookmanaging"
+	"contact-service/config"
 	"contact-service/contactmanaging"
+	"contact-service/disk"
+	"contact-service/encryption"
 	"contact-service/inmem"
+	"contact-service/jwt"
+	"contact-service/mongo"
+	"contact-service/opentelemetry"
+	"contact-service/privacymanaging"
+	"contact-service/prometheus"
+	"contact-service/redis"
+	"contact-service/sharemanaging"
+	"contact-service/sqlite"
 	"contact-service/stdout"
+	"contact-service/tenant"
 )
 
 func main() {
-	logger := stdout.NewLogger()
-	inmemLockCache := inmem.NewLockCache()
-	inmemRepo := inmem.NewUserRepository()
-	inmemLRUCacheRepo := inmem.NewLRUCacheRepository(inmemRepo, 5, logger)
+	cfg, err := config.Load(os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	var logger contactmanaging.Logger = stdout.NewJSONLogger(os.Stdout, cfg.LogLevel, cfg.LogSampleEvery)
+	if cfg.LogFormat == config.LogFormatText {
+		logger = stdout.NewLoggerWithLevel(cfg.LogLevel)
+	}
+	if fields := cfg.RedactedFields(); len(fields) > 0 {
+		logger = stdout.NewRedactingLogger(logger, fields)
+	}
+
+	ctx := context.Background()
+	logger.Info(ctx, "effective config", "config", cfg.String())
+
+	tracerProvider, err := opentelemetry.NewTracerProvider(ctx, cfg.Tracing.Exporter, cfg.Tracing.OTLPEndpoint, cfg.ServiceName, cfg.Tracing.SampleRatio)
+	if err != nil {
+		logger.Error(ctx, err)
+		os.Exit(1)
+	}
+
+	registry := prom.NewRegistry()
+	registry.MustRegister(collectors.NewGoCollector(), collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+
+	// resources are closed on shutdown in the order they are appended: repository, cache, then lock backends
+	var resources []contactmanaging.Resource
+
+	var redisClient *goredis.Client
+	if cfg.Redis.Addr != "" {
+		redisClient = goredis.NewClient(&goredis.Options{Addr: cfg.Redis.Addr})
+	}
+
+	store, err := newRepository(ctx, cfg.Storage, logger)
+	if err != nil {
+		logger.Error(ctx, err)
+		os.Exit(1)
+	}
+	if closer, ok := store.(interface{ Close() error }); ok {
+		resources = append(resources, contactmanaging.Resource{Name: "repository", Close: ignoreContext(closer.Close)})
+	} else if closer, ok := store.(interface{ Close(context.Context) error }); ok {
+		resources = append(resources, contactmanaging.Resource{Name: "repository", Close: closer.Close})
+	}
+
+	healthComponents := map[string]interface{}{
+		"repository": store,
+	}
+
+	// storage metrics wrap the backend itself, so cache hits are not counted as repository calls
+	var repo inmem.Repository = prometheus.NewRepository(store, registry)
+	repo = opentelemetry.NewRepository(repo, tracerProvider)
+
+	// with the redis cache enabled the local LRU is the first cache tier and Redis the second, shared by all replicas
+	if cfg.Cache.Redis {
+		repo = redis.NewCacheRepository(repo, redisClient, cfg.Cache.RedisTTL, logger)
+		healthComponents["redisCache"] = repo
+	}
+
+	lruCacheRepo := inmem.NewShardedLRUCacheRepository(repo, cfg.Cache.Shards, cfg.Cache.Capacity, cfg.Cache.TTL, cfg.Cache.NegativeTTL, logger)
+	healthComponents["cache"] = lruCacheRepo
+	registry.MustRegister(prometheus.NewCacheCollector(lruCacheRepo.Stats))
+
+	if cfg.Cache.Redis {
+		subscriptionCtx, stopSubscription := context.WithCancel(ctx)
+		if err := redis.SubscribeInvalidations(subscriptionCtx, redisClient, logger, lruCacheRepo.Invalidate); err != nil {
+			logger.Error(ctx, err)
+			os.Exit(1)
+		}
+		resources = append(resources, contactmanaging.Resource{Name: "cache invalidations", Close: func(context.Context) error {
+			stopSubscription()
+			return nil
+		}})
+	}
+
+	var lockCache contactmanaging.LockCache = inmem.NewLockCache()
+	if cfg.Lock.Backend == config.LockRedis {
+		redisLockCache, err := redis.NewLockCache(redisClient, cfg.Lock.TTL, logger)
+		if err != nil {
+			logger.Error(ctx, err)
+			os.Exit(1)
+		}
+		lockCache = redisLockCache
+		resources = append(resources, contactmanaging.Resource{Name: "lock", Close: redisLockCache.Close})
+	}
+
+	healthComponents["lock"] = lockCache
 
-	service := contactmanaging.NewService(inmemLRUCacheRepo, inmemLockCache, logger)
+	if redisClient != nil {
+		resources = append(resources, contactmanaging.Resource{Name: "redis", Close: ignoreContext(redisClient.Close)})
+	}
+
+	lockCache = prometheus.NewLockCache(lockCache, registry)
+	lockCache = opentelemetry.NewLockCache(lockCache, tracerProvider)
+
+	// contacts are encrypted above the cache, so neither the cache nor the repository holds them in clear
+	var contactRepo contactmanaging.Repository = lruCacheRepo
+	if cfg.Encryption.KeyringFile != "" {
+		keyring, err := encryption.LoadKeyring(cfg.Encryption.KeyringFile)
+		if err != nil {
+			logger.Error(ctx, err)
+			os.Exit(1)
+		}
+		encryptionRepo := encryption.NewRepository(contactRepo, keyring, logger)
+		contactRepo = encryptionRepo
+
+		// the re-encryption writes through the repository, so it is stopped before anything else is closed
+		resources = append([]contactmanaging.Resource{{Name: "re-encryption", Close: encryptionRepo.Close}}, resources...)
+	}
+	// shares and books are kept by the storage backend when it persists, so they outlive restarts like the contacts
+	// they grant access to
+	var shareRepo tenant.ShareRepository = inmem.NewShareRepository()
+	if persistent, ok := store.(tenant.ShareRepository); ok {
+		shareRepo = persistent
+	}
+	var bookRepo tenant.BookRepository = inmem.NewBookRepository()
+	if persistent, ok := store.(tenant.BookRepository); ok {
+		bookRepo = persistent
+	}
+	var tenancy gin.HandlerFunc
+	if cfg.Tenancy.Enabled {
+		quotas := func(tenantID string) tenant.Quota {
+			q := cfg.Tenancy.Quota(tenantID)
+			return tenant.Quota{MaxContacts: q.MaxContacts, RequestsPerSecond: q.RequestsPerSecond, Burst: q.Burst}
+		}
+		// the contacts quota counts the contacts in storage, below every cache
+		contactRepo = tenant.NewRepository(contactRepo, store, quotas)
+		lockCache = tenant.NewLockCache(lockCache)
+		shareRepo = tenant.NewShareRepository(shareRepo)
+		bookRepo = tenant.NewBookRepository(bookRepo)
+		tenancy = tenant.NewHTTPMiddleware(cfg.Tenancy.Header, quotas)
+	}
+
+	var service contactmanaging.Service = contactmanaging.NewService(contactRepo, shareRepo, lockCache, logger)
+	service = prometheus.NewService(service, registry)
+	service = opentelemetry.NewService(service, tracerProvider)
+
+	// the tracer provider is flushed last, after the requests drained on shutdown have ended their spans
+	resources = append(resources, contactmanaging.Resource{Name: "tracing", Close: tracerProvider.Shutdown})
+
+	var authentication, adminAuthentication gin.HandlerFunc
+	if cfg.Auth.JWTEnabled() {
+		var tenantClaim string
+		if cfg.Tenancy.Enabled {
+			tenantClaim = cfg.Tenancy.Claim
+		}
+		authenticator, err := jwt.NewAuthenticator(cfg.Auth.JWTSecret, cfg.Auth.JWKSFile, cfg.Auth.Issuer, cfg.Auth.Audience, tenantClaim)
+		if err != nil {
+			logger.Error(ctx, err)
+			os.Exit(1)
+		}
+		authentication = jwt.NewHTTPMiddleware(authenticator)
+	}
+
+	// erasure receipts are kept by the storage backend when it persists, so they outlive restarts like the contacts
+	var receipts privacymanaging.ReceiptRepository = inmem.NewErasureRepository()
+	if persistent, ok := store.(privacymanaging.ReceiptRepository); ok {
+		receipts = persistent
+	}
+	privacyService := privacymanaging.NewService(contactRepo, shareRepo, bookRepo, receipts, logger)
+
+	adminRoutes := []func(gin.IRouter){
+		func(r gin.IRouter) { privacymanaging.RegisterAdminHTTPEndpoints(r, privacyService) },
+	}
+	if cfg.Auth.APIKeys {
+		// the hashes of the keys are kept by the storage backend when it persists, so created keys outlive restarts
+		var apiKeyRepo apikeymanaging.Repository = inmem.NewAPIKeyRepository()
+		if persistent, ok := store.(apikeymanaging.Repository); ok {
+			apiKeyRepo = persistent
+		}
+		apiKeyService := apikeymanaging.NewService(apiKeyRepo)
+		if _, err := apiKeyService.ImportKey(ctx, apikey.APIKey{Name: "bootstrap admin", Scopes: []string{apikey.ScopeAdmin}}, cfg.Auth.AdminAPIKey); err != nil {
+			logger.Error(ctx, err)
+			os.Exit(1)
+		}
+
+		// API keys are accepted everywhere, user tokens only on the contacts routes
+		authentication = apikeymanaging.NewHTTPMiddleware(apiKeyService, authentication)
+		adminAuthentication = apikeymanaging.NewHTTPMiddleware(apiKeyService, nil)
+		adminRoutes = append(adminRoutes, func(r gin.IRouter) { apikeymanaging.RegisterHTTPEndpoints(r, apiKeyService) })
+	}
+
+	handler := contactmanaging.NewHTTPHandler(service, contactmanaging.HTTPOptions{
+		CacheStats:       func() interface{} { return lruCacheRepo.Stats() },
+		HealthComponents: healthComponents,
+		AccessLogger:     logger,
+		Logger:           logger,
+		Authentication:   authentication,
+		Tenancy:          tenancy,
+		Routes: []func(gin.IRouter){
+			func(r gin.IRouter) {
+				sharemanaging.RegisterHTTPEndpoints(r, sharemanaging.NewService(shareRepo, contactRepo))
+			},
+			func(r gin.IRouter) {
+				bookmanaging.RegisterHTTPEndpoints(r, bookmanaging.NewService(bookRepo), service)
+			},
+			func(r gin.IRouter) {
+				privacymanaging.RegisterHTTPEndpoints(r, privacyService)
+			},
+		},
+		AdminAuthentication: adminAuthentication,
+		AdminRoutes:         adminRoutes,
+		Middlewares: []gin.HandlerFunc{
+			opentelemetry.NewHTTPMiddleware(tracerProvider),
+			prometheus.NewHTTPMiddleware(registry),
+		},
+		Metrics: prometheus.NewHandler(registry),
+	})
+
+	server := contactmanaging.NewServer(cfg.Addr(), handler, cfg.ShutdownTimeout, logger, resources...)
+	if err := server.Run(ctx); err != nil {
+		logger.Error(ctx, err)
+		os.Exit(1)
+	}
+}
+
+// storage is a contacts backend, which also counts the contacts of every tenant
+type storage interface {
+	inmem.Repository
+	tenant.Counter
+}
+
+func newRepository(ctx context.Context, cfg config.StorageConfig, logger contactmanaging.Logger) (storage, error) {
+	switch cfg.Backend {
+	case config.StorageDisk:
+		return disk.NewRepository(cfg.DataDir, cfg.SnapshotEvery, logger)
+	case config.StorageSQLite:
+		return sqlite.NewRepository(ctx, cfg.SQLitePath)
+	case config.StorageMongo:
+		return mongo.NewRepository(ctx, cfg.MongoURI, cfg.MongoDatabase)
+	default:
+		return inmem.NewUserRepository(), nil
+	}
+}
 
-	contactmanaging.ServeHTTP(service)
+func ignoreContext(closeFunc func() error) func(context.Context) error {
+	return func(context.Context) error {
+		return closeFunc()
+	}
 }
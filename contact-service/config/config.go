@@ -0,0 +1,605 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"infrastructure/myerror"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	StorageMemory = "memory"
+	StorageDisk   = "disk"
+	StorageSQLite = "sqlite"
+	StorageMongo  = "mongo"
+
+	LockMemory = "memory"
+	LockRedis  = "redis"
+
+	LogLevelDebug   = "debug"
+	LogLevelInfo    = "info"
+	LogLevelWarning = "warning"
+	LogLevelError   = "error"
+
+	LogFormatJSON = "json"
+	LogFormatText = "text"
+
+	RedactPhone     = "phone"
+	RedactFirstName = "firstName"
+	RedactLastName  = "lastName"
+	RedactAddress   = "address"
+	RedactNone      = "none"
+
+	TracingNone   = "none"
+	TracingStdout = "stdout"
+	TracingOTLP   = "otlp"
+)
+
+// minAdminAPIKeyLength keeps the bootstrap key as hard to guess as the generated ones
+const minAdminAPIKeyLength = 32
+
+type Config struct {
+	Env             string              `yaml:"env"`
+	ServiceName     string              `yaml:"serviceName"`
+	Port            string              `yaml:"port"`
+	LogLevel        string              `yaml:"logLevel"`
+	LogFormat       string              `yaml:"logFormat"`
+	LogSampleEvery  int                 `yaml:"logSampleEvery"`
+	LogRedact       []string            `yaml:"logRedact"`
+	LogRedactByEnv  map[string][]string `yaml:"logRedactByEnv"`
+	ShutdownTimeout time.Duration       `yaml:"shutdownTimeout"`
+	Storage         StorageConfig       `yaml:"storage"`
+	Cache           CacheConfig         `yaml:"cache"`
+	Lock            LockConfig          `yaml:"lock"`
+	Redis           RedisConfig         `yaml:"redis"`
+	Tracing         TracingConfig       `yaml:"tracing"`
+	Auth            AuthConfig          `yaml:"auth"`
+	Tenancy         TenancyConfig       `yaml:"tenancy"`
+	Encryption      EncryptionConfig    `yaml:"encryption"`
+}
+
+type StorageConfig struct {
+	Backend       string `yaml:"backend"`
+	DataDir       string `yaml:"dataDir"`
+	SnapshotEvery int    `yaml:"snapshotEvery"`
+	SQLitePath    string `yaml:"sqlitePath"`
+	MongoURI      string `yaml:"mongoURI"`
+	MongoDatabase string `yaml:"mongoDatabase"`
+}
+
+type CacheConfig struct {
+	Capacity    int           `yaml:"capacity"`
+	Shards      int           `yaml:"shards"`
+	TTL         time.Duration `yaml:"ttl"`
+	NegativeTTL time.Duration `yaml:"negativeTTL"`
+	Redis       bool          `yaml:"redis"`
+	RedisTTL    time.Duration `yaml:"redisTTL"`
+}
+
+type LockConfig struct {
+	Backend string        `yaml:"backend"`
+	TTL     time.Duration `yaml:"ttl"`
+}
+
+type RedisConfig struct {
+	Addr string `yaml:"addr"`
+}
+
+// AuthConfig enables JWT authentication when a secret or a JWKS file is set. With APIKeys enabled, machine clients
+// authenticate with API keys created through the admin endpoints, starting from the AdminAPIKey.
+type AuthConfig struct {
+	JWTSecret   string `yaml:"jwtSecret"`
+	JWKSFile    string `yaml:"jwksFile"`
+	Issuer      string `yaml:"issuer"`
+	Audience    string `yaml:"audience"`
+	APIKeys     bool   `yaml:"apiKeys"`
+	AdminAPIKey string `yaml:"adminAPIKey"`
+}
+
+// JWTEnabled tells whether users authenticate with tokens
+func (c AuthConfig) JWTEnabled() bool {
+	return c.JWTSecret != "" || c.JWKSFile != ""
+}
+
+// TenancyConfig isolates the data of every tenant, named by the tenant claim of the caller's token or else by the
+// request header. The quotas apply to every tenant, Quotas overrides them per tenant, and zero is unlimited.
+type TenancyConfig struct {
+	Enabled           bool                   `yaml:"enabled"`
+	Header            string                 `yaml:"header"`
+	Claim             string                 `yaml:"claim"`
+	MaxContacts       int                    `yaml:"maxContacts"`
+	RequestsPerSecond float64                `yaml:"requestsPerSecond"`
+	Burst             int                    `yaml:"burst"`
+	Quotas            map[string]QuotaConfig `yaml:"quotas"`
+}
+
+// EncryptionConfig encrypts the contacts at rest with the keys of the keyring file when it is set
+type EncryptionConfig struct {
+	KeyringFile string `yaml:"keyringFile"`
+}
+
+type QuotaConfig struct {
+	MaxContacts       int     `yaml:"maxContacts"`
+	RequestsPerSecond float64 `yaml:"requestsPerSecond"`
+	Burst             int     `yaml:"burst"`
+}
+
+// Quota returns the quotas of the tenant, the fields its override leaves at zero keep the default
+func (c TenancyConfig) Quota(tenantID string) QuotaConfig {
+	q := QuotaConfig{
+		MaxContacts:       c.MaxContacts,
+		RequestsPerSecond: c.RequestsPerSecond,
+		Burst:             c.Burst,
+	}
+
+	override := c.Quotas[tenantID]
+	if override.MaxContacts != 0 {
+		q.MaxContacts = override.MaxContacts
+	}
+	if override.RequestsPerSecond != 0 {
+		q.RequestsPerSecond = override.RequestsPerSecond
+	}
+	if override.Burst != 0 {
+		q.Burst = override.Burst
+	}
+
+	return q
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter"`
+	OTLPEndpoint string  `yaml:"otlpEndpoint"`
+	SampleRatio  float64 `yaml:"sampleRatio"`
+}
+
+func Default() Config {
+	return Config{
+		Env:             "development",
+		ServiceName:     "contact-service",
+		Port:            "8080",
+		LogLevel:        LogLevelInfo,
+		LogFormat:       LogFormatJSON,
+		LogSampleEvery:  1,
+		LogRedact:       []string{RedactPhone, RedactFirstName, RedactLastName, RedactAddress},
+		ShutdownTimeout: 15 * time.Second,
+		Storage: StorageConfig{
+			Backend:       StorageMemory,
+			DataDir:       "data",
+			SnapshotEvery: 1000,
+			SQLitePath:    "contacts.db",
+			MongoDatabase: "contact-service",
+		},
+		Cache: CacheConfig{
+			Capacity:    1000,
+			Shards:      16,
+			TTL:         time.Minute,
+			NegativeTTL: 5 * time.Second,
+			RedisTTL:    10 * time.Minute,
+		},
+		Lock: LockConfig{
+			Backend: LockMemory,
+			TTL:     10 * time.Second,
+		},
+		Tracing: TracingConfig{
+			Exporter:     TracingNone,
+			OTLPEndpoint: "localhost:4318",
+			SampleRatio:  1,
+		},
+		Tenancy: TenancyConfig{
+			Header: "X-Tenant-ID",
+			Claim:  "tenant",
+		},
+	}
+}
+
+// Load builds the configuration from the defaults, then the optional YAML file, then the environment and finally
+// the command line flags, each source overriding the previous one. The file is taken from -config or CONFIG_FILE.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("contact-service", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML configuration file")
+	overrides := bindFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, myerror.NewBadRequestError("config.Load: %v", err)
+	}
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return Config{}, myerror.Wrap(err, "config.Load")
+		}
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return Config{}, myerror.Wrap(err, "config.Load")
+	}
+
+	// only flags given explicitly override, so their defaults never mask the file or the environment
+	var flagErr error
+	fs.Visit(func(f *flag.Flag) {
+		if apply, ok := overrides[f.Name]; ok && flagErr == nil {
+			flagErr = apply(&cfg, f.Value.String())
+		}
+	})
+	if flagErr != nil {
+		return Config{}, myerror.Wrap(flagErr, "config.Load")
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, myerror.Wrap(err, "config.Load")
+	}
+
+	return cfg, nil
+}
+
+// RedactedFields are the contact fields masked in the logs, LogRedactByEnv overrides LogRedact for the environments
+// it lists
+func (c Config) RedactedFields() []string {
+	fields := c.LogRedact
+	if byEnv, ok := c.LogRedactByEnv[c.Env]; ok {
+		fields = byEnv
+	}
+
+	var redacted []string
+	for _, field := range fields {
+		if field != RedactNone {
+			redacted = append(redacted, field)
+		}
+	}
+
+	return redacted
+}
+
+// Addr is the address the HTTP server listens on, the port may be given with or without a leading colon
+func (c Config) Addr() string {
+	return ":" + strings.TrimPrefix(c.Port, ":")
+}
+
+func (c Config) Validate() error {
+	var errorMessages []string
+
+	if port, err := strconv.Atoi(strings.TrimPrefix(c.Port, ":")); err != nil || port < 1 || port > 65535 {
+		errorMessages = append(errorMessages, fmt.Sprintf("port %q must be a number between 1 and 65535", c.Port))
+	}
+
+	if !oneOf(c.LogLevel, LogLevelDebug, LogLevelInfo, LogLevelWarning, LogLevelError) {
+		errorMessages = append(errorMessages, fmt.Sprintf("logLevel %q must be one of debug, info, warning, error", c.LogLevel))
+	}
+
+	if !oneOf(c.LogFormat, LogFormatJSON, LogFormatText) {
+		errorMessages = append(errorMessages, fmt.Sprintf("logFormat %q must be one of json, text", c.LogFormat))
+	}
+	if c.LogSampleEvery < 1 {
+		errorMessages = append(errorMessages, "logSampleEvery must be positive")
+	}
+
+	for _, fields := range append([][]string{c.LogRedact}, mapValues(c.LogRedactByEnv)...) {
+		for _, field := range fields {
+			if !oneOf(field, RedactPhone, RedactFirstName, RedactLastName, RedactAddress, RedactNone) {
+				errorMessages = append(errorMessages, fmt.Sprintf("logRedact field %q must be one of phone, firstName, lastName, address, none", field))
+			}
+		}
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		errorMessages = append(errorMessages, "shutdownTimeout must be positive")
+	}
+
+	switch c.Storage.Backend {
+	case StorageMemory:
+	case StorageDisk:
+		if c.Storage.DataDir == "" {
+			errorMessages = append(errorMessages, "storage.dataDir is required for the disk backend")
+		}
+		if c.Storage.SnapshotEvery < 0 {
+			errorMessages = append(errorMessages, "storage.snapshotEvery must not be negative")
+		}
+	case StorageSQLite:
+		if c.Storage.SQLitePath == "" {
+			errorMessages = append(errorMessages, "storage.sqlitePath is required for the sqlite backend")
+		}
+	case StorageMongo:
+		if c.Storage.MongoURI == "" || c.Storage.MongoDatabase == "" {
+			errorMessages = append(errorMessages, "storage.mongoURI and storage.mongoDatabase are required for the mongo backend")
+		}
+	default:
+		errorMessages = append(errorMessages, fmt.Sprintf("storage.backend %q must be one of memory, disk, sqlite, mongo", c.Storage.Backend))
+	}
+
+	if c.Cache.Capacity < 1 {
+		errorMessages = append(errorMessages, "cache.capacity must be positive")
+	}
+	if c.Cache.Shards < 1 {
+		errorMessages = append(errorMessages, "cache.shards must be positive")
+	}
+	if c.Cache.TTL < 0 || c.Cache.NegativeTTL < 0 || c.Cache.RedisTTL < 0 {
+		errorMessages = append(errorMessages, "cache ttls must not be negative")
+	}
+
+	switch c.Lock.Backend {
+	case LockMemory, LockRedis:
+	default:
+		errorMessages = append(errorMessages, fmt.Sprintf("lock.backend %q must be one of memory, redis", c.Lock.Backend))
+	}
+	if c.Lock.Backend == LockRedis && c.Lock.TTL < time.Millisecond {
+		errorMessages = append(errorMessages, "lock.ttl must be at least 1ms for the redis backend")
+	}
+
+	if (c.Lock.Backend == LockRedis || c.Cache.Redis) && c.Redis.Addr == "" {
+		errorMessages = append(errorMessages, "redis.addr is required when the redis lock or cache is enabled")
+	}
+
+	if c.Auth.APIKeys && len(c.Auth.AdminAPIKey) < minAdminAPIKeyLength {
+		errorMessages = append(errorMessages, fmt.Sprintf("auth.adminAPIKey of at least %d characters is required when auth.apiKeys is enabled", minAdminAPIKeyLength))
+	}
+
+	if !oneOf(c.Tracing.Exporter, TracingNone, TracingStdout, TracingOTLP) {
+		errorMessages = append(errorMessages, fmt.Sprintf("tracing.exporter %q must be one of none, stdout, otlp", c.Tracing.Exporter))
+	}
+	if c.Tracing.Exporter == TracingOTLP && c.Tracing.OTLPEndpoint == "" {
+		errorMessages = append(errorMessages, "tracing.otlpEndpoint is required for the otlp exporter")
+	}
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errorMessages = append(errorMessages, "tracing.sampleRatio must be between 0 and 1")
+	}
+
+	if c.Tenancy.Enabled && c.Tenancy.Header == "" {
+		errorMessages = append(errorMessages, "tenancy.header is required when tenancy is enabled")
+	}
+	for tenantID, q := range c.Tenancy.Quotas {
+		if q.MaxContacts < 0 || q.RequestsPerSecond < 0 || q.Burst < 0 {
+			errorMessages = append(errorMessages, fmt.Sprintf("tenancy.quotas of %q must not be negative", tenantID))
+		}
+	}
+	if c.Tenancy.MaxContacts < 0 || c.Tenancy.RequestsPerSecond < 0 || c.Tenancy.Burst < 0 {
+		errorMessages = append(errorMessages, "tenancy quotas must not be negative")
+	}
+
+	if len(errorMessages) > 0 {
+		return myerror.NewBadRequestError("invalid config: %s", strings.Join(errorMessages, ", "))
+	}
+
+	return nil
+}
+
+// String renders the effective configuration as YAML with credentials masked
+func (c Config) String() string {
+	if u, err := url.Parse(c.Storage.MongoURI); err == nil && u.User != nil {
+		u.User = url.User("xxxxx")
+		c.Storage.MongoURI = u.String()
+	}
+	if c.Auth.JWTSecret != "" {
+		c.Auth.JWTSecret = "xxxxx"
+	}
+	if c.Auth.AdminAPIKey != "" {
+		c.Auth.AdminAPIKey = "xxxxx"
+	}
+
+	node, err := yamlNode(reflect.ValueOf(c))
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+	out, err := yaml.Marshal(node)
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+
+	return string(out)
+}
+
+// yamlNode encodes the value like yaml.Marshal but writes durations as 30s rather than nanoseconds, the way they are
+// written in the file
+func yamlNode(v reflect.Value) (*yaml.Node, error) {
+	if d, ok := v.Interface().(time.Duration); ok {
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: d.String()}, nil
+	}
+	if v.Kind() != reflect.Struct {
+		node := &yaml.Node{}
+		if err := node.Encode(v.Interface()); err != nil {
+			return nil, myerror.Wrap(err, "yamlNode")
+		}
+		return node, nil
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		value, err := yamlNode(v.Field(i))
+		if err != nil {
+			return nil, myerror.Wrap(err, "yamlNode")
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: name}, value)
+	}
+
+	return node, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return myerror.Wrap(err, "loadFile")
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil {
+		return myerror.NewBadRequestError("loadFile: %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// setter parses a raw value from the environment or a flag into the configuration
+type setter func(cfg *Config, value string) error
+
+// settings maps every environment variable to its flag name and setter
+var settings = []struct {
+	env   string
+	flag  string
+	usage string
+	set   setter
+}{
+	{"ENV", "env", "deployment environment", setString(func(c *Config) *string { return &c.Env })},
+	{"SERVICE_NAME", "service-name", "service name", setString(func(c *Config) *string { return &c.ServiceName })},
+	{"PORT", "port", "HTTP port", setString(func(c *Config) *string { return &c.Port })},
+	{"LOG_LEVEL", "log-level", "minimum log level: debug, info, warning, error", setString(func(c *Config) *string { return &c.LogLevel })},
+	{"LOG_FORMAT", "log-format", "log line format: json, text", setString(func(c *Config) *string { return &c.LogFormat })},
+	{"LOG_SAMPLE_EVERY", "log-sample-every", "keep one in every n debug lines of the same message", setInt(func(c *Config) *int { return &c.LogSampleEvery })},
+	{"LOG_REDACT", "log-redact", "comma separated contact fields masked in the logs, or none", setStrings(func(c *Config) *[]string { return &c.LogRedact })},
+	{"SHUTDOWN_TIMEOUT", "shutdown-timeout", "time to drain requests and close resources on shutdown", setDuration(func(c *Config) *time.Duration { return &c.ShutdownTimeout })},
+	{"STORAGE_BACKEND", "storage", "storage backend: memory, disk, sqlite, mongo", setString(func(c *Config) *string { return &c.Storage.Backend })},
+	{"DATA_DIR", "data-dir", "directory of the disk backend", setString(func(c *Config) *string { return &c.Storage.DataDir })},
+	{"SNAPSHOT_EVERY", "snapshot-every", "write-ahead log records between snapshots of the disk backend", setInt(func(c *Config) *int { return &c.Storage.SnapshotEvery })},
+	{"SQLITE_PATH", "sqlite-path", "database file of the sqlite backend", setString(func(c *Config) *string { return &c.Storage.SQLitePath })},
+	{"MONGO_URI", "mongo-uri", "connection string of the mongo backend", setString(func(c *Config) *string { return &c.Storage.MongoURI })},
+	{"MONGO_DATABASE", "mongo-database", "database of the mongo backend", setString(func(c *Config) *string { return &c.Storage.MongoDatabase })},
+	{"CACHE_CAPACITY", "cache-capacity", "number of entries held by the local cache", setInt(func(c *Config) *int { return &c.Cache.Capacity })},
+	{"CACHE_SHARDS", "cache-shards", "number of local cache segments", setInt(func(c *Config) *int { return &c.Cache.Shards })},
+	{"CACHE_TTL", "cache-ttl", "time to live of local cache entries", setDuration(func(c *Config) *time.Duration { return &c.Cache.TTL })},
+	{"CACHE_NEGATIVE_TTL", "cache-negative-ttl", "time to remember contacts that were not found", setDuration(func(c *Config) *time.Duration { return &c.Cache.NegativeTTL })},
+	{"CACHE_REDIS", "cache-redis", "cache contacts in redis behind the local cache", setBool(func(c *Config) *bool { return &c.Cache.Redis })},
+	{"CACHE_REDIS_TTL", "cache-redis-ttl", "time to live of redis cache entries", setDuration(func(c *Config) *time.Duration { return &c.Cache.RedisTTL })},
+	{"LOCK_BACKEND", "lock-backend", "lock backend: memory, redis", setString(func(c *Config) *string { return &c.Lock.Backend })},
+	{"LOCK_TTL", "lock-ttl", "lease time of redis locks", setDuration(func(c *Config) *time.Duration { return &c.Lock.TTL })},
+	{"REDIS_ADDR", "redis-addr", "redis address", setString(func(c *Config) *string { return &c.Redis.Addr })},
+	{"JWT_SECRET", "jwt-secret", "HMAC secret of HS256 tokens", setString(func(c *Config) *string { return &c.Auth.JWTSecret })},
+	{"JWKS_FILE", "jwks-file", "JWKS file with the public keys of RS256 tokens", setString(func(c *Config) *string { return &c.Auth.JWKSFile })},
+	{"JWT_ISSUER", "jwt-issuer", "required issuer of tokens", setString(func(c *Config) *string { return &c.Auth.Issuer })},
+	{"JWT_AUDIENCE", "jwt-audience", "required audience of tokens", setString(func(c *Config) *string { return &c.Auth.Audience })},
+	{"API_KEYS", "api-keys", "authenticate machine clients with API keys", setBool(func(c *Config) *bool { return &c.Auth.APIKeys })},
+	{"ADMIN_API_KEY", "admin-api-key", "bootstrap API key with the admin scope", setString(func(c *Config) *string { return &c.Auth.AdminAPIKey })},
+	{"TRACING_EXPORTER", "tracing-exporter", "span exporter: none, stdout, otlp", setString(func(c *Config) *string { return &c.Tracing.Exporter })},
+	{"OTLP_ENDPOINT", "otlp-endpoint", "host:port of the OTLP/HTTP collector", setString(func(c *Config) *string { return &c.Tracing.OTLPEndpoint })},
+	{"TENANCY", "tenancy", "isolate the data of the tenants", setBool(func(c *Config) *bool { return &c.Tenancy.Enabled })},
+	{"TENANT_HEADER", "tenant-header", "request header naming the tenant", setString(func(c *Config) *string { return &c.Tenancy.Header })},
+	{"TENANT_CLAIM", "tenant-claim", "token claim naming the tenant", setString(func(c *Config) *string { return &c.Tenancy.Claim })},
+	{"TENANT_MAX_CONTACTS", "tenant-max-contacts", "contacts every tenant may store, 0 is unlimited", setInt(func(c *Config) *int { return &c.Tenancy.MaxContacts })},
+	{"TENANT_RATE_LIMIT", "tenant-rate-limit", "requests per second of every tenant, 0 is unlimited", setFloat(func(c *Config) *float64 { return &c.Tenancy.RequestsPerSecond })},
+	{"TENANT_RATE_BURST", "tenant-rate-burst", "requests a tenant may send at once above its rate", setInt(func(c *Config) *int { return &c.Tenancy.Burst })},
+	{"ENCRYPTION_KEYRING", "encryption-keyring", "keyring file with the keys encrypting the contacts at rest", setString(func(c *Config) *string { return &c.Encryption.KeyringFile })},
+	{"TRACING_SAMPLE_RATIO", "tracing-sample-ratio", "fraction of new traces that are sampled", setFloat(func(c *Config) *float64 { return &c.Tracing.SampleRatio })},
+}
+
+func bindFlags(fs *flag.FlagSet) map[string]setter {
+	overrides := make(map[string]setter, len(settings))
+	for _, s := range settings {
+		fs.String(s.flag, "", s.usage+" (env "+s.env+")")
+		overrides[s.flag] = s.set
+	}
+
+	return overrides
+}
+
+func loadEnv(cfg *Config) error {
+	for _, s := range settings {
+		value, ok := os.LookupEnv(s.env)
+		if !ok || value == "" {
+			continue
+		}
+
+		if err := s.set(cfg, value); err != nil {
+			return myerror.Wrap(err, "loadEnv: %s", s.env)
+		}
+	}
+
+	return nil
+}
+
+func setString(field func(*Config) *string) setter {
+	return func(cfg *Config, value string) error {
+		*field(cfg) = value
+		return nil
+	}
+}
+
+func setStrings(field func(*Config) *[]string) setter {
+	return func(cfg *Config, value string) error {
+		var values []string
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+		*field(cfg) = values
+		return nil
+	}
+}
+
+func setInt(field func(*Config) *int) setter {
+	return func(cfg *Config, value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return myerror.NewBadRequestError("invalid number %q", value)
+		}
+		*field(cfg) = v
+		return nil
+	}
+}
+
+func setBool(field func(*Config) *bool) setter {
+	return func(cfg *Config, value string) error {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return myerror.NewBadRequestError("invalid boolean %q", value)
+		}
+		*field(cfg) = v
+		return nil
+	}
+}
+
+func setFloat(field func(*Config) *float64) setter {
+	return func(cfg *Config, value string) error {
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return myerror.NewBadRequestError("invalid number %q", value)
+		}
+		*field(cfg) = v
+		return nil
+	}
+}
+
+func setDuration(field func(*Config) *time.Duration) setter {
+	return func(cfg *Config, value string) error {
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return myerror.NewBadRequestError("invalid duration %q", value)
+		}
+		*field(cfg) = v
+		return nil
+	}
+}
+
+func oneOf(value string, allowed ...string) bool {
+	for _, a := range allowed {
+		if value == a {
+			return true
+		}
+	}
+
+	return false
+}
+
+func mapValues(m map[string][]string) [][]string {
+	values := make([][]string, 0, len(m))
+	for _, v := range m {
+		values = append(values, v)
+	}
+
+	return values
+}
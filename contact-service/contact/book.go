@@ -0,0 +1,70 @@
+package contact
+
+import (
+	"strings"
+	"time"
+)
+
+const (
+	RoleOwner  = "owner"
+	RoleEditor = "editor"
+	RoleViewer = "viewer"
+
+	bookOwnerPrefix = "book:"
+)
+
+// Book is an address book owned by a team, its contacts are stored under BookOwnerID(ID) in place of a user ID
+type Book struct {
+	ID        string
+	Name      string
+	OwnerID   string
+	Members   []Member
+	CreatedAt time.Time
+}
+
+// Member is a user invited to a book, who reaches its contacts once the invite is accepted
+type Member struct {
+	UserID    string
+	Role      string
+	InvitedAt time.Time
+	JoinedAt  time.Time
+}
+
+func (m Member) IsActive() bool {
+	return !m.JoinedAt.IsZero()
+}
+
+// Member returns the membership of the user, invited or active
+func (b Book) Member(userID string) (Member, bool) {
+	for _, m := range b.Members {
+		if m.UserID == userID {
+			return m, true
+		}
+	}
+
+	return Member{}, false
+}
+
+// Allows tells whether the user is an active member whose role grants permission on the contacts of the book,
+// viewers may only read
+func (b Book) Allows(userID, permission string) bool {
+	m, ok := b.Member(userID)
+	if !ok || !m.IsActive() {
+		return false
+	}
+
+	return m.Role != RoleViewer || permission == PermissionRead
+}
+
+func IsValidMemberRole(role string) bool {
+	return role == RoleEditor || role == RoleViewer
+}
+
+// BookOwnerID is the owner ID the contacts of the book are stored under
+func BookOwnerID(bookID string) string {
+	return bookOwnerPrefix + bookID
+}
+
+func IsBookOwnerID(ownerID string) bool {
+	return strings.HasPrefix(ownerID, bookOwnerPrefix)
+}
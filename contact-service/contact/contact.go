@@ -9,8 +9,10 @@ type Contact struct {
 	FirstName string
 	LastName  string
 	Address   string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	// EncryptedPhone holds the phone when the contact is encrypted at rest, Phone then holds its blind index
+	EncryptedPhone string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
 type Filters struct {
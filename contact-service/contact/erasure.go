@@ -0,0 +1,21 @@
+package contact
+
+import "time"
+
+// ErasureReceipt records that the data of a user was erased, without keeping any of it. Digest is the SHA-256 of the
+// erased record IDs, so an auditor holding a backup can check which records the erasure covered.
+type ErasureReceipt struct {
+	ID          string
+	UserID      string
+	TenantID    string
+	RequestedBy string
+	ErasedAt    time.Time
+
+	Contacts       int
+	BookContacts   int
+	SharesGranted  int
+	SharesReceived int
+	Memberships    int
+	Books          int
+	Digest         string
+}
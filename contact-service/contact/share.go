@@ -0,0 +1,27 @@
+package contact
+
+import "time"
+
+const (
+	PermissionRead = "read"
+	PermissionEdit = "edit"
+)
+
+// Share grants a user access to a contact of another user, or to all of their contacts when ContactID is empty
+type Share struct {
+	ID         string
+	OwnerID    string
+	ContactID  string
+	GranteeID  string
+	Permission string
+	CreatedAt  time.Time
+}
+
+// Allows tells whether the share grants permission on the contact, edit implies read
+func (s Share) Allows(contactID, permission string) bool {
+	if s.ContactID != "" && s.ContactID != contactID {
+		return false
+	}
+
+	return s.Permission == PermissionEdit || s.Permission == permission
+}
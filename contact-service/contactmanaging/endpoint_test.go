@@ -5,6 +5,7 @@ import (
 	"contact-service/stdout"
 	"context"
 	"testing"
+	"time"
 )
 
 func Test_endpointCreateContact(t *testing.T) {
@@ -60,7 +61,7 @@ func Test_endpointCreateContact(t *testing.T) {
 	logger := stdout.NewLogger()
 	inmemLockCache := inmem.NewLockCache()
 	inmemRepo := inmem.NewUserRepository()
-	inmemLRUCacheRepo := inmem.NewLRUCacheRepository(inmemRepo, 5, logger)
+	inmemLRUCacheRepo := inmem.NewLRUCacheRepository(inmemRepo, 5, time.Minute, 0, logger)
 
 	s := service{
 		repo:      inmemLRUCacheRepo,
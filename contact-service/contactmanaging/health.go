@@ -0,0 +1,79 @@
+package contactmanaging
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	HealthStatusUp        = "up"
+	HealthStatusDown      = "down"
+	HealthStatusUnchecked = "unchecked"
+
+	healthCheckTimeout = 2 * time.Second
+)
+
+// HealthChecker is optionally implemented by dependencies, such as a Repository or a LockCache, that can tell
+// whether they are currently usable
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+type ComponentHealth struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type HealthReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentHealth `json:"components,omitempty"`
+}
+
+// checkHealth checks every component concurrently. The report is down if any component is down, components that do
+// not implement HealthChecker are reported as unchecked and do not affect it.
+func checkHealth(ctx context.Context, components map[string]interface{}) HealthReport {
+	report := HealthReport{
+		Status:     HealthStatusUp,
+		Components: make(map[string]ComponentHealth, len(components)),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	// the unchecked components are reported before any check starts, the checks then write the report under the mutex
+	checkers := make(map[string]HealthChecker, len(components))
+	for name, component := range components {
+		if checker, ok := component.(HealthChecker); ok {
+			checkers[name] = checker
+		} else {
+			report.Components[name] = ComponentHealth{Status: HealthStatusUnchecked}
+		}
+	}
+
+	var (
+		mutex sync.Mutex
+		wg    sync.WaitGroup
+	)
+	for name, checker := range checkers {
+		wg.Add(1)
+		go func(name string, checker HealthChecker) {
+			defer wg.Done()
+
+			health := ComponentHealth{Status: HealthStatusUp}
+			if err := checker.HealthCheck(ctx); err != nil {
+				health = ComponentHealth{Status: HealthStatusDown, Error: err.Error()}
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			report.Components[name] = health
+			if health.Status == HealthStatusDown {
+				report.Status = HealthStatusDown
+			}
+		}(name, checker)
+	}
+	wg.Wait()
+
+	return report
+}
@@ -0,0 +1,52 @@
+package contactmanaging
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+type healthCheckerFunc func(ctx context.Context) error
+
+func (f healthCheckerFunc) HealthCheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+func Test_checkHealth(t *testing.T) {
+	up := healthCheckerFunc(func(context.Context) error { return nil })
+	down := healthCheckerFunc(func(context.Context) error { return errors.New("connection refused") })
+
+	tests := []struct {
+		name       string
+		components map[string]interface{}
+		want       string
+	}{
+		{
+			name:       "all components up",
+			components: map[string]interface{}{"repository": up, "lock": up},
+			want:       HealthStatusUp,
+		},
+		{
+			name:       "one component down",
+			components: map[string]interface{}{"repository": down, "lock": up},
+			want:       HealthStatusDown,
+		},
+		{
+			name:       "unchecked component does not affect the status",
+			components: map[string]interface{}{"repository": up, "cache": struct{}{}},
+			want:       HealthStatusUp,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := checkHealth(context.Background(), tt.components)
+			if report.Status != tt.want {
+				t.Errorf("checkHealth() status = %s, want %s", report.Status, tt.want)
+			}
+			if len(report.Components) != len(tt.components) {
+				t.Errorf("checkHealth() reported %d components, want %d", len(report.Components), len(tt.components))
+			}
+		})
+	}
+}
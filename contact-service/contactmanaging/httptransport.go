@@ -1,12 +1,14 @@
 package contactmanaging
 
 import (
+	"contact-service/apikey"
+	"contact-service/contact"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"infrastructure/myerror"
 	"infrastructure/myhttp"
+	"net/http"
 	"strconv"
-	"strings"
 	"time"
 )
 
@@ -17,21 +19,116 @@ const (
 	deleteContactURL                  = "/users/:userID/contacts/:contactID"
 	searchContactsURL                 = "/users/:userID/contacts"
 	searchContactsPaginationFormatURL = "%s?phone=%s&firstName=%s&lastName=%s&address=%s&limit=%d&offset=%d"
+	bookContactsURL                   = "/books/:bookID/contacts"
+	bookContactURL                    = "/books/:bookID/contacts/:contactID"
+	adminURL                          = "/admin"
+	cacheStatsURL                     = "/cache/stats"
+	adminHealthURL                    = "/health"
+	livenessURL                       = "/healthz"
+	readinessURL                      = "/readyz"
+	metricsURL                        = "/metrics"
 )
 
-func ServeHTTP(s Service) {
-	r := gin.Default()
+// StatsFunc reports runtime statistics of a component as a JSON-encodable value
+type StatsFunc func() interface{}
+
+// HTTPOptions are the optional parts of the HTTP API, a nil field disables its endpoints
+type HTTPOptions struct {
+	// CacheStats is served under /admin, only when AdminAuthentication is set
+	CacheStats StatsFunc
+	// HealthComponents are the dependencies checked by the readiness endpoint, by name. Those implementing
+	// HealthChecker are checked, the others are reported as unchecked.
+	HealthComponents map[string]interface{}
+	// AccessLogger writes one line per request instead of gin's default access log
+	AccessLogger Logger
+	// Logger records the errors of the health checks, which the unauthenticated readiness endpoint does not return
+	Logger Logger
+	// Authentication runs before the contacts routes only, so health checks and metrics stay reachable
+	Authentication gin.HandlerFunc
+	// Tenancy runs after Authentication, naming the tenant whose data the contacts routes reach
+	Tenancy gin.HandlerFunc
+	// Routes register the endpoints of other services next to the contacts routes, behind the same authentication
+	Routes []func(gin.IRouter)
+	// AdminAuthentication runs before the /admin routes, which also require the admin scope of scoped callers
+	AdminAuthentication gin.HandlerFunc
+	// AdminRoutes register the endpoints of other services under /admin, only when AdminAuthentication is set so they
+	// are never served to unauthenticated callers
+	AdminRoutes []func(gin.IRouter)
+	// Middlewares run before every route, in order
+	Middlewares []gin.HandlerFunc
+	Metrics     http.Handler
+}
+
+// NewHTTPHandler routes the contacts API to the service
+func NewHTTPHandler(s Service, opts HTTPOptions) http.Handler {
+	r := gin.New()
+	// handlers pass the gin context on as the context.Context, so it must expose the values set on the request
+	// context by the middlewares, e.g. the request ID and the trace span
+	r.ContextWithFallback = true
 
-	r.POST(createContactURL, makeHTTPEndpointCreateContact(s))
-	r.PUT(updateContactURL, makeHTTPEndpointUpdateContact(s))
-	r.GET(getContactURL, makeHTTPEndpointGetContact(s))
-	r.GET(searchContactsURL, makeHTTPEndpointSearchContacts(s))
-	r.DELETE(deleteContactURL, makeHTTPEndpointDeleteContact(s))
+	r.Use(gin.Recovery(), requestIDMiddleware())
+	if opts.AccessLogger != nil {
+		r.Use(accessLogMiddleware(opts.AccessLogger))
+	} else {
+		r.Use(gin.Logger())
+	}
+	r.Use(opts.Middlewares...)
 
-	fmt.Println("Server listening on port 8080")
-	if err := r.Run(":8080"); err != nil {
-		panic(err)
+	contacts := r.Group("")
+	if opts.Authentication != nil {
+		contacts.Use(opts.Authentication)
+	}
+	if opts.Tenancy != nil {
+		contacts.Use(opts.Tenancy)
+	}
+	contacts.Use(reserveBookOwnerIDs())
+
+	// getting, updating and searching contacts honour shares, so the service authorizes them
+	contacts.POST(createContactURL, RequireScope(apikey.ScopeWrite), AuthorizeUser(), makeHTTPEndpointCreateContact(s))
+	contacts.PUT(updateContactURL, RequireScope(apikey.ScopeWrite), makeHTTPEndpointUpdateContact(s))
+	contacts.GET(getContactURL, RequireScope(apikey.ScopeRead), makeHTTPEndpointGetContact(s))
+	contacts.GET(searchContactsURL, RequireScope(apikey.ScopeRead), makeHTTPEndpointSearchContacts(s))
+	contacts.DELETE(deleteContactURL, RequireScope(apikey.ScopeWrite), AuthorizeUser(), makeHTTPEndpointDeleteContact(s))
+	for _, register := range opts.Routes {
+		register(contacts)
 	}
+
+	r.GET(livenessURL, makeHTTPEndpointLiveness())
+	r.GET(readinessURL, makeHTTPEndpointReadiness(opts.HealthComponents, opts.Logger))
+
+	admin := r.Group(adminURL)
+	if opts.AdminAuthentication != nil {
+		admin.Use(opts.AdminAuthentication)
+	}
+	admin.Use(RequireScope(apikey.ScopeAdmin))
+
+	if opts.AdminAuthentication != nil {
+		if opts.CacheStats != nil {
+			admin.GET(cacheStatsURL, makeHTTPEndpointStats(opts.CacheStats))
+		}
+		admin.GET(adminHealthURL, makeHTTPEndpointAdminHealth(opts.HealthComponents))
+		for _, register := range opts.AdminRoutes {
+			register(admin)
+		}
+	}
+	if opts.Metrics != nil {
+		r.GET(metricsURL, gin.WrapH(opts.Metrics))
+	}
+
+	return r
+}
+
+// RegisterBookHTTPEndpoints serves the contacts of the address books under /books/:bookID/contacts with the same
+// handlers as the contacts of a user, authorize rejects the callers who may not use the book with the permission
+func RegisterBookHTTPEndpoints(r gin.IRouter, s Service, authorize func(permission string) gin.HandlerFunc) {
+	read := []gin.HandlerFunc{RequireScope(apikey.ScopeRead), authorize(contact.PermissionRead), bookOwnerMiddleware()}
+	write := []gin.HandlerFunc{RequireScope(apikey.ScopeWrite), authorize(contact.PermissionEdit), bookOwnerMiddleware()}
+
+	r.POST(bookContactsURL, append(write, makeHTTPEndpointCreateContact(s))...)
+	r.PUT(bookContactURL, append(write, makeHTTPEndpointUpdateContact(s))...)
+	r.GET(bookContactURL, append(read, makeHTTPEndpointGetContact(s))...)
+	r.GET(bookContactsURL, append(read, makeHTTPEndpointSearchContacts(s))...)
+	r.DELETE(bookContactURL, append(write, makeHTTPEndpointDeleteContact(s))...)
 }
 
 // Create
@@ -283,9 +380,10 @@ func decodeSearchContactsHTTPRequest(c *gin.Context) (searchContactsHTTPRequest,
 	return req, nil
 }
 
-func formatSearchContactsURL(userID, phone, firstName, lastName, address string, limit, offset int) string {
+// formatSearchContactsURL links to another page of the search served at path, of a user or of an address book
+func formatSearchContactsURL(path, phone, firstName, lastName, address string, limit, offset int) string {
 	return fmt.Sprintf(searchContactsPaginationFormatURL,
-		strings.Replace(searchContactsURL, ":userID", userID, 1),
+		path,
 		phone,
 		firstName,
 		lastName,
@@ -296,18 +394,18 @@ func formatSearchContactsURL(userID, phone, firstName, lastName, address string,
 }
 
 func encodeSearchContactsResponse(c *gin.Context, resp searchContactsResponse, err error) {
-	req, err := decodeSearchContactsHTTPRequest(c)
-	if err != nil {
-		myhttp.EncodeJSONError(c, err)
+	req, decodeErr := decodeSearchContactsHTTPRequest(c)
+	if decodeErr != nil {
+		myhttp.EncodeJSONError(c, decodeErr)
 		return
 	}
 
 	var nextURL, prevURL string
 	if req.Offset > 0 {
-		prevURL = formatSearchContactsURL(req.UserID, req.Phone, req.FirstName, req.LastName, req.Address, req.Limit, req.Offset-req.Limit)
+		prevURL = formatSearchContactsURL(c.Request.URL.Path, req.Phone, req.FirstName, req.LastName, req.Address, req.Limit, req.Offset-req.Limit)
 	}
 	if len(resp.Contacts) > 0 {
-		nextURL = formatSearchContactsURL(req.UserID, req.Phone, req.FirstName, req.LastName, req.Address, req.Limit, req.Offset+req.Limit)
+		nextURL = formatSearchContactsURL(c.Request.URL.Path, req.Phone, req.FirstName, req.LastName, req.Address, req.Limit, req.Offset+req.Limit)
 	}
 
 	contacts := make([]getContactHTTPResponse, 0, len(resp.Contacts))
@@ -361,3 +459,56 @@ func decodeDeleteContactHTTPRequest(c *gin.Context) deleteContactHTTPRequest {
 		ContactID: c.Param("contactID"),
 	}
 }
+
+// Health
+
+// makeHTTPEndpointLiveness only tells the process is serving, dependencies are left to the readiness endpoint so an
+// outage of a database does not get every replica restarted
+func makeHTTPEndpointLiveness() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		myhttp.EncodeJSONSuccess(c, HealthReport{Status: HealthStatusUp})
+	}
+}
+
+// makeHTTPEndpointReadiness reports only the status of the components, their errors may reveal internal details to
+// unauthenticated callers so they are logged and served under /admin/health
+func makeHTTPEndpointReadiness(components map[string]interface{}, logger Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checkHealth(c, components)
+
+		for name, health := range report.Components {
+			if health.Error == "" {
+				continue
+			}
+			if logger != nil {
+				logger.Warning(c, myerror.NewInternalError("readiness: %s", health.Error), "component", name)
+			}
+			health.Error = ""
+			report.Components[name] = health
+		}
+
+		myhttp.EncodeJSONWithStatus(c, healthHTTPStatus(report), report)
+	}
+}
+
+func makeHTTPEndpointAdminHealth(components map[string]interface{}) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		report := checkHealth(c, components)
+		myhttp.EncodeJSONWithStatus(c, healthHTTPStatus(report), report)
+	}
+}
+
+func healthHTTPStatus(report HealthReport) int {
+	if report.Status != HealthStatusUp {
+		return http.StatusServiceUnavailable
+	}
+
+	return http.StatusOK
+}
+
+// Admin
+func makeHTTPEndpointStats(stats StatsFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		myhttp.EncodeJSONSuccess(c, stats())
+	}
+}
@@ -0,0 +1,105 @@
+package contactmanaging
+
+import (
+	"context"
+	"errors"
+	"infrastructure/mycontext"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/apikey"
+)
+
+func TestNewHTTPHandler_AdminRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	withScopes := func(scopes ...string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			c.Request = c.Request.WithContext(mycontext.WithScopes(c.Request.Context(), scopes))
+			c.Next()
+		}
+	}
+
+	tests := []struct {
+		name                string
+		adminAuthentication gin.HandlerFunc
+		wantStatus          int
+	}{
+		{name: "not served without admin authentication", wantStatus: http.StatusNotFound},
+		{name: "admin scope", adminAuthentication: withScopes(apikey.ScopeAdmin), wantStatus: http.StatusOK},
+		{name: "other scopes", adminAuthentication: withScopes(apikey.ScopeRead), wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHTTPHandler(missingService{}, HTTPOptions{
+				AccessLogger:        &accessLogRecorder{},
+				AdminAuthentication: tt.adminAuthentication,
+				CacheStats:          func() interface{} { return struct{}{} },
+				AdminRoutes: []func(gin.IRouter){
+					func(r gin.IRouter) { r.GET("/receipts", func(c *gin.Context) { c.Status(http.StatusOK) }) },
+				},
+			})
+
+			for _, path := range []string{"/admin/receipts", "/admin/cache/stats"} {
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+				if rec.Code != tt.wantStatus {
+					t.Errorf("%s status = %d, want %d", path, rec.Code, tt.wantStatus)
+				}
+			}
+		})
+	}
+}
+
+// warningRecorder keeps the error of every Warning line
+type warningRecorder struct {
+	Logger
+	errs []error
+}
+
+func (l *warningRecorder) Warning(_ context.Context, err error, _ ...interface{}) {
+	l.errs = append(l.errs, err)
+}
+
+func TestNewHTTPHandler_Readiness(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := &warningRecorder{}
+	handler := NewHTTPHandler(missingService{}, HTTPOptions{
+		AccessLogger: &accessLogRecorder{},
+		Logger:       logger,
+		AdminAuthentication: func(c *gin.Context) {
+			c.Request = c.Request.WithContext(mycontext.WithScopes(c.Request.Context(), []string{apikey.ScopeAdmin}))
+			c.Next()
+		},
+		HealthComponents: map[string]interface{}{
+			"repository": healthCheckerFunc(func(context.Context) error { return errors.New("connection refused") }),
+		},
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if strings.Contains(rec.Body.String(), "connection refused") {
+		t.Errorf("/readyz body = %s, want no error details", rec.Body.String())
+	}
+	if len(logger.errs) != 1 || !strings.Contains(logger.errs[0].Error(), "connection refused") {
+		t.Errorf("logged errors = %v, want the repository error", logger.errs)
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/health", nil))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("/admin/health status = %d, want %d", rec.Code, http.StatusServiceUnavailable)
+	}
+	if !strings.Contains(rec.Body.String(), "connection refused") {
+		t.Errorf("/admin/health body = %s, want the error details", rec.Body.String())
+	}
+}
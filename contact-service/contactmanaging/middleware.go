@@ -0,0 +1,127 @@
+package contactmanaging
+
+import (
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"infrastructure/myhttp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"contact-service/apikey"
+	"contact-service/contact"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+// requestIDMiddleware accepts the caller's X-Request-ID or assigns a new one, and stores it with the user ID of the
+// route in the request context, so the service and the logger can correlate every line of the request
+func requestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !isValidRequestID(requestID) {
+			requestID = uuid.New().String()
+		}
+
+		ctx := mycontext.WithRequestID(c.Request.Context(), requestID)
+		if userID := c.Param("userID"); userID != "" {
+			ctx = mycontext.WithUserID(ctx, userID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// isValidRequestID only accepts short printable IDs, so a caller cannot inject arbitrary content into the logs
+func isValidRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for _, r := range requestID {
+		if r < 0x21 || r > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
+
+// accessLogMiddleware writes one line per request once it has been served
+func accessLogMiddleware(logger Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		logger.Info(c.Request.Context(), "access",
+			"method", c.Request.Method,
+			"route", c.FullPath(),
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency", time.Since(start).String(),
+			"bytes", c.Writer.Size(),
+			"clientIP", c.ClientIP(),
+		)
+	}
+}
+
+// RequireScope rejects callers restricted by scopes, such as API keys, that were not granted scope. Callers without
+// scopes, such as users authenticated by a token or requests when authentication is disabled, are let through.
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if scopes, ok := mycontext.Scopes(c.Request.Context()); ok && !apikey.HasScope(scopes, scope) {
+			myhttp.EncodeJSONError(c, myerror.NewForbiddenError("requireScope: the %s scope is required", scope))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AuthorizeUser only lets an authenticated caller reach the routes of its own :userID, routes that honour shares
+// leave the check to the service instead
+func AuthorizeUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if caller := mycontext.Subject(c.Request.Context()); caller != "" && caller != c.Param("userID") {
+			myhttp.EncodeJSONError(c, myerror.NewForbiddenError("authorizeUser: user %s may not access the data of user %s", caller, c.Param("userID")))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// reserveBookOwnerIDs hides the contacts of the address books from the routes of a :userID, so they are only reached
+// through the /books routes that check the membership
+func reserveBookOwnerIDs() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if userID := c.Param("userID"); contact.IsBookOwnerID(userID) {
+			myhttp.EncodeJSONError(c, myerror.NewNotFoundError("reserveBookOwnerIDs: user %s not found", userID))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// bookOwnerMiddleware lets the contacts handlers serve an address book, by naming the owner ID its contacts are stored
+// under as the :userID of the route
+func bookOwnerMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Params = append(c.Params, gin.Param{Key: "userID", Value: contact.BookOwnerID(c.Param("bookID"))})
+
+		c.Next()
+	}
+}
@@ -0,0 +1,103 @@
+package contactmanaging
+
+import (
+	"context"
+	"encoding/json"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// missingService finds no contact to delete
+type missingService struct {
+	Service
+}
+
+func (missingService) DeleteContact(_ context.Context, userID, contactID string) error {
+	return myerror.NewNotFoundError("contact with ID %s not found for user %s", contactID, userID)
+}
+
+// accessLogRecorder keeps the context and keyvals of every Info line
+type accessLogRecorder struct {
+	Logger
+	requestIDs []string
+	userIDs    []string
+	keyvals    [][]interface{}
+}
+
+func (l *accessLogRecorder) Info(ctx context.Context, _ string, keyvals ...interface{}) {
+	l.requestIDs = append(l.requestIDs, mycontext.RequestID(ctx))
+	l.userIDs = append(l.userIDs, mycontext.UserID(ctx))
+	l.keyvals = append(l.keyvals, keyvals)
+}
+
+func Test_requestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		requestID     string
+		wantRequestID string
+	}{
+		{name: "accepted from the caller", requestID: "abc-123", wantRequestID: "abc-123"},
+		{name: "assigned when missing"},
+		{name: "assigned when invalid", requestID: "bad id\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := &accessLogRecorder{}
+			handler := NewHTTPHandler(missingService{}, HTTPOptions{AccessLogger: logger})
+
+			req := httptest.NewRequest(http.MethodDelete, "/users/u1/contacts/c1", nil)
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+
+			requestID := rec.Header().Get(RequestIDHeader)
+			if tt.wantRequestID != "" && requestID != tt.wantRequestID {
+				t.Errorf("response %s = %q, want %q", RequestIDHeader, requestID, tt.wantRequestID)
+			}
+			if requestID == "" || strings.ContainsAny(requestID, " \n") {
+				t.Fatalf("response %s = %q, want a valid ID", RequestIDHeader, requestID)
+			}
+
+			var body struct {
+				RequestID string `json:"requestID"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if body.RequestID != requestID {
+				t.Errorf("error body requestID = %q, want %q", body.RequestID, requestID)
+			}
+
+			if len(logger.requestIDs) != 1 {
+				t.Fatalf("got %d access log lines, want 1", len(logger.requestIDs))
+			}
+			if logger.requestIDs[0] != requestID || logger.userIDs[0] != "u1" {
+				t.Errorf("access log context = (%q, %q), want (%q, u1)", logger.requestIDs[0], logger.userIDs[0], requestID)
+			}
+			if !containsKeyval(logger.keyvals[0], "status", http.StatusNotFound) {
+				t.Errorf("access log keyvals = %v, want status 404", logger.keyvals[0])
+			}
+		})
+	}
+}
+
+func containsKeyval(keyvals []interface{}, key string, value interface{}) bool {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		if keyvals[i] == key && keyvals[i+1] == value {
+			return true
+		}
+	}
+
+	return false
+}
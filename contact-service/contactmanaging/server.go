@@ -0,0 +1,109 @@
+package contactmanaging
+
+import (
+	"context"
+	"errors"
+	"infrastructure/myerror"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// Resource is a dependency released on shutdown, once no request can use it anymore
+type Resource struct {
+	Name  string
+	Close func(context.Context) error
+}
+
+type server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	resources       []Resource
+	logger          Logger
+}
+
+// NewServer serves handler on addr. On shutdown the resources are closed in the given order after the in-flight
+// requests have finished.
+func NewServer(addr string, handler http.Handler, shutdownTimeout time.Duration, logger Logger, resources ...Resource) *server {
+	return &server{
+		httpServer: &http.Server{
+			Addr:    addr,
+			Handler: handler,
+		},
+		shutdownTimeout: shutdownTimeout,
+		resources:       resources,
+		logger:          logger,
+	}
+}
+
+// Run serves until ctx is done or the process receives SIGINT or SIGTERM, then shuts down gracefully
+func (s *server) Run(ctx context.Context) error {
+	ctx, stop := signal.NotifyContext(ctx, syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		s.logger.Info(ctx, "server.Run: listening", "addr", s.httpServer.Addr)
+		serveErr <- s.httpServer.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		// the server never started, e.g. the port is taken, but the resources were already opened
+		closeErr := s.closeResources(context.Background())
+		return myerror.Wrap(errors.Join(err, closeErr), "server.Run")
+	case <-ctx.Done():
+	}
+
+	// a second signal while draining falls back to the default behaviour and kills the process
+	stop()
+
+	if err := s.Shutdown(context.Background()); err != nil {
+		return myerror.Wrap(err, "server.Run")
+	}
+
+	return nil
+}
+
+// Shutdown stops accepting connections, waits up to the shutdown timeout for active requests and then closes the
+// resources in order. A resource failing to close does not prevent the others from being closed.
+func (s *server) Shutdown(ctx context.Context) error {
+	s.logger.Info(ctx, "server.Shutdown: draining in-flight requests", "timeout", s.shutdownTimeout)
+
+	shutdownCtx, cancel := context.WithTimeout(ctx, s.shutdownTimeout)
+	defer cancel()
+
+	httpErr := s.httpServer.Shutdown(shutdownCtx)
+	if httpErr != nil {
+		// the deadline passed, cut the remaining connections before their resources go away
+		s.httpServer.Close()
+	}
+
+	// closing gets its own deadline, the drain may have used up the shutdown one
+	closeCtx, cancelClose := context.WithTimeout(context.WithoutCancel(ctx), s.shutdownTimeout)
+	defer cancelClose()
+
+	closeErr := s.closeResources(closeCtx)
+
+	if err := errors.Join(httpErr, closeErr); err != nil {
+		return myerror.Wrap(err, "server.Shutdown")
+	}
+
+	s.logger.Info(ctx, "server.Shutdown: stopped")
+
+	return nil
+}
+
+func (s *server) closeResources(ctx context.Context) error {
+	var errs []error
+	for _, r := range s.resources {
+		if err := r.Close(ctx); err != nil {
+			errs = append(errs, myerror.Wrap(err, "closeResources: %s", r.Name))
+			continue
+		}
+		s.logger.Info(ctx, "server.closeResources: closed", "resource", r.Name)
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,87 @@
+package contactmanaging
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"contact-service/contact"
+	"contact-service/stdout"
+)
+
+// slowService answers SearchContacts only once release is closed
+type slowService struct {
+	Service
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s slowService) SearchContacts(context.Context, contact.Filters) ([]contact.Contact, error) {
+	close(s.started)
+	<-s.release
+	return nil, nil
+}
+
+func Test_server_GracefulShutdown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	var closed []string
+	closeFunc := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			closed = append(closed, name)
+			return nil
+		}
+	}
+
+	s := slowService{started: make(chan struct{}), release: make(chan struct{})}
+	srv := NewServer(addr, NewHTTPHandler(s, HTTPOptions{}), 5*time.Second, stdout.NewLogger(),
+		Resource{Name: "repository", Close: closeFunc("repository")},
+		Resource{Name: "lock", Close: closeFunc("lock")},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() { runErr <- srv.Run(ctx) }()
+
+	respStatus := make(chan int, 1)
+	go func() {
+		for {
+			resp, err := http.Get(fmt.Sprintf("http://%s/users/1/contacts", addr))
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			resp.Body.Close()
+			respStatus <- resp.StatusCode
+			return
+		}
+	}()
+
+	<-s.started
+	cancel()
+
+	// the in-flight request keeps the server draining and the resources open
+	time.Sleep(50 * time.Millisecond)
+	if len(closed) != 0 {
+		t.Fatalf("resources closed before in-flight requests finished: %v", closed)
+	}
+	close(s.release)
+
+	if status := <-respStatus; status != http.StatusOK {
+		t.Errorf("in-flight request status = %d, want %d", status, http.StatusOK)
+	}
+	if err := <-runErr; err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if fmt.Sprint(closed) != "[repository lock]" {
+		t.Errorf("closed resources = %v, want [repository lock]", closed)
+	}
+}
@@ -3,6 +3,7 @@ package contactmanaging
 import (
 	"context"
 	"fmt"
+	"infrastructure/mycontext"
 	"infrastructure/myerror"
 	"regexp"
 	"time"
@@ -21,6 +22,12 @@ type Repository interface {
 	IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error)
 }
 
+// ShareRepository holds the shares that let users reach the contacts of other users
+type ShareRepository interface {
+	FindShares(ctx context.Context, ownerID, granteeID string) ([]contact.Share, error)
+	DeleteSharesOfContact(ctx context.Context, ownerID, contactID string) error
+}
+
 type LockCache interface {
 	Lock(context.Context, string) (bool, error)
 	Unlock(context.Context, string) error
@@ -35,13 +42,15 @@ type Logger interface {
 
 type service struct {
 	repo      Repository
+	shares    ShareRepository
 	lockCache LockCache
 	logger    Logger
 }
 
-func NewService(repo Repository, locker LockCache, logger Logger) *service {
+func NewService(repo Repository, shares ShareRepository, locker LockCache, logger Logger) *service {
 	return &service{
 		repo:      repo,
+		shares:    shares,
 		lockCache: locker,
 		logger:    logger,
 	}
@@ -75,6 +84,10 @@ func (s service) CreateContact(ctx context.Context, c contact.Contact) (string,
 }
 
 func (s service) UpdateContact(ctx context.Context, c contact.Contact) error {
+	if err := s.authorize(ctx, c.UserID, c.ID, contact.PermissionEdit); err != nil {
+		return myerror.Wrap(err, "service.UpdateContact")
+	}
+
 	lockKey := fmt.Sprintf("update:%s:%s", c.UserID, c.Phone)
 	if err := s.lock(ctx, lockKey); err != nil {
 		return myerror.Wrap(err, "service.UpdateContact")
@@ -105,6 +118,10 @@ func (s service) UpdateContact(ctx context.Context, c contact.Contact) error {
 }
 
 func (s service) GetContact(ctx context.Context, userID, contactID string) (contact.Contact, error) {
+	if err := s.authorize(ctx, userID, contactID, contact.PermissionRead); err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "service.GetContact")
+	}
+
 	c, err := s.repo.GetContact(ctx, userID, contactID)
 	if err != nil {
 		return contact.Contact{}, myerror.Wrap(err, "service.GetContact")
@@ -118,10 +135,20 @@ func (s service) DeleteContact(ctx context.Context, userID, contactID string) er
 		return myerror.Wrap(err, "service.DeleteContact")
 	}
 
+	if err := s.shares.DeleteSharesOfContact(ctx, userID, contactID); err != nil {
+		return myerror.Wrap(err, "service.DeleteContact")
+	}
+
 	return nil
 }
 
+// SearchContacts searches the contacts of the owner, which other users may do only with a share of the whole address
+// book
 func (s service) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	if err := s.authorize(ctx, filters.UserID, "", contact.PermissionRead); err != nil {
+		return nil, myerror.Wrap(err, "service.SearchContacts")
+	}
+
 	contacts, err := s.repo.SearchContacts(ctx, filters)
 	if err != nil {
 		return nil, myerror.Wrap(err, "service.SearchContacts")
@@ -140,6 +167,31 @@ func (s service) updateContactFields(contactPrevState contact.Contact, c contact
 	return contactPrevState
 }
 
+// authorize lets the caller reach a contact of the owner if the caller is the owner or was granted permission on the
+// contact or on all of the owner's contacts, an empty contactID asks for all of them. Requests without an authenticated caller are not restricted, nor are
+// address books, whose routes check the membership.
+func (s service) authorize(ctx context.Context, ownerID, contactID, permission string) error {
+	caller := mycontext.Subject(ctx)
+	if caller == "" || caller == ownerID || contact.IsBookOwnerID(ownerID) {
+		return nil
+	}
+
+	shares, err := s.shares.FindShares(ctx, ownerID, caller)
+	if err != nil {
+		return myerror.Wrap(err, "authorize")
+	}
+	for _, share := range shares {
+		if share.Allows(contactID, permission) {
+			return nil
+		}
+	}
+
+	if contactID == "" {
+		return myerror.NewForbiddenError("authorize: user %s has no %s permission on the contacts of user %s", caller, permission, ownerID)
+	}
+	return myerror.NewForbiddenError("authorize: user %s has no %s permission on contact %s of user %s", caller, permission, contactID, ownerID)
+}
+
 func (s service) lock(ctx context.Context, key string) error {
 	lockSuccess, err := s.lockCache.Lock(ctx, key)
 	if err != nil {
@@ -0,0 +1,60 @@
+package contactmanaging
+
+import (
+	"context"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"testing"
+
+	"contact-service/contact"
+	"contact-service/inmem"
+	"contact-service/stdout"
+)
+
+func Test_service_authorize(t *testing.T) {
+	ctx := context.Background()
+
+	shares := inmem.NewShareRepository()
+	for _, s := range []contact.Share{
+		{ID: "s1", OwnerID: "owner", ContactID: "c1", GranteeID: "reader", Permission: contact.PermissionRead},
+		{ID: "s2", OwnerID: "owner", GranteeID: "editor", Permission: contact.PermissionEdit},
+	} {
+		if _, err := shares.SaveShare(ctx, s); err != nil {
+			t.Fatalf("SaveShare() error = %v", err)
+		}
+	}
+	s := NewService(inmem.NewUserRepository(), shares, inmem.NewLockCache(), stdout.NewLogger())
+
+	tests := []struct {
+		name       string
+		caller     string
+		ownerID    string
+		contactID  string
+		permission string
+		wantErr    bool
+	}{
+		{name: "no caller", ownerID: "owner", contactID: "c1", permission: contact.PermissionEdit},
+		{name: "owner", caller: "owner", ownerID: "owner", contactID: "c1", permission: contact.PermissionEdit},
+		{name: "address book", caller: "stranger", ownerID: contact.BookOwnerID("b1"), contactID: "c1", permission: contact.PermissionEdit},
+		{name: "shared contact", caller: "reader", ownerID: "owner", contactID: "c1", permission: contact.PermissionRead},
+		{name: "other contact than the shared one", caller: "reader", ownerID: "owner", contactID: "c2", permission: contact.PermissionRead, wantErr: true},
+		{name: "all contacts with a contact share", caller: "reader", ownerID: "owner", permission: contact.PermissionRead, wantErr: true},
+		{name: "edit with a read share", caller: "reader", ownerID: "owner", contactID: "c1", permission: contact.PermissionEdit, wantErr: true},
+		{name: "read with an edit share", caller: "editor", ownerID: "owner", contactID: "c2", permission: contact.PermissionRead},
+		{name: "all contacts with a book share", caller: "editor", ownerID: "owner", permission: contact.PermissionEdit},
+		{name: "no share", caller: "stranger", ownerID: "owner", contactID: "c1", permission: contact.PermissionRead, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := mycontext.WithSubject(context.Background(), tt.caller)
+			err := s.authorize(ctx, tt.ownerID, tt.contactID, tt.permission)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("authorize() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && myerror.GetParsedError(err).Type != myerror.ForbiddenError {
+				t.Errorf("authorize() error = %v, want a forbidden error", err)
+			}
+		})
+	}
+}
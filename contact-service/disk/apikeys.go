@@ -0,0 +1,93 @@
+package disk
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"sort"
+	"time"
+
+	"contact-service/apikey"
+)
+
+func (r *repository) CreateKey(_ context.Context, k apikey.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.keys[k.Hash]; ok {
+		return myerror.NewBadRequestError("disk.CreateKey: key already exists")
+	}
+
+	if err := r.persistKeys(func(keys map[string]apikey.APIKey) { keys[k.Hash] = k }); err != nil {
+		return myerror.Wrap(err, "disk.CreateKey")
+	}
+
+	return nil
+}
+
+func (r *repository) GetKeyByHash(_ context.Context, hash string) (apikey.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if k, ok := r.keys[hash]; ok {
+		return k, nil
+	}
+
+	return apikey.APIKey{}, myerror.NewNotFoundError("disk.GetKeyByHash: key not found")
+}
+
+// ListKeys returns every key, revoked and expired ones included, oldest first
+func (r *repository) ListKeys(context.Context) ([]apikey.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]apikey.APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+
+	return keys, nil
+}
+
+func (r *repository) RevokeKey(_ context.Context, keyID string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for hash, k := range r.keys {
+		if k.ID != keyID {
+			continue
+		}
+
+		if !k.RevokedAt.IsZero() {
+			return nil
+		}
+		k.RevokedAt = revokedAt
+		if err := r.persistKeys(func(keys map[string]apikey.APIKey) { keys[hash] = k }); err != nil {
+			return myerror.Wrap(err, "disk.RevokeKey")
+		}
+		return nil
+	}
+
+	return myerror.NewNotFoundError("disk.RevokeKey: key with ID %s not found", keyID)
+}
+
+// persistKeys must be called while holding the write lock, it works like persistShares
+func (r *repository) persistKeys(change func(map[string]apikey.APIKey)) error {
+	keys := make(map[string]apikey.APIKey, len(r.keys)+1)
+	for hash, k := range r.keys {
+		keys[hash] = k
+	}
+	change(keys)
+
+	if err := writeJSON(r.keysPath(), keys); err != nil {
+		return myerror.Wrap(err, "persistKeys")
+	}
+	r.keys = keys
+
+	return nil
+}
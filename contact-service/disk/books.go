@@ -0,0 +1,142 @@
+package disk
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"sort"
+
+	"contact-service/contact"
+)
+
+func (r *repository) CreateBook(_ context.Context, b contact.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.persistBooks(func(books map[string]contact.Book) { books[b.ID] = copyBook(b) }); err != nil {
+		return myerror.Wrap(err, "disk.CreateBook")
+	}
+
+	return nil
+}
+
+func (r *repository) GetBook(_ context.Context, bookID string) (contact.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, ok := r.books[bookID]
+	if !ok {
+		return contact.Book{}, myerror.NewNotFoundError("disk.GetBook: book with ID %s not found", bookID)
+	}
+
+	return copyBook(b), nil
+}
+
+// ListBooksByMember returns the books the user owns, belongs to or is invited to, oldest first
+func (r *repository) ListBooksByMember(_ context.Context, userID string) ([]contact.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := make([]contact.Book, 0)
+	for _, b := range r.books {
+		if _, ok := b.Member(userID); ok {
+			books = append(books, copyBook(b))
+		}
+	}
+	sort.Slice(books, func(i, j int) bool {
+		if !books[i].CreatedAt.Equal(books[j].CreatedAt) {
+			return books[i].CreatedAt.Before(books[j].CreatedAt)
+		}
+		return books[i].ID < books[j].ID
+	})
+
+	return books, nil
+}
+
+// SaveMember adds the member to the book, or replaces the membership of the same user
+func (r *repository) SaveMember(_ context.Context, bookID string, m contact.Member) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.books[bookID]
+	if !ok {
+		return myerror.NewNotFoundError("disk.SaveMember: book with ID %s not found", bookID)
+	}
+
+	b.Members = append(withoutMember(b.Members, m.UserID), m)
+	if err := r.persistBooks(func(books map[string]contact.Book) { books[bookID] = b }); err != nil {
+		return myerror.Wrap(err, "disk.SaveMember")
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteMember(_ context.Context, bookID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.books[bookID]
+	if !ok {
+		return myerror.NewNotFoundError("disk.DeleteMember: book with ID %s not found", bookID)
+	}
+
+	members := withoutMember(b.Members, userID)
+	if len(members) == len(b.Members) {
+		return myerror.NewNotFoundError("disk.DeleteMember: user %s is not a member of book %s", userID, bookID)
+	}
+	b.Members = members
+	if err := r.persistBooks(func(books map[string]contact.Book) { books[bookID] = b }); err != nil {
+		return myerror.Wrap(err, "disk.DeleteMember")
+	}
+
+	return nil
+}
+
+// DeleteBook drops the book and its memberships, its contacts are stored with the contacts of the users
+func (r *repository) DeleteBook(_ context.Context, bookID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.books[bookID]; !ok {
+		return myerror.NewNotFoundError("disk.DeleteBook: book with ID %s not found", bookID)
+	}
+
+	if err := r.persistBooks(func(books map[string]contact.Book) { delete(books, bookID) }); err != nil {
+		return myerror.Wrap(err, "disk.DeleteBook")
+	}
+
+	return nil
+}
+
+// persistBooks must be called while holding the write lock, it works like persistShares
+func (r *repository) persistBooks(change func(map[string]contact.Book)) error {
+	books := make(map[string]contact.Book, len(r.books)+1)
+	for id, b := range r.books {
+		books[id] = b
+	}
+	change(books)
+
+	if err := writeJSON(r.booksPath(), books); err != nil {
+		return myerror.Wrap(err, "persistBooks")
+	}
+	r.books = books
+
+	return nil
+}
+
+// withoutMember returns a new slice, so the members held by the repository are never changed in place
+func withoutMember(members []contact.Member, userID string) []contact.Member {
+	kept := make([]contact.Member, 0, len(members)+1)
+	for _, m := range members {
+		if m.UserID != userID {
+			kept = append(kept, m)
+		}
+	}
+
+	return kept
+}
+
+// copyBook keeps the callers from changing the members held by the repository
+func copyBook(b contact.Book) contact.Book {
+	b.Members = append([]contact.Member(nil), b.Members...)
+	return b
+}
@@ -0,0 +1,47 @@
+package disk
+
+import (
+	"sort"
+	"strings"
+)
+
+// ownerCounts counts the contacts of every owner. The owners are kept sorted, so the owners whose ID starts with a
+// prefix are a single range and counting the contacts of a tenant never scans the contacts.
+type ownerCounts struct {
+	counts map[string]int
+	owners []string
+}
+
+func newOwnerCounts() *ownerCounts {
+	return &ownerCounts{counts: make(map[string]int)}
+}
+
+// add changes the count of the owner by delta, dropping the owner once it has no contacts left
+func (o *ownerCounts) add(ownerID string, delta int) {
+	count, ok := o.counts[ownerID]
+	i := sort.SearchStrings(o.owners, ownerID)
+	if !ok {
+		o.owners = append(o.owners, "")
+		copy(o.owners[i+1:], o.owners[i:])
+		o.owners[i] = ownerID
+	}
+
+	count += delta
+	if count > 0 {
+		o.counts[ownerID] = count
+		return
+	}
+
+	delete(o.counts, ownerID)
+	o.owners = append(o.owners[:i], o.owners[i+1:]...)
+}
+
+// sum counts the contacts of the owners whose ID starts with the prefix
+func (o *ownerCounts) sum(prefix string) int {
+	total := 0
+	for i := sort.SearchStrings(o.owners, prefix); i < len(o.owners) && strings.HasPrefix(o.owners[i], prefix); i++ {
+		total += o.counts[o.owners[i]]
+	}
+
+	return total
+}
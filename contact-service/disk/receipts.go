@@ -0,0 +1,99 @@
+package disk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"infrastructure/myerror"
+	"io"
+	"os"
+
+	"contact-service/contact"
+)
+
+// SaveReceipt appends the receipt to the receipts log, then compacts the write-ahead log so the contacts deleted by
+// the erasure no longer remain on disk
+func (r *repository) SaveReceipt(_ context.Context, receipt contact.ErasureReceipt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	line, err := json.Marshal(receipt)
+	if err != nil {
+		return myerror.Wrap(err, "disk.SaveReceipt")
+	}
+	line = append(line, '\n')
+
+	if _, err := r.receiptsLog.Write(line); err != nil {
+		return myerror.Wrap(err, "disk.SaveReceipt")
+	}
+	if err := r.receiptsLog.Sync(); err != nil {
+		return myerror.Wrap(err, "disk.SaveReceipt")
+	}
+	r.receipts = append(r.receipts, receipt)
+
+	if err := r.snapshot(); err != nil {
+		return myerror.Wrap(err, "disk.SaveReceipt")
+	}
+
+	return nil
+}
+
+func (r *repository) GetReceipt(_ context.Context, receiptID string) (contact.ErasureReceipt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, receipt := range r.receipts {
+		if receipt.ID == receiptID {
+			return receipt, nil
+		}
+	}
+
+	return contact.ErasureReceipt{}, myerror.NewNotFoundError("disk.GetReceipt: receipt with ID %s not found", receiptID)
+}
+
+// ListReceipts returns the receipts oldest first
+func (r *repository) ListReceipts(context.Context) ([]contact.ErasureReceipt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]contact.ErasureReceipt(nil), r.receipts...), nil
+}
+
+func (r *repository) loadReceipts() error {
+	f, err := os.Open(r.receiptsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return myerror.Wrap(err, "loadReceipts")
+	}
+	defer f.Close()
+
+	var validSize int64
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a trailing line without a newline is a torn write from a crash and was never acknowledged
+			if len(line) > 0 {
+				r.logger.Warning(context.Background(), myerror.NewInternalError("loadReceipts: discarding incomplete trailing receipt"))
+				if err := os.Truncate(r.receiptsPath(), validSize); err != nil {
+					return myerror.Wrap(err, "loadReceipts")
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return myerror.Wrap(err, "loadReceipts")
+		}
+
+		var receipt contact.ErasureReceipt
+		if err := json.Unmarshal(line, &receipt); err != nil {
+			return myerror.Wrap(err, "loadReceipts: corrupted receipt %d", len(r.receipts)+1)
+		}
+
+		r.receipts = append(r.receipts, receipt)
+		validSize += int64(len(line))
+	}
+}
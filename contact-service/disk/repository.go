@@ -0,0 +1,489 @@
+package disk
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"infrastructure/myerror"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"contact-service/apikey"
+	"contact-service/contact"
+)
+
+const (
+	walFileName      = "wal.log"
+	snapshotFileName = "snapshot.json"
+	receiptsFileName = "receipts.log"
+	sharesFileName   = "shares.json"
+	booksFileName    = "books.json"
+	keysFileName     = "apikeys.json"
+
+	opCreate = "create"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+type Logger interface {
+	Info(ctx context.Context, msg string, keyvals ...interface{})
+	Error(ctx context.Context, err error, keyvals ...interface{})
+	Warning(ctx context.Context, err error, keyvals ...interface{})
+	Debug(ctx context.Context, msg string, keyvals ...interface{})
+}
+
+// walRecord is a single line of the write-ahead log
+type walRecord struct {
+	Op      string          `json:"op"`
+	Contact contact.Contact `json:"contact"`
+}
+
+// repository keeps all contacts in memory and persists every mutation to an append-only
+// write-ahead log before applying it. Once the log grows past snapshotEvery records it is
+// compacted into a snapshot file and truncated.
+type repository struct {
+	mu            sync.RWMutex
+	dir           string
+	contacts      map[string]contact.Contact
+	counts        *ownerCounts
+	wal           *os.File
+	walRecords    int
+	snapshotEvery int
+	logger        Logger
+
+	// receipts are appended to their own log, which is never compacted
+	receipts    []contact.ErasureReceipt
+	receiptsLog *os.File
+
+	// shares, books and API keys are few and change rarely, so their files are rewritten whole on every change. Keys
+	// are indexed by hash, the lookup of every authenticated request.
+	shares map[string]contact.Share
+	books  map[string]contact.Book
+	keys   map[string]apikey.APIKey
+}
+
+func NewRepository(dir string, snapshotEvery int, logger Logger) (*repository, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+
+	r := &repository{
+		dir:           dir,
+		contacts:      make(map[string]contact.Contact),
+		counts:        newOwnerCounts(),
+		shares:        make(map[string]contact.Share),
+		books:         make(map[string]contact.Book),
+		keys:          make(map[string]apikey.APIKey),
+		snapshotEvery: snapshotEvery,
+		logger:        logger,
+	}
+
+	if err := r.loadSnapshot(); err != nil {
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+
+	if err := r.replayWAL(); err != nil {
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+
+	if err := readJSON(r.sharesPath(), &r.shares); err != nil {
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+
+	if err := readJSON(r.booksPath(), &r.books); err != nil {
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+
+	if err := readJSON(r.keysPath(), &r.keys); err != nil {
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+
+	wal, err := os.OpenFile(r.walPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+	r.wal = wal
+
+	if err := r.loadReceipts(); err != nil {
+		wal.Close()
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+
+	receiptsLog, err := os.OpenFile(r.receiptsPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		wal.Close()
+		return nil, myerror.Wrap(err, "disk.NewRepository")
+	}
+	r.receiptsLog = receiptsLog
+
+	r.logger.Info(context.Background(), "disk.NewRepository: loaded contacts", "dir", dir, "contacts", len(r.contacts), "walRecords", r.walRecords)
+
+	return r, nil
+}
+
+func (r *repository) GetContact(_ context.Context, userID string, contactID string) (contact.Contact, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if c, ok := r.contacts[getContactKey(userID, contactID)]; ok {
+		return c, nil
+	}
+
+	return contact.Contact{}, myerror.NewNotFoundError("disk.GetContact: contact with ID %s not found for user %s", contactID, userID)
+}
+
+func (r *repository) SearchContacts(_ context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var matches []contact.Contact
+	for _, c := range r.contacts {
+		if c.UserID != filters.UserID {
+			continue
+		}
+
+		if (filters.Phone != "" && c.Phone != filters.Phone) ||
+			(filters.FirstName != "" && c.FirstName != filters.FirstName) ||
+			(filters.LastName != "" && c.LastName != filters.LastName) ||
+			(filters.Address != "" && c.Address != filters.Address) {
+			continue
+		}
+
+		matches = append(matches, c)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].FirstName < matches[j].FirstName
+	})
+
+	if filters.Offset >= len(matches) {
+		return nil, nil
+	}
+
+	matches = matches[filters.Offset:]
+	if len(matches) > filters.Limit {
+		matches = matches[:filters.Limit]
+	}
+
+	return matches, nil
+}
+
+func (r *repository) CreateContact(_ context.Context, c contact.Contact) error {
+	if err := r.apply(walRecord{Op: opCreate, Contact: c}); err != nil {
+		return myerror.Wrap(err, "disk.CreateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) UpdateContact(_ context.Context, c contact.Contact) error {
+	if err := r.apply(walRecord{Op: opUpdate, Contact: c}); err != nil {
+		return myerror.Wrap(err, "disk.UpdateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteContact(_ context.Context, userID string, contactID string) error {
+	rec := walRecord{
+		Op:      opDelete,
+		Contact: contact.Contact{UserID: userID, ID: contactID},
+	}
+	if err := r.apply(rec); err != nil {
+		return myerror.Wrap(err, "disk.DeleteContact")
+	}
+
+	return nil
+}
+
+func (r *repository) IsPhoneExistsForUser(_ context.Context, userID, phone string) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.contacts {
+		if c.UserID == userID && c.Phone == phone {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// CountContacts counts the contacts of the users whose ID starts with the prefix
+func (r *repository) CountContacts(_ context.Context, userIDPrefix string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.counts.sum(userIDPrefix), nil
+}
+
+// HealthCheck verifies the write-ahead log is still open and its directory is reachable
+func (r *repository) HealthCheck(context.Context) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if _, err := r.wal.Stat(); err != nil {
+		return myerror.Wrap(err, "disk.HealthCheck")
+	}
+
+	if _, err := os.Stat(r.dir); err != nil {
+		return myerror.Wrap(err, "disk.HealthCheck")
+	}
+
+	return nil
+}
+
+// Snapshot writes the current state to the snapshot file and truncates the write-ahead log
+func (r *repository) Snapshot() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.snapshot(); err != nil {
+		return myerror.Wrap(err, "disk.Snapshot")
+	}
+
+	return nil
+}
+
+// Close compacts the write-ahead log into a snapshot and releases the log file
+func (r *repository) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.snapshot(); err != nil {
+		return myerror.Wrap(err, "disk.Close")
+	}
+
+	if err := r.wal.Close(); err != nil {
+		return myerror.Wrap(err, "disk.Close")
+	}
+
+	if err := r.receiptsLog.Close(); err != nil {
+		return myerror.Wrap(err, "disk.Close")
+	}
+
+	return nil
+}
+
+// apply appends the record to the write-ahead log, syncs it to disk and only then applies it in memory
+func (r *repository) apply(rec walRecord) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return myerror.Wrap(err, "apply")
+	}
+	line = append(line, '\n')
+
+	if _, err := r.wal.Write(line); err != nil {
+		return myerror.Wrap(err, "apply")
+	}
+	if err := r.wal.Sync(); err != nil {
+		return myerror.Wrap(err, "apply")
+	}
+
+	r.applyInMemory(rec)
+	r.walRecords++
+
+	if r.snapshotEvery > 0 && r.walRecords >= r.snapshotEvery {
+		if err := r.snapshot(); err != nil {
+			// the record is already durable in the log, so a failed compaction is not fatal
+			r.logger.Warning(context.Background(), myerror.Wrap(err, "apply"))
+		}
+	}
+
+	return nil
+}
+
+func (r *repository) applyInMemory(rec walRecord) {
+	contactKey := getContactKey(rec.Contact.UserID, rec.Contact.ID)
+
+	_, exists := r.contacts[contactKey]
+	switch rec.Op {
+	case opCreate, opUpdate:
+		r.contacts[contactKey] = rec.Contact
+		if !exists {
+			r.counts.add(rec.Contact.UserID, 1)
+		}
+	case opDelete:
+		delete(r.contacts, contactKey)
+		if exists {
+			r.counts.add(rec.Contact.UserID, -1)
+		}
+	}
+}
+
+// snapshot must be called while holding the write lock
+func (r *repository) snapshot() error {
+	contacts := make([]contact.Contact, 0, len(r.contacts))
+	for _, c := range r.contacts {
+		contacts = append(contacts, c)
+	}
+
+	data, err := json.Marshal(contacts)
+	if err != nil {
+		return myerror.Wrap(err, "snapshot")
+	}
+
+	// write to a temporary file and rename it so a crash never leaves a partial snapshot behind
+	tmpPath := r.snapshotPath() + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		return myerror.Wrap(err, "snapshot")
+	}
+	if err := os.Rename(tmpPath, r.snapshotPath()); err != nil {
+		return myerror.Wrap(err, "snapshot")
+	}
+
+	if err := r.wal.Truncate(0); err != nil {
+		return myerror.Wrap(err, "snapshot")
+	}
+	if err := r.wal.Sync(); err != nil {
+		return myerror.Wrap(err, "snapshot")
+	}
+	r.walRecords = 0
+
+	r.logger.Info(context.Background(), "disk.snapshot: compacted write-ahead log", "contacts", len(contacts))
+
+	return nil
+}
+
+func (r *repository) loadSnapshot() error {
+	data, err := os.ReadFile(r.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return myerror.Wrap(err, "loadSnapshot")
+	}
+
+	var contacts []contact.Contact
+	if err := json.Unmarshal(data, &contacts); err != nil {
+		return myerror.Wrap(err, "loadSnapshot")
+	}
+
+	for _, c := range contacts {
+		r.applyInMemory(walRecord{Op: opCreate, Contact: c})
+	}
+
+	return nil
+}
+
+func (r *repository) replayWAL() error {
+	f, err := os.Open(r.walPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return myerror.Wrap(err, "replayWAL")
+	}
+	defer f.Close()
+
+	var validSize int64
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			// a trailing line without a newline is a torn write from a crash and was never acknowledged
+			if len(line) > 0 {
+				r.logger.Warning(context.Background(), myerror.NewInternalError("replayWAL: discarding incomplete trailing record"))
+				if err := os.Truncate(r.walPath(), validSize); err != nil {
+					return myerror.Wrap(err, "replayWAL")
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return myerror.Wrap(err, "replayWAL")
+		}
+
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			return myerror.Wrap(err, "replayWAL: corrupted record %d", r.walRecords+1)
+		}
+
+		r.applyInMemory(rec)
+		r.walRecords++
+		validSize += int64(len(line))
+	}
+}
+
+func (r *repository) walPath() string {
+	return filepath.Join(r.dir, walFileName)
+}
+
+func (r *repository) receiptsPath() string {
+	return filepath.Join(r.dir, receiptsFileName)
+}
+
+func (r *repository) snapshotPath() string {
+	return filepath.Join(r.dir, snapshotFileName)
+}
+
+func (r *repository) sharesPath() string {
+	return filepath.Join(r.dir, sharesFileName)
+}
+
+func (r *repository) booksPath() string {
+	return filepath.Join(r.dir, booksFileName)
+}
+
+func (r *repository) keysPath() string {
+	return filepath.Join(r.dir, keysFileName)
+}
+
+// writeJSON replaces the file with the JSON of v through a temporary file, so a crash never leaves a partial file
+func writeJSON(path string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmpPath := path + ".tmp"
+	if err := writeFileSync(tmpPath, data); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpPath, path)
+}
+
+// readJSON decodes the file written by writeJSON into v, leaving v untouched when there is no file yet
+func readJSON(path string, v interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func getContactKey(userID, contactID string) string {
+	return fmt.Sprintf("%s:%s", userID, contactID)
+}
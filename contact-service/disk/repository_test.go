@@ -0,0 +1,388 @@
+package disk
+
+import (
+	"contact-service/apikey"
+	"contact-service/contact"
+	"contact-service/stdout"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"infrastructure/myerror"
+)
+
+func Test_repository_Restart(t *testing.T) {
+	tests := []struct {
+		name          string
+		snapshotEvery int
+		tornWrite     bool
+	}{
+		{
+			name:          "replay write-ahead log only",
+			snapshotEvery: 0,
+		},
+		{
+			name:          "replay snapshot and write-ahead log",
+			snapshotEvery: 2,
+		},
+		{
+			name:          "discard torn trailing record",
+			snapshotEvery: 0,
+			tornWrite:     true,
+		},
+	}
+
+	ctx := context.Background()
+	logger := stdout.NewLogger()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			r, err := NewRepository(dir, tt.snapshotEvery, logger)
+			if err != nil {
+				t.Fatalf("NewRepository() error = %v", err)
+			}
+
+			john := contact.Contact{UserID: "1", ID: "a", Phone: "111", FirstName: "John"}
+			jane := contact.Contact{UserID: "1", ID: "b", Phone: "222", FirstName: "Jane"}
+			for _, c := range []contact.Contact{john, jane} {
+				if err := r.CreateContact(ctx, c); err != nil {
+					t.Fatalf("CreateContact() error = %v", err)
+				}
+			}
+			john.Phone = "333"
+			if err := r.UpdateContact(ctx, john); err != nil {
+				t.Fatalf("UpdateContact() error = %v", err)
+			}
+			if err := r.DeleteContact(ctx, jane.UserID, jane.ID); err != nil {
+				t.Fatalf("DeleteContact() error = %v", err)
+			}
+
+			// simulate a crash: drop the handle without compacting
+			if err := r.wal.Close(); err != nil {
+				t.Fatalf("wal.Close() error = %v", err)
+			}
+
+			if tt.tornWrite {
+				f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+				if err != nil {
+					t.Fatalf("OpenFile() error = %v", err)
+				}
+				if _, err := f.WriteString(`{"op":"create","contact":{"UserID":"1"`); err != nil {
+					t.Fatalf("WriteString() error = %v", err)
+				}
+				f.Close()
+			}
+
+			r, err = NewRepository(dir, tt.snapshotEvery, logger)
+			if err != nil {
+				t.Fatalf("NewRepository() after restart error = %v", err)
+			}
+			defer r.Close()
+
+			got, err := r.GetContact(ctx, john.UserID, john.ID)
+			if err != nil {
+				t.Fatalf("GetContact() error = %v", err)
+			}
+			if got.Phone != "333" {
+				t.Errorf("GetContact() phone = %s, want 333", got.Phone)
+			}
+
+			if _, err := r.GetContact(ctx, jane.UserID, jane.ID); err == nil {
+				t.Errorf("GetContact() expected deleted contact to be missing")
+			}
+
+			// the log must stay appendable after recovery
+			if err := r.CreateContact(ctx, jane); err != nil {
+				t.Fatalf("CreateContact() after restart error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_repository_Receipts(t *testing.T) {
+	ctx := context.Background()
+	logger := stdout.NewLogger()
+	dir := t.TempDir()
+
+	r, err := NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	erased := contact.Contact{UserID: "1", ID: "a", Phone: "0541234567", FirstName: "Dana"}
+	if err := r.CreateContact(ctx, erased); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	if err := r.DeleteContact(ctx, erased.UserID, erased.ID); err != nil {
+		t.Fatalf("DeleteContact() error = %v", err)
+	}
+
+	receipt := contact.ErasureReceipt{ID: "r1", UserID: "1", ErasedAt: time.Now().UTC(), Contacts: 1, Digest: "digest"}
+	if err := r.SaveReceipt(ctx, receipt); err != nil {
+		t.Fatalf("SaveReceipt() error = %v", err)
+	}
+
+	for _, name := range []string{walFileName, snapshotFileName, receiptsFileName} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("ReadFile(%s) error = %v", name, err)
+		}
+		if strings.Contains(string(data), erased.Phone) || strings.Contains(string(data), erased.FirstName) {
+			t.Errorf("%s still holds the erased contact: %s", name, data)
+		}
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	r, err = NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() after restart error = %v", err)
+	}
+	defer r.Close()
+
+	got, err := r.GetReceipt(ctx, receipt.ID)
+	if err != nil {
+		t.Fatalf("GetReceipt() error = %v", err)
+	}
+	if got != receipt {
+		t.Errorf("GetReceipt() = %+v, want %+v", got, receipt)
+	}
+	if receipts, err := r.ListReceipts(ctx); err != nil || len(receipts) != 1 {
+		t.Errorf("ListReceipts() = %v, %v, want 1 receipt", receipts, err)
+	}
+	if _, err := r.GetReceipt(ctx, "missing"); err == nil {
+		t.Errorf("GetReceipt() of a missing receipt expected an error")
+	}
+}
+
+func Test_repository_CountContacts(t *testing.T) {
+	ctx := context.Background()
+	logger := stdout.NewLogger()
+	dir := t.TempDir()
+
+	r, err := NewRepository(dir, 3, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	for _, c := range []contact.Contact{
+		{UserID: "acme/1", ID: "a", Phone: "111"},
+		{UserID: "acme/1", ID: "b", Phone: "222"},
+		{UserID: "acme/2", ID: "c", Phone: "333"},
+		{UserID: "acme2/1", ID: "d", Phone: "444"},
+	} {
+		if err := r.CreateContact(ctx, c); err != nil {
+			t.Fatalf("CreateContact() error = %v", err)
+		}
+	}
+	// updates replace a contact without counting it again
+	if err := r.UpdateContact(ctx, contact.Contact{UserID: "acme/1", ID: "a", Phone: "555"}); err != nil {
+		t.Fatalf("UpdateContact() error = %v", err)
+	}
+	if err := r.DeleteContact(ctx, "acme/2", "c"); err != nil {
+		t.Fatalf("DeleteContact() error = %v", err)
+	}
+
+	// the counts are rebuilt from the snapshot and the write-ahead log after a restart
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	r, err = NewRepository(dir, 3, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() after restart error = %v", err)
+	}
+	defer r.Close()
+
+	for prefix, want := range map[string]int{"acme/": 2, "acme": 3, "": 3, "none/": 0} {
+		if got, err := r.CountContacts(ctx, prefix); err != nil || got != want {
+			t.Errorf("CountContacts(%q) = %d, %v, want %d", prefix, got, err, want)
+		}
+	}
+}
+
+func Test_repository_Shares(t *testing.T) {
+	ctx := context.Background()
+	logger := stdout.NewLogger()
+	dir := t.TempDir()
+
+	r, err := NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	contactShare := contact.Share{ID: "s1", OwnerID: "1", ContactID: "a", GranteeID: "2", Permission: contact.PermissionRead, CreatedAt: time.Unix(0, 1).UTC()}
+	bookShare := contact.Share{ID: "s2", OwnerID: "1", GranteeID: "2", Permission: contact.PermissionRead, CreatedAt: time.Unix(0, 2).UTC()}
+	for _, s := range []contact.Share{bookShare, contactShare} {
+		if _, err := r.SaveShare(ctx, s); err != nil {
+			t.Fatalf("SaveShare() error = %v", err)
+		}
+	}
+
+	// sharing the contact again only changes the permission of the existing share
+	got, err := r.SaveShare(ctx, contact.Share{ID: "s3", OwnerID: "1", ContactID: "a", GranteeID: "2", Permission: contact.PermissionEdit})
+	if err != nil || got.ID != contactShare.ID || got.Permission != contact.PermissionEdit {
+		t.Fatalf("SaveShare() of an existing share = %+v, %v, want %s with edit", got, err, contactShare.ID)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	r, err = NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() after restart error = %v", err)
+	}
+	defer r.Close()
+
+	shares, err := r.ListSharesByOwner(ctx, "1")
+	if err != nil || len(shares) != 2 || shares[0].ID != contactShare.ID || shares[0].Permission != contact.PermissionEdit {
+		t.Errorf("ListSharesByOwner() = %+v, %v, want the edit share %s first", shares, err, contactShare.ID)
+	}
+	if shares, err := r.FindShares(ctx, "1", "3"); err != nil || len(shares) != 0 {
+		t.Errorf("FindShares() for another grantee = %+v, %v, want none", shares, err)
+	}
+
+	if err := r.DeleteSharesOfContact(ctx, "1", "a"); err != nil {
+		t.Fatalf("DeleteSharesOfContact() error = %v", err)
+	}
+	if err := r.DeleteShare(ctx, "2", bookShare.ID); err == nil {
+		t.Errorf("DeleteShare() by another owner expected an error")
+	}
+	if shares, err := r.ListSharesByGrantee(ctx, "2"); err != nil || len(shares) != 1 || shares[0] != bookShare {
+		t.Errorf("ListSharesByGrantee() = %+v, %v, want only %+v", shares, err, bookShare)
+	}
+}
+
+func Test_repository_Books(t *testing.T) {
+	ctx := context.Background()
+	logger := stdout.NewLogger()
+	dir := t.TempDir()
+
+	r, err := NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	joinedAt := time.Unix(0, 1).UTC()
+	owner := contact.Member{UserID: "1", Role: contact.RoleOwner, InvitedAt: joinedAt, JoinedAt: joinedAt}
+	invited := contact.Member{UserID: "2", Role: contact.RoleViewer, InvitedAt: joinedAt}
+	for _, b := range []contact.Book{
+		{ID: "b2", Name: "team", OwnerID: "3", CreatedAt: time.Unix(0, 2).UTC(), Members: []contact.Member{
+			{UserID: "3", Role: contact.RoleOwner, InvitedAt: joinedAt, JoinedAt: joinedAt},
+			{UserID: "1", Role: contact.RoleEditor, InvitedAt: joinedAt, JoinedAt: joinedAt},
+		}},
+		{ID: "b1", Name: "family", OwnerID: "1", CreatedAt: time.Unix(0, 1).UTC(), Members: []contact.Member{owner, invited}},
+	} {
+		if err := r.CreateBook(ctx, b); err != nil {
+			t.Fatalf("CreateBook() error = %v", err)
+		}
+	}
+
+	// accepting the invite replaces the membership
+	invited.JoinedAt = joinedAt
+	if err := r.SaveMember(ctx, "b1", invited); err != nil {
+		t.Fatalf("SaveMember() error = %v", err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	r, err = NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() after restart error = %v", err)
+	}
+	defer r.Close()
+
+	books, err := r.ListBooksByMember(ctx, "1")
+	if err != nil || len(books) != 2 || books[0].ID != "b1" {
+		t.Fatalf("ListBooksByMember() = %+v, %v, want b1 first", books, err)
+	}
+	if m, ok := books[0].Member("2"); len(books[0].Members) != 2 || !ok || !m.IsActive() {
+		t.Errorf("members of b1 = %+v, want the owner and the active viewer", books[0].Members)
+	}
+	if m, _ := books[1].Member("1"); m.Role != contact.RoleEditor || !m.IsActive() {
+		t.Errorf("membership in b2 = %+v, want an active editor", m)
+	}
+
+	if err := r.SaveMember(ctx, "missing", invited); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("SaveMember() to a missing book error = %v, want not found", err)
+	}
+	if err := r.DeleteMember(ctx, "b2", "1"); err != nil {
+		t.Fatalf("DeleteMember() error = %v", err)
+	}
+	if err := r.DeleteMember(ctx, "b2", "1"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("DeleteMember() of a removed member error = %v, want not found", err)
+	}
+	if err := r.DeleteBook(ctx, "b1"); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	if _, err := r.GetBook(ctx, "b1"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("GetBook() of a deleted book error = %v, want not found", err)
+	}
+	if books, err := r.ListBooksByMember(ctx, "1"); err != nil || len(books) != 0 {
+		t.Errorf("ListBooksByMember() after the deletes = %+v, %v, want none", books, err)
+	}
+}
+
+func Test_repository_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	logger := stdout.NewLogger()
+	dir := t.TempDir()
+
+	r, err := NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	first := apikey.APIKey{ID: "k1", Name: "first", Prefix: "pbk_a", Hash: "h1", UserID: "1", Scopes: []string{apikey.ScopeRead, apikey.ScopeWrite}, CreatedAt: time.Unix(0, 1).UTC()}
+	second := apikey.APIKey{ID: "k2", Name: "second", Prefix: "pbk_b", Hash: "h2", Scopes: []string{apikey.ScopeAdmin}, CreatedAt: time.Unix(0, 2).UTC(), ExpiresAt: time.Unix(0, 3).UTC()}
+	for _, k := range []apikey.APIKey{second, first} {
+		if err := r.CreateKey(ctx, k); err != nil {
+			t.Fatalf("CreateKey() error = %v", err)
+		}
+	}
+	if err := r.CreateKey(ctx, apikey.APIKey{ID: "k3", Hash: first.Hash}); myerror.GetParsedError(err).Type != myerror.BadRequestError {
+		t.Errorf("CreateKey() of a stored hash error = %v, want bad request", err)
+	}
+	if err := r.RevokeKey(ctx, first.ID, time.Unix(0, 4)); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	r, err = NewRepository(dir, 0, logger)
+	if err != nil {
+		t.Fatalf("NewRepository() after restart error = %v", err)
+	}
+	defer r.Close()
+
+	got, err := r.GetKeyByHash(ctx, first.Hash)
+	if err != nil {
+		t.Fatalf("GetKeyByHash() error = %v", err)
+	}
+	if got.ID != first.ID || len(got.Scopes) != 2 || !got.ExpiresAt.IsZero() || got.RevokedAt.UnixNano() != 4 {
+		t.Errorf("GetKeyByHash() = %+v, want %s revoked at 4 with two scopes", got, first.ID)
+	}
+
+	// a second revocation keeps the first one
+	if err := r.RevokeKey(ctx, first.ID, time.Unix(0, 5)); err != nil {
+		t.Fatalf("RevokeKey() of a revoked key error = %v", err)
+	}
+	keys, err := r.ListKeys(ctx)
+	if err != nil || len(keys) != 2 || keys[0].ID != first.ID || keys[0].RevokedAt.UnixNano() != 4 || !keys[1].ExpiresAt.Equal(second.ExpiresAt) {
+		t.Errorf("ListKeys() = %+v, %v, want %s first, revoked at 4", keys, err, first.ID)
+	}
+
+	if err := r.RevokeKey(ctx, "missing", time.Unix(0, 4)); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("RevokeKey() of a missing key error = %v, want not found", err)
+	}
+	if _, err := r.GetKeyByHash(ctx, "missing"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("GetKeyByHash() of a missing hash error = %v, want not found", err)
+	}
+}
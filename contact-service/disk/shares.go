@@ -0,0 +1,123 @@
+package disk
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"sort"
+
+	"contact-service/contact"
+)
+
+// SaveShare creates the share, or replaces the permission of the existing share of the same contact and grantee
+func (r *repository) SaveShare(_ context.Context, s contact.Share) (contact.Share, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id, existing := range r.shares {
+		if existing.OwnerID == s.OwnerID && existing.ContactID == s.ContactID && existing.GranteeID == s.GranteeID {
+			existing.Permission = s.Permission
+			if err := r.persistShares(func(shares map[string]contact.Share) { shares[id] = existing }); err != nil {
+				return contact.Share{}, myerror.Wrap(err, "disk.SaveShare")
+			}
+			return existing, nil
+		}
+	}
+
+	if err := r.persistShares(func(shares map[string]contact.Share) { shares[s.ID] = s }); err != nil {
+		return contact.Share{}, myerror.Wrap(err, "disk.SaveShare")
+	}
+
+	return s, nil
+}
+
+func (r *repository) DeleteShare(_ context.Context, ownerID, shareID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.shares[shareID]
+	if !ok || s.OwnerID != ownerID {
+		return myerror.NewNotFoundError("disk.DeleteShare: share with ID %s not found for user %s", shareID, ownerID)
+	}
+
+	if err := r.persistShares(func(shares map[string]contact.Share) { delete(shares, shareID) }); err != nil {
+		return myerror.Wrap(err, "disk.DeleteShare")
+	}
+
+	return nil
+}
+
+// DeleteSharesOfContact drops the shares of a single contact, e.g. once it is deleted
+func (r *repository) DeleteSharesOfContact(_ context.Context, ownerID, contactID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.persistShares(func(shares map[string]contact.Share) {
+		for id, s := range shares {
+			if s.OwnerID == ownerID && s.ContactID == contactID {
+				delete(shares, id)
+			}
+		}
+	})
+	if err != nil {
+		return myerror.Wrap(err, "disk.DeleteSharesOfContact")
+	}
+
+	return nil
+}
+
+func (r *repository) ListSharesByOwner(_ context.Context, ownerID string) ([]contact.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.listShares(func(s contact.Share) bool { return s.OwnerID == ownerID }), nil
+}
+
+func (r *repository) ListSharesByGrantee(_ context.Context, granteeID string) ([]contact.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.listShares(func(s contact.Share) bool { return s.GranteeID == granteeID }), nil
+}
+
+// FindShares returns the shares granted by the owner to the grantee
+func (r *repository) FindShares(_ context.Context, ownerID, granteeID string) ([]contact.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.listShares(func(s contact.Share) bool { return s.OwnerID == ownerID && s.GranteeID == granteeID }), nil
+}
+
+// listShares must be called while holding the lock, shares are returned oldest first
+func (r *repository) listShares(match func(contact.Share) bool) []contact.Share {
+	shares := make([]contact.Share, 0)
+	for _, s := range r.shares {
+		if match(s) {
+			shares = append(shares, s)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+		}
+		return shares[i].ID < shares[j].ID
+	})
+
+	return shares
+}
+
+// persistShares must be called while holding the write lock. It applies the change to a copy of the shares, writes
+// the copy to the shares file and only then keeps it, so a failed write leaves the shares unchanged.
+func (r *repository) persistShares(change func(map[string]contact.Share)) error {
+	shares := make(map[string]contact.Share, len(r.shares)+1)
+	for id, s := range r.shares {
+		shares[id] = s
+	}
+	change(shares)
+
+	if err := writeJSON(r.sharesPath(), shares); err != nil {
+		return myerror.Wrap(err, "persistShares")
+	}
+	r.shares = shares
+
+	return nil
+}
@@ -0,0 +1,361 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"infrastructure/mycontext"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"contact-service/contact"
+	"contact-service/inmem"
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})   {}
+func (nopLogger) Error(context.Context, error, ...interface{})   {}
+func (nopLogger) Warning(context.Context, error, ...interface{}) {}
+func (nopLogger) Debug(context.Context, string, ...interface{})  {}
+
+// searchRecorder records the filters of the searches that reach the repository, the background re-encryption
+// searches too
+type searchRecorder struct {
+	Repository
+	mu      sync.Mutex
+	filters []contact.Filters
+}
+
+func (s *searchRecorder) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	s.mu.Lock()
+	s.filters = append(s.filters, filters)
+	s.mu.Unlock()
+
+	return s.Repository.SearchContacts(ctx, filters)
+}
+
+// fullScans counts the searches of the user's contacts that did not filter by phone
+func (s *searchRecorder) fullScans(userID string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n := 0
+	for _, f := range s.filters {
+		if f.UserID == userID && f.Phone == "" {
+			n++
+		}
+	}
+
+	return n
+}
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(b), dataKeySize)))
+}
+
+func writeKeyring(t *testing.T, file keyringFile) *Keyring {
+	t.Helper()
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "keyring.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	k, err := LoadKeyring(path)
+	if err != nil {
+		t.Fatalf("LoadKeyring() error = %v", err)
+	}
+
+	return k
+}
+
+func TestRepository_EncryptsAtRest(t *testing.T) {
+	store := inmem.NewUserRepository()
+	keyring := writeKeyring(t, keyringFile{
+		Default: &keySetFile{Current: "k1", Keys: map[string]string{"k1": testKey('a')}, IndexKey: testKey('i')},
+	})
+	repo := NewRepository(store, keyring, nopLogger{})
+	ctx := context.Background()
+
+	want := contact.Contact{UserID: "1", ID: "c1", Phone: "0501234567", FirstName: "Dana", LastName: "Levi", Address: "Herzl 1"}
+	if err := repo.CreateContact(ctx, want); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	if err := repo.CreateContact(ctx, contact.Contact{UserID: "1", ID: "c2", Phone: "0509999999", FirstName: "Amit"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+
+	stored, _ := store.GetContact(ctx, "1", "c1")
+	for _, value := range []string{stored.Phone, stored.EncryptedPhone, stored.FirstName, stored.LastName, stored.Address} {
+		if strings.Contains(value, "050") || strings.Contains(value, "Dana") || strings.Contains(value, "Levi") || strings.Contains(value, "Herzl") {
+			t.Errorf("stored value %q is in clear", value)
+		}
+	}
+
+	got, err := repo.GetContact(ctx, "1", "c1")
+	if err != nil {
+		t.Fatalf("GetContact() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("GetContact() = %+v, want %+v", got, want)
+	}
+
+	if exists, _ := repo.IsPhoneExistsForUser(ctx, "1", "0501234567"); !exists {
+		t.Error("IsPhoneExistsForUser() = false, want true")
+	}
+	if exists, _ := repo.IsPhoneExistsForUser(ctx, "2", "0501234567"); exists {
+		t.Error("IsPhoneExistsForUser() of another user = true")
+	}
+
+	tests := []struct {
+		name    string
+		filters contact.Filters
+		wantIDs []string
+	}{
+		{name: "all ordered by first name", filters: contact.Filters{UserID: "1", Limit: 10}, wantIDs: []string{"c2", "c1"}},
+		{name: "by phone", filters: contact.Filters{UserID: "1", Phone: "0501234567", Limit: 10}, wantIDs: []string{"c1"}},
+		{name: "by last name", filters: contact.Filters{UserID: "1", LastName: "Levi", Limit: 10}, wantIDs: []string{"c1"}},
+		{name: "offset", filters: contact.Filters{UserID: "1", Limit: 10, Offset: 1}, wantIDs: []string{"c1"}},
+		{name: "limit", filters: contact.Filters{UserID: "1", Limit: 1}, wantIDs: []string{"c2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contacts, err := repo.SearchContacts(ctx, tt.filters)
+			if err != nil {
+				t.Fatalf("SearchContacts() error = %v", err)
+			}
+
+			var ids []string
+			for _, c := range contacts {
+				ids = append(ids, c.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("SearchContacts() = %v, want %v", ids, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestRepository_SearchByPhone(t *testing.T) {
+	store := &searchRecorder{Repository: inmem.NewUserRepository()}
+	repo := NewRepository(store, writeKeyring(t, keyringFile{
+		Default: &keySetFile{Current: "k1", Keys: map[string]string{"k1": testKey('a')}, IndexKey: testKey('i')},
+	}), nopLogger{})
+	ctx := context.Background()
+
+	if err := repo.CreateContact(ctx, contact.Contact{UserID: "1", ID: "c1", Phone: "123", FirstName: "Dana"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	if err := repo.CreateContact(ctx, contact.Contact{UserID: "1", ID: "c2", Phone: "456", FirstName: "Amit"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	// a contact stored before encryption was enabled, of another user as its search queues a re-encryption that reads
+	// every contact of the user
+	if err := store.CreateContact(ctx, contact.Contact{UserID: "2", ID: "c3", Phone: "789", FirstName: "Noa"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		filters contact.Filters
+		wantIDs []string
+	}{
+		{name: "encrypted", filters: contact.Filters{UserID: "1", Phone: "123", Limit: 10}, wantIDs: []string{"c1"}},
+		{name: "in clear", filters: contact.Filters{UserID: "2", Phone: "789", Limit: 10}, wantIDs: []string{"c3"}},
+		{name: "with other filters", filters: contact.Filters{UserID: "1", Phone: "123", FirstName: "Amit", Limit: 10}},
+		{name: "unknown", filters: contact.Filters{UserID: "1", Phone: "000", Limit: 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contacts, err := repo.SearchContacts(ctx, tt.filters)
+			if err != nil {
+				t.Fatalf("SearchContacts() error = %v", err)
+			}
+
+			var ids []string
+			for _, c := range contacts {
+				ids = append(ids, c.ID)
+			}
+			if strings.Join(ids, ",") != strings.Join(tt.wantIDs, ",") {
+				t.Errorf("SearchContacts() = %v, want %v", ids, tt.wantIDs)
+			}
+			if n := store.fullScans("1"); n != 0 {
+				t.Errorf("SearchContacts() read every contact of the user %d times", n)
+			}
+		})
+	}
+
+	if err := repo.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+}
+
+func TestRepository_TenantKeys(t *testing.T) {
+	store := inmem.NewUserRepository()
+	keyring := writeKeyring(t, keyringFile{
+		Tenants: map[string]*keySetFile{
+			"acme": {Current: "acme-1", Keys: map[string]string{"acme-1": testKey('a')}, IndexKey: testKey('i')},
+		},
+	})
+	repo := NewRepository(store, keyring, nopLogger{})
+	acme := mycontext.WithTenantID(context.Background(), "acme")
+
+	if err := repo.CreateContact(acme, contact.Contact{UserID: "1", ID: "c1", Phone: "123"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	if stored, _ := store.GetContact(acme, "1", "c1"); !strings.HasPrefix(stored.EncryptedPhone, "enc:acme-1:") {
+		t.Errorf("stored phone = %q, want sealed by acme-1", stored.EncryptedPhone)
+	}
+
+	globex := mycontext.WithTenantID(context.Background(), "globex")
+	if err := repo.CreateContact(globex, contact.Contact{UserID: "1", ID: "c2", Phone: "123"}); err == nil {
+		t.Error("CreateContact() of a tenant without keys succeeded")
+	}
+}
+
+func TestRepository_Reencrypt(t *testing.T) {
+	store := inmem.NewUserRepository()
+	ctx := context.Background()
+
+	old := NewRepository(store, writeKeyring(t, keyringFile{
+		Default: &keySetFile{Current: "k1", Keys: map[string]string{"k1": testKey('a')}, IndexKey: testKey('i')},
+	}), nopLogger{})
+	if err := old.CreateContact(ctx, contact.Contact{UserID: "1", ID: "c1", Phone: "123", FirstName: "Dana"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	if err := old.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// a contact stored before encryption was enabled
+	legacy := contact.Contact{UserID: "1", ID: "c2", Phone: "456", FirstName: "Amit"}
+	if err := store.CreateContact(ctx, legacy); err != nil {
+		t.Fatal(err)
+	}
+
+	rotated := NewRepository(store, writeKeyring(t, keyringFile{
+		Default: &keySetFile{Current: "k2", Keys: map[string]string{"k1": testKey('a'), "k2": testKey('b')}, IndexKey: testKey('i')},
+	}), nopLogger{})
+
+	if exists, _ := rotated.IsPhoneExistsForUser(ctx, "1", "456"); !exists {
+		t.Error("IsPhoneExistsForUser() of a contact in clear = false, want true")
+	}
+
+	contacts, err := rotated.SearchContacts(ctx, contact.Filters{UserID: "1", Limit: 10})
+	if err != nil {
+		t.Fatalf("SearchContacts() error = %v", err)
+	}
+	if len(contacts) != 2 || contacts[0].FirstName != "Amit" || contacts[1].FirstName != "Dana" {
+		t.Fatalf("SearchContacts() = %+v", contacts)
+	}
+
+	// closing waits for the re-encryption queued by the search
+	if err := rotated.Close(ctx); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	for _, id := range []string{"c1", "c2"} {
+		stored, _ := store.GetContact(ctx, "1", id)
+		if !strings.HasPrefix(stored.EncryptedPhone, "enc:k2:") || !strings.HasPrefix(stored.FirstName, "enc:k2:") {
+			t.Errorf("contact %s stored = %+v, want sealed by k2", id, stored)
+		}
+	}
+	if exists, _ := rotated.IsPhoneExistsForUser(ctx, "1", "456"); !exists {
+		t.Error("IsPhoneExistsForUser() after re-encryption = false, want true")
+	}
+}
+
+// blockingUpdates counts the updates that reach the repository and holds the first one until release is closed
+type blockingUpdates struct {
+	Repository
+	updates atomic.Int32
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingUpdates) UpdateContact(ctx context.Context, c contact.Contact) error {
+	if b.updates.Add(1) == 1 {
+		close(b.entered)
+		<-b.release
+	}
+
+	return b.Repository.UpdateContact(ctx, c)
+}
+
+func TestRepository_CloseWithQueuedJobs(t *testing.T) {
+	store := &blockingUpdates{Repository: inmem.NewUserRepository(), entered: make(chan struct{}), release: make(chan struct{})}
+	ctx := context.Background()
+
+	// contacts stored before encryption was enabled, two for each of three users
+	for _, userID := range []string{"1", "2", "3"} {
+		for _, id := range []string{"c1", "c2"} {
+			if err := store.CreateContact(ctx, contact.Contact{UserID: userID, ID: id, Phone: id}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	repo := NewRepository(store, writeKeyring(t, keyringFile{
+		Default: &keySetFile{Current: "k1", Keys: map[string]string{"k1": testKey('a')}, IndexKey: testKey('i')},
+	}), nopLogger{})
+	for _, userID := range []string{"1", "2", "3"} {
+		if _, err := repo.SearchContacts(ctx, contact.Filters{UserID: userID, Limit: 10}); err != nil {
+			t.Fatalf("SearchContacts() error = %v", err)
+		}
+	}
+	<-store.entered
+
+	closeCtx, cancel := context.WithCancel(ctx)
+	closed := make(chan error)
+	go func() { closed <- repo.Close(closeCtx) }()
+
+	// the wait of Close is over while the first contact is being written and the other users are still queued
+	cancel()
+	<-repo.reencrypt.ctx.Done()
+	close(store.release)
+
+	if err := <-closed; err == nil {
+		t.Errorf("Close() error = nil, want the cancellation of its wait")
+	}
+	if n := store.updates.Load(); n != 1 {
+		t.Errorf("repository updated %d times, want only the update in progress when Close gave up", n)
+	}
+}
+
+func TestLoadKeyring_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		file keyringFile
+	}{
+		{name: "no keys", file: keyringFile{}},
+		{name: "missing current key", file: keyringFile{Default: &keySetFile{Current: "k2", Keys: map[string]string{"k1": testKey('a')}, IndexKey: testKey('i')}}},
+		{name: "short key", file: keyringFile{Default: &keySetFile{Current: "k1", Keys: map[string]string{"k1": "c2hvcnQ="}, IndexKey: testKey('i')}}},
+		{name: "key ID with colon", file: keyringFile{Default: &keySetFile{Current: "k:1", Keys: map[string]string{"k:1": testKey('a')}, IndexKey: testKey('i')}}},
+		{name: "missing index key", file: keyringFile{Default: &keySetFile{Current: "k1", Keys: map[string]string{"k1": testKey('a')}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := json.Marshal(tt.file)
+			path := filepath.Join(t.TempDir(), "keyring.json")
+			if err := os.WriteFile(path, data, 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := LoadKeyring(path); err == nil {
+				t.Error("LoadKeyring() error = nil, want an error")
+			}
+		})
+	}
+}
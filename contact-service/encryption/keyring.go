@@ -0,0 +1,185 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"infrastructure/myerror"
+	"os"
+	"strings"
+)
+
+const (
+	// encryptedPrefix marks encrypted values, values without it were stored before encryption was enabled
+	encryptedPrefix  = "enc:"
+	blindIndexPrefix = "bi:"
+
+	dataKeySize      = 32
+	minIndexKeySize  = 32
+	blindIndexLength = 16
+)
+
+// keySetFile is the keys of one tenant in the keyring file, keys are base64 encoded
+type keySetFile struct {
+	Current  string            `json:"current"`
+	Keys     map[string]string `json:"keys"`
+	IndexKey string            `json:"indexKey"`
+}
+
+// keyringFile holds the keys of every tenant, the default keys serve requests without a tenant and the tenants
+// without keys of their own
+type keyringFile struct {
+	Default *keySetFile            `json:"default"`
+	Tenants map[string]*keySetFile `json:"tenants"`
+}
+
+// keySet encrypts with the current data key and decrypts with any of them. Its index key is never rotated, since the
+// blind indexes of the stored phones would then no longer match.
+type keySet struct {
+	current  string
+	keys     map[string]cipher.AEAD
+	indexKey []byte
+}
+
+type Keyring struct {
+	defaultKeys *keySet
+	tenants     map[string]*keySet
+}
+
+// LoadKeyring reads the keyring file at path
+func LoadKeyring(path string) (*Keyring, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, myerror.Wrap(err, "encryption.LoadKeyring")
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, myerror.NewBadRequestError("encryption.LoadKeyring: %s: %v", path, err)
+	}
+
+	k := &Keyring{
+		tenants: make(map[string]*keySet, len(file.Tenants)),
+	}
+	if file.Default != nil {
+		if k.defaultKeys, err = newKeySet(file.Default); err != nil {
+			return nil, myerror.Wrap(err, "encryption.LoadKeyring: default")
+		}
+	}
+	for tenantID, f := range file.Tenants {
+		if k.tenants[tenantID], err = newKeySet(f); err != nil {
+			return nil, myerror.Wrap(err, "encryption.LoadKeyring: tenant %s", tenantID)
+		}
+	}
+	if k.defaultKeys == nil && len(k.tenants) == 0 {
+		return nil, myerror.NewBadRequestError("encryption.LoadKeyring: %s has no keys", path)
+	}
+
+	return k, nil
+}
+
+func newKeySet(f *keySetFile) (*keySet, error) {
+	ks := &keySet{
+		current: f.Current,
+		keys:    make(map[string]cipher.AEAD, len(f.Keys)),
+	}
+
+	for keyID, encoded := range f.Keys {
+		if keyID == "" || strings.Contains(keyID, ":") {
+			return nil, myerror.NewBadRequestError("newKeySet: key ID %q must be non-empty and without ':'", keyID)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil || len(key) != dataKeySize {
+			return nil, myerror.NewBadRequestError("newKeySet: key %s must be %d base64 encoded bytes", keyID, dataKeySize)
+		}
+
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, myerror.NewInternalError("newKeySet: %v", err)
+		}
+		if ks.keys[keyID], err = cipher.NewGCM(block); err != nil {
+			return nil, myerror.NewInternalError("newKeySet: %v", err)
+		}
+	}
+
+	if _, ok := ks.keys[ks.current]; !ok {
+		return nil, myerror.NewBadRequestError("newKeySet: current key %q is not in keys", ks.current)
+	}
+
+	indexKey, err := base64.StdEncoding.DecodeString(f.IndexKey)
+	if err != nil || len(indexKey) < minIndexKeySize {
+		return nil, myerror.NewBadRequestError("newKeySet: indexKey must be at least %d base64 encoded bytes", minIndexKeySize)
+	}
+	ks.indexKey = indexKey
+
+	return ks, nil
+}
+
+// keySet returns the keys of the tenant, or the default keys
+func (k *Keyring) keySet(tenantID string) (*keySet, error) {
+	if ks, ok := k.tenants[tenantID]; ok {
+		return ks, nil
+	}
+	if k.defaultKeys != nil {
+		return k.defaultKeys, nil
+	}
+
+	return nil, myerror.NewInternalError("keySet: no encryption keys for tenant %q", tenantID)
+}
+
+// seal encrypts the value with the current key, the additional data binds it to the field it is stored in
+func (ks *keySet) seal(value string, additionalData []byte) (string, error) {
+	aead := ks.keys[ks.current]
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", myerror.NewInternalError("seal: %v", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), additionalData)
+
+	return encryptedPrefix + ks.current + ":" + base64.RawURLEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value sealed with any key of the set, stale tells whether it was not sealed with the current key.
+// Values stored before encryption was enabled are returned as they are, and are stale too.
+func (ks *keySet) open(value string, additionalData []byte) (plain string, stale bool, err error) {
+	if !strings.HasPrefix(value, encryptedPrefix) {
+		return value, true, nil
+	}
+
+	keyID, encoded, ok := strings.Cut(strings.TrimPrefix(value, encryptedPrefix), ":")
+	if !ok {
+		return "", false, myerror.NewInternalError("open: malformed encrypted value")
+	}
+	aead, ok := ks.keys[keyID]
+	if !ok {
+		return "", false, myerror.NewInternalError("open: unknown key %s", keyID)
+	}
+
+	sealed, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", false, myerror.NewInternalError("open: malformed encrypted value")
+	}
+	out, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additionalData)
+	if err != nil {
+		return "", false, myerror.NewInternalError("open: %v", err)
+	}
+
+	return string(out), keyID != ks.current, nil
+}
+
+// blindIndex is a keyed hash of the phone, equal for equal phones of the same user, so exact lookups work without
+// storing the phone in clear
+func (ks *keySet) blindIndex(userID, phone string) string {
+	mac := hmac.New(sha256.New, ks.indexKey)
+	mac.Write([]byte(userID))
+	mac.Write([]byte{0})
+	mac.Write([]byte(phone))
+
+	return blindIndexPrefix + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:blindIndexLength])
+}
@@ -0,0 +1,177 @@
+package encryption
+
+import (
+	"context"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"sync"
+)
+
+const reencryptQueueSize = 1024
+
+// reencryptJob is a user with contacts stored in clear or sealed by an old key
+type reencryptJob struct {
+	tenantID string
+	userID   string
+}
+
+// reencrypter re-encrypts in the background the contacts of the users queued by reads, so rotated keys can
+// eventually be removed from the keyring
+type reencrypter struct {
+	repo *repository
+	jobs chan reencryptJob
+	done chan struct{}
+
+	// ctx is canceled by close once its wait is over, so the queued jobs are dropped instead of writing to storage
+	// that is being closed
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	pending map[reencryptJob]struct{}
+	closed  bool
+}
+
+func newReencrypter(repo *repository) *reencrypter {
+	ctx, cancel := context.WithCancel(context.Background())
+	re := &reencrypter{
+		repo:    repo,
+		jobs:    make(chan reencryptJob, reencryptQueueSize),
+		done:    make(chan struct{}),
+		ctx:     ctx,
+		cancel:  cancel,
+		pending: make(map[reencryptJob]struct{}),
+	}
+	go re.run()
+
+	return re
+}
+
+// enqueue queues the user once until its contacts are re-encrypted, a full queue drops the user until it is read again
+func (re *reencrypter) enqueue(ctx context.Context, userID string) {
+	job := reencryptJob{tenantID: mycontext.TenantID(ctx), userID: userID}
+
+	re.mu.Lock()
+	defer re.mu.Unlock()
+
+	if _, ok := re.pending[job]; ok || re.closed {
+		return
+	}
+
+	select {
+	case re.jobs <- job:
+		re.pending[job] = struct{}{}
+	default:
+		re.repo.logger.Debug(ctx, "reencrypter.enqueue: queue is full", "userID", userID)
+	}
+}
+
+func (re *reencrypter) run() {
+	defer close(re.done)
+
+	for job := range re.jobs {
+		if re.ctx.Err() != nil {
+			return
+		}
+		ctx := mycontext.WithTenantID(re.ctx, job.tenantID)
+
+		re.mu.Lock()
+		delete(re.pending, job)
+		re.mu.Unlock()
+
+		count, err := re.repo.reencryptUser(ctx, job.userID)
+		if re.ctx.Err() != nil {
+			re.repo.logger.Info(ctx, "reencrypter.run: stopped before the queue was drained", "userID", job.userID, "count", count)
+			return
+		}
+		if err != nil {
+			re.repo.logger.Error(ctx, myerror.Wrap(err, "reencrypter.run"), "userID", job.userID)
+			continue
+		}
+		re.repo.logger.Info(ctx, "reencrypter.run: re-encrypted contacts", "userID", job.userID, "count", count)
+	}
+}
+
+// close stops taking jobs and waits for the queued ones until ctx is done. It then cancels the remaining jobs and
+// waits for the contact in progress, so nothing is written once it returns.
+func (re *reencrypter) close(ctx context.Context) error {
+	re.mu.Lock()
+	if !re.closed {
+		re.closed = true
+		close(re.jobs)
+	}
+	re.mu.Unlock()
+
+	select {
+	case <-re.done:
+		re.cancel()
+		return nil
+	case <-ctx.Done():
+		re.cancel()
+		<-re.done
+		return myerror.Wrap(ctx.Err(), "reencrypter.close")
+	}
+}
+
+// reencryptUser seals every stale contact of the user again with the current key, keeping its update time
+func (r *repository) reencryptUser(ctx context.Context, userID string) (int, error) {
+	ks, err := r.keyring.keySet(mycontext.TenantID(ctx))
+	if err != nil {
+		return 0, myerror.Wrap(err, "reencryptUser")
+	}
+
+	stored, err := r.allContacts(ctx, userID, "")
+	if err != nil {
+		return 0, myerror.Wrap(err, "reencryptUser")
+	}
+
+	count := 0
+	for _, c := range stored {
+		if err := ctx.Err(); err != nil {
+			return count, myerror.Wrap(err, "reencryptUser")
+		}
+
+		reencrypted, err := r.reencryptContact(ctx, ks, c.UserID, c.ID)
+		if err != nil {
+			return count, myerror.Wrap(err, "reencryptUser")
+		}
+		if reencrypted {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// reencryptContact reads the contact again under its lock, so a concurrent update or delete is not overwritten
+func (r *repository) reencryptContact(ctx context.Context, ks *keySet, userID, contactID string) (bool, error) {
+	mu := r.stripe(userID, contactID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	c, err := r.next.GetContact(ctx, userID, contactID)
+	if err != nil {
+		if myerror.GetParsedError(err).Type == myerror.NotFoundError {
+			return false, nil
+		}
+		return false, myerror.Wrap(err, "reencryptContact")
+	}
+
+	c, stale, err := decrypt(ks, c)
+	if err != nil {
+		return false, myerror.Wrap(err, "reencryptContact")
+	}
+	if !stale {
+		return false, nil
+	}
+
+	encrypted, err := encrypt(ks, c)
+	if err != nil {
+		return false, myerror.Wrap(err, "reencryptContact")
+	}
+	if err := r.next.UpdateContact(ctx, encrypted); err != nil {
+		return false, myerror.Wrap(err, "reencryptContact")
+	}
+
+	return true, nil
+}
@@ -0,0 +1,312 @@
+package encryption
+
+import (
+	"context"
+	"hash/fnv"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"sort"
+	"strings"
+	"sync"
+
+	"contact-service/contact"
+)
+
+const (
+	// searchPageSize is the number of contacts read from the repository at a time while searching
+	searchPageSize = 500
+	lockStripes    = 64
+)
+
+type Repository interface {
+	CreateContact(context.Context, contact.Contact) error
+	GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error)
+	DeleteContact(ctx context.Context, userID string, contactID string) error
+	SearchContacts(ctx context.Context, filters contact.Filters) (contacts []contact.Contact, err error)
+	UpdateContact(context.Context, contact.Contact) error
+	IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error)
+}
+
+type Logger interface {
+	Info(ctx context.Context, msg string, keyvals ...interface{})
+	Error(ctx context.Context, err error, keyvals ...interface{})
+	Warning(ctx context.Context, err error, keyvals ...interface{})
+	Debug(ctx context.Context, msg string, keyvals ...interface{})
+}
+
+// repository encrypts the phone, names and address of the contacts before they reach the repository and the caches
+// below it, with the keys of the tenant in the context. The phone is replaced by its blind index, so exact phone
+// lookups and searches by phone still reach the repository indexes. Searches by name or address alone cannot use them
+// and decrypt every contact of the user instead.
+type repository struct {
+	next    Repository
+	keyring *Keyring
+	logger  Logger
+
+	// stripes serializes the writes to the same contact with its re-encryption
+	stripes [lockStripes]sync.Mutex
+
+	reencrypt *reencrypter
+}
+
+// NewRepository starts the background re-encryption of contacts read with an old key, Close stops it
+func NewRepository(next Repository, keyring *Keyring, logger Logger) *repository {
+	r := &repository{
+		next:    next,
+		keyring: keyring,
+		logger:  logger,
+	}
+	r.reencrypt = newReencrypter(r)
+
+	return r
+}
+
+func (r *repository) CreateContact(ctx context.Context, c contact.Contact) error {
+	ks, err := r.keyring.keySet(mycontext.TenantID(ctx))
+	if err != nil {
+		return myerror.Wrap(err, "encryption.CreateContact")
+	}
+
+	encrypted, err := encrypt(ks, c)
+	if err != nil {
+		return myerror.Wrap(err, "encryption.CreateContact")
+	}
+
+	if err := r.next.CreateContact(ctx, encrypted); err != nil {
+		return myerror.Wrap(err, "encryption.CreateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	ks, err := r.keyring.keySet(mycontext.TenantID(ctx))
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "encryption.GetContact")
+	}
+
+	c, err := r.next.GetContact(ctx, userID, contactID)
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "encryption.GetContact")
+	}
+
+	c, stale, err := decrypt(ks, c)
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "encryption.GetContact")
+	}
+	if stale {
+		r.reencrypt.enqueue(ctx, userID)
+	}
+
+	return c, nil
+}
+
+func (r *repository) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	mu := r.stripe(userID, contactID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := r.next.DeleteContact(ctx, userID, contactID); err != nil {
+		return myerror.Wrap(err, "encryption.DeleteContact")
+	}
+
+	return nil
+}
+
+// SearchContacts decrypts every contact of the user and filters them here, ordered by first name like the
+// repositories do
+func (r *repository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	ks, err := r.keyring.keySet(mycontext.TenantID(ctx))
+	if err != nil {
+		return nil, myerror.Wrap(err, "encryption.SearchContacts")
+	}
+
+	// a phone is looked up by its blind index, and in clear for contacts not encrypted yet, the other filters are
+	// matched after decrypting
+	phones := []string{""}
+	if filters.Phone != "" {
+		phones = []string{ks.blindIndex(filters.UserID, filters.Phone), filters.Phone}
+	}
+
+	var stored []contact.Contact
+	for _, phone := range phones {
+		contacts, err := r.allContacts(ctx, filters.UserID, phone)
+		if err != nil {
+			return nil, myerror.Wrap(err, "encryption.SearchContacts")
+		}
+		stored = append(stored, contacts...)
+	}
+
+	var matches []contact.Contact
+	anyStale := false
+	for _, c := range stored {
+		c, stale, err := decrypt(ks, c)
+		if err != nil {
+			return nil, myerror.Wrap(err, "encryption.SearchContacts")
+		}
+		anyStale = anyStale || stale
+
+		if (filters.Phone != "" && c.Phone != filters.Phone) ||
+			(filters.FirstName != "" && c.FirstName != filters.FirstName) ||
+			(filters.LastName != "" && c.LastName != filters.LastName) ||
+			(filters.Address != "" && c.Address != filters.Address) {
+			continue
+		}
+		matches = append(matches, c)
+	}
+	if anyStale {
+		r.reencrypt.enqueue(ctx, filters.UserID)
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].FirstName != matches[j].FirstName {
+			return matches[i].FirstName < matches[j].FirstName
+		}
+		return matches[i].ID < matches[j].ID
+	})
+
+	if filters.Offset >= len(matches) {
+		return nil, nil
+	}
+	matches = matches[filters.Offset:]
+	if len(matches) > filters.Limit {
+		matches = matches[:filters.Limit]
+	}
+
+	return matches, nil
+}
+
+func (r *repository) UpdateContact(ctx context.Context, c contact.Contact) error {
+	ks, err := r.keyring.keySet(mycontext.TenantID(ctx))
+	if err != nil {
+		return myerror.Wrap(err, "encryption.UpdateContact")
+	}
+
+	encrypted, err := encrypt(ks, c)
+	if err != nil {
+		return myerror.Wrap(err, "encryption.UpdateContact")
+	}
+
+	mu := r.stripe(c.UserID, c.ID)
+	mu.Lock()
+	defer mu.Unlock()
+
+	if err := r.next.UpdateContact(ctx, encrypted); err != nil {
+		return myerror.Wrap(err, "encryption.UpdateContact")
+	}
+
+	return nil
+}
+
+// IsPhoneExistsForUser looks the phone up by its blind index, and in clear for contacts not encrypted yet
+func (r *repository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	ks, err := r.keyring.keySet(mycontext.TenantID(ctx))
+	if err != nil {
+		return false, myerror.Wrap(err, "encryption.IsPhoneExistsForUser")
+	}
+
+	for _, stored := range []string{ks.blindIndex(userID, phone), phone} {
+		exists, err := r.next.IsPhoneExistsForUser(ctx, userID, stored)
+		if err != nil {
+			return false, myerror.Wrap(err, "encryption.IsPhoneExistsForUser")
+		}
+		if exists {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// Close stops the background re-encryption, contacts still queued are re-encrypted the next time they are read
+func (r *repository) Close(ctx context.Context) error {
+	return r.reencrypt.close(ctx)
+}
+
+// allContacts reads every stored contact of the user with the stored phone, or all of them when phone is empty, a page
+// at a time
+func (r *repository) allContacts(ctx context.Context, userID, phone string) ([]contact.Contact, error) {
+	var contacts []contact.Contact
+	for {
+		page, err := r.next.SearchContacts(ctx, contact.Filters{
+			UserID: userID,
+			Phone:  phone,
+			Limit:  searchPageSize,
+			Offset: len(contacts),
+		})
+		if err != nil {
+			return nil, myerror.Wrap(err, "allContacts")
+		}
+
+		contacts = append(contacts, page...)
+		if len(page) < searchPageSize {
+			return contacts, nil
+		}
+	}
+}
+
+func (r *repository) stripe(userID, contactID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+	h.Write([]byte{0})
+	h.Write([]byte(contactID))
+
+	return &r.stripes[h.Sum32()%lockStripes]
+}
+
+// encrypt returns the contact as it is stored, with its fields sealed by the current key of the set
+func encrypt(ks *keySet, c contact.Contact) (contact.Contact, error) {
+	var err error
+	if c.EncryptedPhone, err = ks.seal(c.Phone, additionalData(c, "phone")); err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "encrypt")
+	}
+	c.Phone = ks.blindIndex(c.UserID, c.Phone)
+
+	for field, value := range fields(&c) {
+		if *value, err = ks.seal(*value, additionalData(c, field)); err != nil {
+			return contact.Contact{}, myerror.Wrap(err, "encrypt")
+		}
+	}
+
+	return c, nil
+}
+
+// decrypt returns the contact in clear, stale tells whether any of its fields is in clear or sealed by an old key
+// in the repository
+func decrypt(ks *keySet, c contact.Contact) (contact.Contact, bool, error) {
+	anyStale := c.EncryptedPhone == ""
+	if !anyStale {
+		phone, stale, err := ks.open(c.EncryptedPhone, additionalData(c, "phone"))
+		if err != nil {
+			return contact.Contact{}, false, myerror.Wrap(err, "decrypt: contact %s", c.ID)
+		}
+		c.Phone = phone
+		c.EncryptedPhone = ""
+		anyStale = stale
+	}
+
+	for field, value := range fields(&c) {
+		plain, stale, err := ks.open(*value, additionalData(c, field))
+		if err != nil {
+			return contact.Contact{}, false, myerror.Wrap(err, "decrypt: contact %s", c.ID)
+		}
+		*value = plain
+		anyStale = anyStale || stale
+	}
+
+	return c, anyStale, nil
+}
+
+// fields are the encrypted fields of the contact besides the phone, which also has a blind index
+func fields(c *contact.Contact) map[string]*string {
+	return map[string]*string{
+		"firstName": &c.FirstName,
+		"lastName":  &c.LastName,
+		"address":   &c.Address,
+	}
+}
+
+// additionalData binds a sealed value to the contact and field it belongs to, so it cannot be moved to another
+func additionalData(c contact.Contact, field string) []byte {
+	return []byte(strings.Join([]string{c.UserID, c.ID, field}, "\x00"))
+}
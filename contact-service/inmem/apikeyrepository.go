@@ -0,0 +1,86 @@
+package inmem
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"sort"
+	"sync"
+	"time"
+
+	"contact-service/apikey"
+)
+
+// apiKeyRepository indexes the API keys by ID and by hash, the hash being the lookup of every authenticated request
+type apiKeyRepository struct {
+	mu     sync.RWMutex
+	keys   map[string]apikey.APIKey
+	byHash map[string]string
+}
+
+func NewAPIKeyRepository() *apiKeyRepository {
+	return &apiKeyRepository{
+		keys:   make(map[string]apikey.APIKey),
+		byHash: make(map[string]string),
+	}
+}
+
+func (r *apiKeyRepository) CreateKey(_ context.Context, k apikey.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.byHash[k.Hash]; ok {
+		return myerror.NewBadRequestError("inmem.CreateKey: key already exists")
+	}
+
+	r.keys[k.ID] = k
+	r.byHash[k.Hash] = k.ID
+
+	return nil
+}
+
+func (r *apiKeyRepository) GetKeyByHash(_ context.Context, hash string) (apikey.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if id, ok := r.byHash[hash]; ok {
+		return r.keys[id], nil
+	}
+
+	return apikey.APIKey{}, myerror.NewNotFoundError("inmem.GetKeyByHash: key not found")
+}
+
+// ListKeys returns every key, revoked and expired ones included, oldest first
+func (r *apiKeyRepository) ListKeys(context.Context) ([]apikey.APIKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	keys := make([]apikey.APIKey, 0, len(r.keys))
+	for _, k := range r.keys {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if !keys[i].CreatedAt.Equal(keys[j].CreatedAt) {
+			return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+		}
+		return keys[i].ID < keys[j].ID
+	})
+
+	return keys, nil
+}
+
+func (r *apiKeyRepository) RevokeKey(_ context.Context, keyID string, revokedAt time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	k, ok := r.keys[keyID]
+	if !ok {
+		return myerror.NewNotFoundError("inmem.RevokeKey: key with ID %s not found", keyID)
+	}
+
+	if k.RevokedAt.IsZero() {
+		k.RevokedAt = revokedAt
+		r.keys[keyID] = k
+	}
+
+	return nil
+}
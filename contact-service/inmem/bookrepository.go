@@ -0,0 +1,140 @@
+package inmem
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"sort"
+	"sync"
+
+	"contact-service/contact"
+)
+
+// bookRepository indexes the books by member, invited members included
+type bookRepository struct {
+	mu       sync.RWMutex
+	books    map[string]contact.Book
+	byMember map[string]map[string]struct{}
+}
+
+func NewBookRepository() *bookRepository {
+	return &bookRepository{
+		books:    make(map[string]contact.Book),
+		byMember: make(map[string]map[string]struct{}),
+	}
+}
+
+func (r *bookRepository) CreateBook(_ context.Context, b contact.Book) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b.Members = append([]contact.Member(nil), b.Members...)
+	r.books[b.ID] = b
+	for _, m := range b.Members {
+		addToSet(r.byMember, m.UserID, b.ID)
+	}
+
+	return nil
+}
+
+func (r *bookRepository) GetBook(_ context.Context, bookID string) (contact.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, ok := r.books[bookID]
+	if !ok {
+		return contact.Book{}, myerror.NewNotFoundError("inmem.GetBook: book with ID %s not found", bookID)
+	}
+
+	return copyBook(b), nil
+}
+
+// ListBooksByMember returns the books the user owns, belongs to or is invited to, oldest first
+func (r *bookRepository) ListBooksByMember(_ context.Context, userID string) ([]contact.Book, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	books := make([]contact.Book, 0, len(r.byMember[userID]))
+	for id := range r.byMember[userID] {
+		books = append(books, copyBook(r.books[id]))
+	}
+	sort.Slice(books, func(i, j int) bool {
+		if !books[i].CreatedAt.Equal(books[j].CreatedAt) {
+			return books[i].CreatedAt.Before(books[j].CreatedAt)
+		}
+		return books[i].ID < books[j].ID
+	})
+
+	return books, nil
+}
+
+// SaveMember adds the member to the book, or replaces the membership of the same user
+func (r *bookRepository) SaveMember(_ context.Context, bookID string, m contact.Member) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.books[bookID]
+	if !ok {
+		return myerror.NewNotFoundError("inmem.SaveMember: book with ID %s not found", bookID)
+	}
+
+	members := make([]contact.Member, 0, len(b.Members)+1)
+	for _, existing := range b.Members {
+		if existing.UserID != m.UserID {
+			members = append(members, existing)
+		}
+	}
+	b.Members = append(members, m)
+	r.books[bookID] = b
+	addToSet(r.byMember, m.UserID, bookID)
+
+	return nil
+}
+
+func (r *bookRepository) DeleteMember(_ context.Context, bookID, userID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.books[bookID]
+	if !ok {
+		return myerror.NewNotFoundError("inmem.DeleteMember: book with ID %s not found", bookID)
+	}
+
+	members := make([]contact.Member, 0, len(b.Members))
+	for _, existing := range b.Members {
+		if existing.UserID != userID {
+			members = append(members, existing)
+		}
+	}
+	if len(members) == len(b.Members) {
+		return myerror.NewNotFoundError("inmem.DeleteMember: user %s is not a member of book %s", userID, bookID)
+	}
+	b.Members = members
+	r.books[bookID] = b
+	removeFromSet(r.byMember, userID, bookID)
+
+	return nil
+}
+
+// DeleteBook drops the book and its memberships, its contacts are stored with the contacts of the users
+func (r *bookRepository) DeleteBook(_ context.Context, bookID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, ok := r.books[bookID]
+	if !ok {
+		return myerror.NewNotFoundError("inmem.DeleteBook: book with ID %s not found", bookID)
+	}
+
+	delete(r.books, bookID)
+	for _, m := range b.Members {
+		removeFromSet(r.byMember, m.UserID, bookID)
+	}
+
+	return nil
+}
+
+// copyBook keeps the callers from changing the members held by the repository
+func copyBook(b contact.Book) contact.Book {
+	b.Members = append([]contact.Member(nil), b.Members...)
+	return b
+}
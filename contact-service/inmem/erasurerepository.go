@@ -0,0 +1,52 @@
+package inmem
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"sync"
+
+	"contact-service/contact"
+)
+
+// erasureRepository keeps the erasure receipts in the order they were saved
+type erasureRepository struct {
+	mu       sync.RWMutex
+	receipts []contact.ErasureReceipt
+	byID     map[string]int
+}
+
+func NewErasureRepository() *erasureRepository {
+	return &erasureRepository{
+		byID: make(map[string]int),
+	}
+}
+
+func (r *erasureRepository) SaveReceipt(_ context.Context, receipt contact.ErasureReceipt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.byID[receipt.ID] = len(r.receipts)
+	r.receipts = append(r.receipts, receipt)
+
+	return nil
+}
+
+func (r *erasureRepository) GetReceipt(_ context.Context, receiptID string) (contact.ErasureReceipt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, ok := r.byID[receiptID]
+	if !ok {
+		return contact.ErasureReceipt{}, myerror.NewNotFoundError("inmem.GetReceipt: receipt with ID %s not found", receiptID)
+	}
+
+	return r.receipts[i], nil
+}
+
+// ListReceipts returns the receipts oldest first
+func (r *erasureRepository) ListReceipts(context.Context) ([]contact.ErasureReceipt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return append([]contact.ErasureReceipt(nil), r.receipts...), nil
+}
@@ -28,6 +28,11 @@ func (c *lockCache) Lock(_ context.Context, key string) (bool, error) {
 	return false, nil
 }
 
+// HealthCheck always succeeds, the locks live in the process memory
+func (c *lockCache) HealthCheck(context.Context) error {
+	return nil
+}
+
 func (c *lockCache) Unlock(_ context.Context, key string) error {
 	c.mutex.Lock()
 	defer c.mutex.Unlock()
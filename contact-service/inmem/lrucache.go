@@ -3,8 +3,14 @@ package inmem
 import (
 	"container/list"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
 
 	"contact-service/contact"
 	"infrastructure/myerror"
@@ -27,26 +33,70 @@ type Logger interface {
 }
 
 type CacheEntry struct {
-	Key   string
-	Value any
+	Key       string
+	Value     any
+	ExpiresAt time.Time
+}
+
+// CacheStats is a point-in-time view of the cache counters since it was created
+type CacheStats struct {
+	Hits        uint64 `json:"hits"`
+	Misses      uint64 `json:"misses"`
+	Evictions   uint64 `json:"evictions"`
+	Expirations uint64 `json:"expirations"`
+	Coalesced   uint64 `json:"coalesced"`
+	Size        int    `json:"size"`
+	Capacity    int    `json:"capacity"`
+}
+
+// searchKeyPrefix starts the keys of cached search pages
+const searchKeyPrefix = "search:"
+
+// notFoundEntry is cached for contacts the repository did not find
+type notFoundEntry struct{}
+
+// searchPage is the cached result of a single SearchContacts call
+type searchPage struct {
+	UserID   string
+	Contacts []contact.Contact
 }
 
 type lruCache struct {
-	repo     Repository
-	capacity int
-	cache    map[string]*list.Element
-	lruList  *list.List
-	mutex    sync.RWMutex
-	logger   Logger
+	repo        Repository
+	capacity    int
+	ttl         time.Duration
+	negativeTTL time.Duration
+	cache       map[string]*list.Element
+	lruList     *list.List
+	mutex       sync.RWMutex
+	logger      Logger
+	stats       CacheStats
+
+	// searchKeys indexes the cached search pages by user for invalidation
+	searchKeys map[string]map[string]struct{}
+
+	// fetches coalesces concurrent misses of the same contact into one repository call, writeSeq is bumped on every
+	// write so a fetch that raced with a write does not cache the value it read before the write
+	fetches  singleflight.Group
+	writeSeq uint64
 }
 
-func NewLRUCacheRepository(repo Repository, capacity int, logger Logger) *lruCache {
+// NewLRUCacheRepository caches up to capacity contacts, each for at most ttl. A zero ttl keeps entries until they
+// are evicted by capacity. Contacts that were not found are remembered for negativeTTL, zero disables it.
+func NewLRUCacheRepository(repo Repository, capacity int, ttl, negativeTTL time.Duration, logger Logger) *lruCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+
 	return &lruCache{
-		repo:     repo,
-		capacity: capacity,
-		cache:    make(map[string]*list.Element),
-		lruList:  list.New(),
-		logger:   logger,
+		repo:        repo,
+		capacity:    capacity,
+		ttl:         ttl,
+		negativeTTL: negativeTTL,
+		cache:       make(map[string]*list.Element),
+		lruList:     list.New(),
+		logger:      logger,
+		searchKeys:  make(map[string]map[string]struct{}),
 	}
 }
 
@@ -60,63 +110,169 @@ func (l *lruCache) CreateContact(ctx context.Context, c contact.Contact) error {
 
 	entryKey := getCacheKey(c.UserID, c.ID)
 	l.addCacheEntry(ctx, entryKey, c)
+	l.invalidateSearches(c.UserID)
+	l.writeSeq++
 
 	return nil
 }
 
 func (l *lruCache) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	entryKey := getCacheKey(userID, contactID)
+
+	// Check if the key exists in the cache, if so, return it
 	l.mutex.Lock()
-	defer l.mutex.Unlock()
+	value, ok := l.lookup(entryKey)
+	l.mutex.Unlock()
+
+	if !ok {
+		// If not found in the cache, fetch from the underlying repository without holding the lock
+		var err error
+		value, err = l.fetchContact(ctx, entryKey, userID, contactID)
+		if err != nil {
+			return contact.Contact{}, myerror.Wrap(err, "lruCache.GetContact")
+		}
+	}
 
-	entryKey := getCacheKey(userID, contactID)
+	switch v := value.(type) {
+	case contact.Contact:
+		return v, nil
+	case notFoundEntry:
+		return contact.Contact{}, myerror.NewNotFoundError("lruCache.GetContact: contact with ID %s not found for user %s", contactID, userID)
+	default:
+		return contact.Contact{}, myerror.NewInternalError("lruCache.GetContact: failed to cast to contact.Contact")
+	}
+}
 
-	// Check if the key exists in the cache, if so, move it to the front
-	if elem, ok := l.cache[entryKey]; ok {
-		l.lruList.MoveToFront(elem)
-		c, ok := elem.Value.(*CacheEntry).Value.(contact.Contact)
-		if !ok {
-			return contact.Contact{}, myerror.NewInternalError("lruCache.GetContact: failed to cast to contact.Contact")
+// fetchContact loads a contact from the repository, concurrent calls for the same key share a single call and its
+// result or error
+func (l *lruCache) fetchContact(ctx context.Context, entryKey, userID, contactID string) (any, error) {
+	value, err, shared := l.fetches.Do(entryKey, func() (interface{}, error) {
+		l.mutex.Lock()
+		seq := l.writeSeq
+		l.mutex.Unlock()
+
+		// the fetch is shared, so one caller giving up must not fail the others
+		c, err := l.repo.GetContact(context.WithoutCancel(ctx), userID, contactID)
+
+		l.mutex.Lock()
+		defer l.mutex.Unlock()
+
+		if err != nil {
+			if myerror.GetParsedError(err).Type == myerror.NotFoundError && l.negativeTTL > 0 && seq == l.writeSeq {
+				l.addCacheEntryWithTTL(ctx, entryKey, notFoundEntry{}, l.negativeTTL)
+			}
+			return nil, err
 		}
+
+		if seq == l.writeSeq {
+			l.addCacheEntry(ctx, entryKey, c)
+		}
+
 		return c, nil
+	})
+
+	if shared {
+		l.mutex.Lock()
+		l.stats.Coalesced++
+		l.mutex.Unlock()
 	}
 
-	// If not found in the cache, fetch from the underlying repository
-	c, err := l.repo.GetContact(ctx, userID, contactID)
 	if err != nil {
-		return contact.Contact{}, myerror.Wrap(err, "get")
+		return nil, myerror.Wrap(err, "fetchContact")
 	}
 
-	l.addCacheEntry(ctx, entryKey, c)
+	return value, nil
+}
+
+// lookup returns a live entry and moves it to the front, expired entries are removed and reported as a miss
+func (l *lruCache) lookup(key string) (any, bool) {
+	elem, ok := l.cache[key]
+	if !ok {
+		l.stats.Misses++
+		return nil, false
+	}
+
+	entry := elem.Value.(*CacheEntry)
+	if !entry.ExpiresAt.IsZero() && time.Now().After(entry.ExpiresAt) {
+		l.removeElement(elem)
+		l.stats.Expirations++
+		l.stats.Misses++
+		return nil, false
+	}
 
-	return c, nil
+	l.lruList.MoveToFront(elem)
+	l.stats.Hits++
+
+	return entry.Value, true
 }
 
 func (l *lruCache) addCacheEntry(ctx context.Context, key string, value any) {
+	l.addCacheEntryWithTTL(ctx, key, value, l.ttl)
+}
+
+func (l *lruCache) addCacheEntryWithTTL(ctx context.Context, key string, value any, ttl time.Duration) {
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = time.Now().Add(ttl)
+	}
+
 	// If the key already exists, update its value and move it to the front
 	if elem, ok := l.cache[key]; ok {
-		elem.Value.(*CacheEntry).Value = value
+		entry := elem.Value.(*CacheEntry)
+		entry.Value = value
+		entry.ExpiresAt = expiresAt
 		l.lruList.MoveToFront(elem)
-		l.logger.Info(ctx, "lruCache.addCacheEntry: updated cache entry", "key", key, "value", value)
+		l.logger.Debug(ctx, "lruCache.addCacheEntry: updated cache entry", "key", logKey(key))
 		return
 	}
 
 	// If the cache is full, remove the least recently used element
-	if l.lruList.Len() == l.capacity {
+	if l.lruList.Len() >= l.capacity {
 		l.logger.Info(ctx, "lruCache.addCacheEntry: cache is full, removing least recently used element")
-		elem := l.lruList.Back()
-		delete(l.cache, elem.Value.(*CacheEntry).Key)
-		l.lruList.Remove(elem)
+		l.removeElement(l.lruList.Back())
+		l.stats.Evictions++
 	}
 
-	l.logger.Info(ctx, "lruCache.addCacheEntry: adding new cache entry", "key", key, "value", value)
+	l.logger.Debug(ctx, "lruCache.addCacheEntry: adding new cache entry", "key", logKey(key))
 
 	// Add the new entry to the front of the list
 	elem := l.lruList.PushFront(&CacheEntry{
-		Key:   key,
-		Value: value,
+		Key:       key,
+		Value:     value,
+		ExpiresAt: expiresAt,
 	})
 
 	l.cache[key] = elem
+
+	if page, ok := value.(searchPage); ok {
+		if l.searchKeys[page.UserID] == nil {
+			l.searchKeys[page.UserID] = make(map[string]struct{})
+		}
+		l.searchKeys[page.UserID][key] = struct{}{}
+	}
+}
+
+func (l *lruCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*CacheEntry)
+	delete(l.cache, entry.Key)
+	l.lruList.Remove(elem)
+
+	if page, ok := entry.Value.(searchPage); ok {
+		delete(l.searchKeys[page.UserID], entry.Key)
+		if len(l.searchKeys[page.UserID]) == 0 {
+			delete(l.searchKeys, page.UserID)
+		}
+	}
+}
+
+// invalidateSearches drops every cached search page of the user, since any change to one of the user's contacts
+// may move contacts between pages
+func (l *lruCache) invalidateSearches(userID string) {
+	for key := range l.searchKeys[userID] {
+		if elem, ok := l.cache[key]; ok {
+			l.removeElement(elem)
+		}
+	}
 }
 
 func (l *lruCache) DeleteContact(ctx context.Context, userID string, contactID string) error {
@@ -127,22 +283,48 @@ func (l *lruCache) DeleteContact(ctx context.Context, userID string, contactID s
 		return myerror.Wrap(err, "lruCache.DeleteContact")
 	}
 
-	contactKey := getCacheKey(userID, contactID)
-	if elem, ok := l.cache[contactKey]; ok {
-		delete(l.cache, contactKey)
-		l.lruList.Remove(elem)
+	if elem, ok := l.cache[getCacheKey(userID, contactID)]; ok {
+		l.removeElement(elem)
 	}
+	l.invalidateSearches(userID)
+	l.writeSeq++
 
 	return nil
 }
 
-// SearchContacts is not cached
+// SearchContacts queries the repository without holding the lock, like GetContact, and caches the page only if no
+// write happened meanwhile
 func (l *lruCache) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	entryKey := getSearchCacheKey(filters)
+
+	l.mutex.Lock()
+	value, ok := l.lookup(entryKey)
+	seq := l.writeSeq
+	l.mutex.Unlock()
+
+	if ok {
+		page, ok := value.(searchPage)
+		if !ok {
+			return nil, myerror.NewInternalError("lruCache.SearchContacts: failed to cast to searchPage")
+		}
+		return append([]contact.Contact(nil), page.Contacts...), nil
+	}
+
 	contacts, err := l.repo.SearchContacts(ctx, filters)
 	if err != nil {
 		return nil, myerror.Wrap(err, "lruCache.SearchContacts")
 	}
 
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if seq == l.writeSeq {
+		l.addCacheEntry(ctx, entryKey, searchPage{
+			UserID:   filters.UserID,
+			Contacts: append([]contact.Contact(nil), contacts...),
+		})
+	}
+
 	return contacts, nil
 }
 
@@ -156,13 +338,44 @@ func (l *lruCache) UpdateContact(ctx context.Context, c contact.Contact) error {
 
 	contactKey := getCacheKey(c.UserID, c.ID)
 	l.addCacheEntry(ctx, contactKey, c)
+	l.invalidateSearches(c.UserID)
+	l.writeSeq++
 
 	return nil
 }
 
+// Invalidate drops a cached contact without touching the underlying repository, e.g. after another replica changed it
+func (l *lruCache) Invalidate(_ context.Context, userID, contactID string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if elem, ok := l.cache[getCacheKey(userID, contactID)]; ok {
+		l.removeElement(elem)
+	}
+	l.invalidateSearches(userID)
+	l.writeSeq++
+}
+
+// Stats reports the cache counters and its current size
+func (l *lruCache) Stats() CacheStats {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	stats := l.stats
+	stats.Size = l.lruList.Len()
+	stats.Capacity = l.capacity
+
+	return stats
+}
+
+// HealthCheck always succeeds, the cache lives in the process memory
+func (l *lruCache) HealthCheck(context.Context) error {
+	return nil
+}
+
 // IsPhoneExistsForUser is not cached
-func (l *lruCache) IsPhoneExistsForUser(_ context.Context, userID, phone string) (bool, error) {
-	IsPhoneExistsForUser, err := l.repo.IsPhoneExistsForUser(context.Background(), userID, phone)
+func (l *lruCache) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	IsPhoneExistsForUser, err := l.repo.IsPhoneExistsForUser(ctx, userID, phone)
 	if err != nil {
 		return false, myerror.Wrap(err, "lruCache.IsPhoneExistsForUser")
 	}
@@ -173,3 +386,27 @@ func (l *lruCache) IsPhoneExistsForUser(_ context.Context, userID, phone string)
 func getCacheKey(userID, contactID string) string {
 	return fmt.Sprintf("%s:%s", userID, contactID)
 }
+
+// logKey hides the filters of a search key behind a hash, as they hold names, phones and addresses in clear. The
+// hash still tells log lines of the same search apart from others.
+func logKey(key string) string {
+	if !strings.HasPrefix(key, searchKeyPrefix) {
+		return key
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return searchKeyPrefix + hex.EncodeToString(sum[:8])
+}
+
+// getSearchCacheKey normalizes the filters into a key, quoting the values so no two different filters share one
+func getSearchCacheKey(filters contact.Filters) string {
+	return fmt.Sprintf(searchKeyPrefix+"%q:%q:%q:%q:%q:%d:%d",
+		filters.UserID,
+		filters.Phone,
+		filters.FirstName,
+		filters.LastName,
+		filters.Address,
+		filters.Limit,
+		filters.Offset,
+	)
+}
@@ -0,0 +1,272 @@
+package inmem
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"contact-service/contact"
+	"contact-service/stdout"
+)
+
+func Test_lruCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	for _, id := range []string{"a", "b", "c"} {
+		if err := repo.CreateContact(ctx, contact.Contact{UserID: "1", ID: id}); err != nil {
+			t.Fatalf("CreateContact() error = %v", err)
+		}
+	}
+
+	l := NewLRUCacheRepository(repo, 2, 50*time.Millisecond, 0, stdout.NewLogger())
+
+	// miss, hit, miss, miss with eviction of "a"
+	for _, id := range []string{"a", "a", "b", "c"} {
+		if _, err := l.GetContact(ctx, "1", id); err != nil {
+			t.Fatalf("GetContact() error = %v", err)
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	// "c" has expired
+	if _, err := l.GetContact(ctx, "1", "c"); err != nil {
+		t.Fatalf("GetContact() error = %v", err)
+	}
+
+	want := CacheStats{Hits: 1, Misses: 4, Evictions: 1, Expirations: 1, Size: 2, Capacity: 2}
+	if got := l.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+}
+
+func Test_lruCache_SearchInvalidation(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	l := NewLRUCacheRepository(repo, 10, time.Minute, 0, stdout.NewLogger())
+
+	filters := contact.Filters{UserID: "1", Limit: 10}
+	otherFilters := contact.Filters{UserID: "2", Limit: 10}
+
+	if err := l.CreateContact(ctx, contact.Contact{UserID: "1", ID: "a", FirstName: "Bob"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+
+	for _, f := range []contact.Filters{filters, otherFilters} {
+		if _, err := l.SearchContacts(ctx, f); err != nil {
+			t.Fatalf("SearchContacts() error = %v", err)
+		}
+	}
+
+	// bypass the cache, the cached page must still be served
+	if err := repo.CreateContact(ctx, contact.Contact{UserID: "1", ID: "b", FirstName: "Alice"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	if got, _ := l.SearchContacts(ctx, filters); len(got) != 1 {
+		t.Fatalf("SearchContacts() = %d contacts, want the cached page with 1", len(got))
+	}
+
+	// a change through the cache drops every page of the user but not of other users
+	if err := l.DeleteContact(ctx, "1", "a"); err != nil {
+		t.Fatalf("DeleteContact() error = %v", err)
+	}
+	got, _ := l.SearchContacts(ctx, filters)
+	if len(got) != 1 || got[0].ID != "b" {
+		t.Errorf("SearchContacts() after delete = %+v, want only contact b", got)
+	}
+
+	if _, ok := l.cache[getSearchCacheKey(otherFilters)]; !ok {
+		t.Errorf("search page of another user was invalidated")
+	}
+}
+
+// countingRepository counts GetContact calls and holds them until release is closed
+type countingRepository struct {
+	Repository
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRepository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	r.calls.Add(1)
+	<-r.release
+	return r.Repository.GetContact(ctx, userID, contactID)
+}
+
+func Test_lruCache_CoalescedMisses(t *testing.T) {
+	tests := []struct {
+		name      string
+		contactID string
+		wantErr   bool
+	}{
+		{name: "existing contact", contactID: "a", wantErr: false},
+		{name: "missing contact", contactID: "missing", wantErr: true},
+	}
+
+	ctx := context.Background()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &countingRepository{Repository: NewUserRepository(), release: make(chan struct{})}
+			if err := repo.CreateContact(ctx, contact.Contact{UserID: "1", ID: "a"}); err != nil {
+				t.Fatalf("CreateContact() error = %v", err)
+			}
+
+			l := NewLRUCacheRepository(repo, 10, time.Minute, time.Minute, nopLogger{})
+
+			const callers = 10
+			var wg sync.WaitGroup
+			errs := make(chan error, callers)
+			for i := 0; i < callers; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := l.GetContact(ctx, "1", tt.contactID)
+					errs <- err
+				}()
+			}
+
+			// let every caller reach the in-flight fetch before the repository answers
+			for l.Stats().Misses < callers {
+				time.Sleep(time.Millisecond)
+			}
+			time.Sleep(10 * time.Millisecond)
+			close(repo.release)
+			wg.Wait()
+			close(errs)
+
+			for err := range errs {
+				if (err != nil) != tt.wantErr {
+					t.Errorf("GetContact() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+
+			// a later miss, positive or negative, is served from the cache
+			if _, err := l.GetContact(ctx, "1", tt.contactID); (err != nil) != tt.wantErr {
+				t.Errorf("GetContact() cached error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if calls := repo.calls.Load(); calls != 1 {
+				t.Errorf("repository called %d times, want 1", calls)
+			}
+		})
+	}
+}
+
+// blockingSearchRepository signals on entered once a SearchContacts call has read the contacts, and holds its result
+// until release is closed
+type blockingSearchRepository struct {
+	Repository
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingSearchRepository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	contacts, err := r.Repository.SearchContacts(ctx, filters)
+	r.entered <- struct{}{}
+	<-r.release
+	return contacts, err
+}
+
+func Test_lruCache_SearchRacingWrite(t *testing.T) {
+	ctx := context.Background()
+	repo := &blockingSearchRepository{Repository: NewUserRepository(), entered: make(chan struct{}, 2), release: make(chan struct{})}
+	l := NewLRUCacheRepository(repo, 10, time.Minute, 0, nopLogger{})
+	filters := contact.Filters{UserID: "1", Limit: 10}
+
+	if err := l.CreateContact(ctx, contact.Contact{UserID: "1", ID: "a", FirstName: "Bob"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+
+	searched := make(chan []contact.Contact)
+	go func() {
+		got, _ := l.SearchContacts(ctx, filters)
+		searched <- got
+	}()
+	<-repo.entered
+
+	// the write must not wait for the search in flight
+	created := make(chan error)
+	go func() { created <- l.CreateContact(ctx, contact.Contact{UserID: "1", ID: "b", FirstName: "Alice"}) }()
+	select {
+	case err := <-created:
+		if err != nil {
+			t.Fatalf("CreateContact() error = %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("CreateContact() blocked by the search in flight")
+	}
+
+	close(repo.release)
+	if got := <-searched; len(got) != 1 {
+		t.Fatalf("SearchContacts() in flight = %d contacts, want the 1 read before the write", len(got))
+	}
+
+	// the page read before the write was not cached
+	if got, _ := l.SearchContacts(ctx, filters); len(got) != 2 {
+		t.Errorf("SearchContacts() after the write = %d contacts, want 2", len(got))
+	}
+}
+
+func Test_lruCache_SearchEntryLog(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepository()
+	c := contact.Contact{UserID: "1", ID: "a", FirstName: "Dana", LastName: "Levi", Phone: "0541234567", Address: "Herzl 1"}
+	if err := repo.CreateContact(ctx, c); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	logger := stdout.NewRedactingLogger(stdout.NewJSONLogger(&out, stdout.LevelDebug, 1), stdout.AllFields)
+	l := NewLRUCacheRepository(repo, 10, time.Minute, 0, logger)
+
+	filters := contact.Filters{UserID: "1", FirstName: "Dana", LastName: "Levi", Phone: "0541234567", Address: "Herzl 1"}
+	for i := 0; i < 2; i++ {
+		if _, err := l.SearchContacts(ctx, filters); err != nil {
+			t.Fatalf("SearchContacts() error = %v", err)
+		}
+	}
+	l.mutex.Lock()
+	l.addCacheEntry(ctx, getSearchCacheKey(filters), searchPage{UserID: "1", Contacts: []contact.Contact{c}})
+	l.mutex.Unlock()
+
+	if !strings.Contains(out.String(), searchKeyPrefix) {
+		t.Errorf("log %s has no search entry", out.String())
+	}
+	for _, absent := range []string{"Dana", "Levi", "0541234567", "Herzl"} {
+		if strings.Contains(out.String(), absent) {
+			t.Errorf("log %s contains %s", out.String(), absent)
+		}
+	}
+}
+
+// contextRepository records the context of the IsPhoneExistsForUser calls
+type contextRepository struct {
+	Repository
+	ctx context.Context
+}
+
+func (r *contextRepository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	r.ctx = ctx
+	return r.Repository.IsPhoneExistsForUser(ctx, userID, phone)
+}
+
+func Test_lruCache_IsPhoneExistsForUser(t *testing.T) {
+	repo := &contextRepository{Repository: NewUserRepository()}
+	l := NewLRUCacheRepository(repo, 10, time.Minute, 0, nopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := l.IsPhoneExistsForUser(ctx, "1", "123"); err != nil {
+		t.Fatalf("IsPhoneExistsForUser() error = %v", err)
+	}
+
+	// the repository sees the cancellation of the caller
+	if repo.ctx == nil || repo.ctx.Err() == nil {
+		t.Errorf("repository context error = %v, want the caller's cancellation", repo.ctx)
+	}
+}
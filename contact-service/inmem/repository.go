@@ -2,60 +2,187 @@ package inmem
 
 import (
 	"context"
-	"fmt"
 	"infrastructure/myerror"
 	"sort"
+	"strings"
 	"sync"
 
 	"contact-service/contact"
 )
 
+// nameEntry is a single position in a sorted name index
+type nameEntry struct {
+	name      string
+	contactID string
+}
+
+func (e nameEntry) less(other nameEntry) bool {
+	if e.name != other.name {
+		return e.name < other.name
+	}
+	return e.contactID < other.contactID
+}
+
+// nameIndex keeps the contact IDs of one user sorted by a name, ties are broken by contact ID
+type nameIndex []nameEntry
+
+func (idx nameIndex) search(e nameEntry) int {
+	return sort.Search(len(idx), func(i int) bool {
+		return !idx[i].less(e)
+	})
+}
+
+func (idx *nameIndex) insert(e nameEntry) {
+	i := idx.search(e)
+	*idx = append(*idx, nameEntry{})
+	copy((*idx)[i+1:], (*idx)[i:])
+	(*idx)[i] = e
+}
+
+func (idx *nameIndex) remove(e nameEntry) {
+	i := idx.search(e)
+	if i < len(*idx) && (*idx)[i] == e {
+		*idx = append((*idx)[:i], (*idx)[i+1:]...)
+	}
+}
+
+// equalRange returns the entries whose name equals the given name
+func (idx nameIndex) equalRange(name string) nameIndex {
+	from := sort.Search(len(idx), func(i int) bool {
+		return idx[i].name >= name
+	})
+	to := sort.Search(len(idx), func(i int) bool {
+		return idx[i].name > name
+	})
+
+	return idx[from:to]
+}
+
+// userContacts holds the contacts of a single user together with their secondary indexes
+type userContacts struct {
+	contacts    map[string]contact.Contact
+	byPhone     map[string]map[string]struct{}
+	byFirstName nameIndex
+	byLastName  nameIndex
+}
+
+func newUserContacts() *userContacts {
+	return &userContacts{
+		contacts: make(map[string]contact.Contact),
+		byPhone:  make(map[string]map[string]struct{}),
+	}
+}
+
+func (u *userContacts) add(c contact.Contact) {
+	if prev, ok := u.contacts[c.ID]; ok {
+		u.remove(prev)
+	}
+
+	u.contacts[c.ID] = c
+
+	if u.byPhone[c.Phone] == nil {
+		u.byPhone[c.Phone] = make(map[string]struct{})
+	}
+	u.byPhone[c.Phone][c.ID] = struct{}{}
+
+	u.byFirstName.insert(nameEntry{name: c.FirstName, contactID: c.ID})
+	u.byLastName.insert(nameEntry{name: c.LastName, contactID: c.ID})
+}
+
+func (u *userContacts) remove(c contact.Contact) {
+	delete(u.contacts, c.ID)
+
+	delete(u.byPhone[c.Phone], c.ID)
+	if len(u.byPhone[c.Phone]) == 0 {
+		delete(u.byPhone, c.Phone)
+	}
+
+	u.byFirstName.remove(nameEntry{name: c.FirstName, contactID: c.ID})
+	u.byLastName.remove(nameEntry{name: c.LastName, contactID: c.ID})
+}
+
+// repository indexes contacts per user, so every lookup is proportional to the data of one user. The user IDs are
+// also kept sorted, so the users whose ID starts with a prefix are a single range.
 type repository struct {
-	mu       sync.RWMutex
-	contacts map[string]contact.Contact
+	mu      sync.RWMutex
+	users   map[string]*userContacts
+	userIDs []string
 }
 
 func NewUserRepository() *repository {
 	return &repository{
-		contacts: make(map[string]contact.Contact),
+		users: make(map[string]*userContacts),
 	}
 }
 
 func (r *repository) GetContact(_ context.Context, userID string, contactID string) (contact.Contact, error) {
-	contactKey := getContactKey(userID, contactID)
-	if c, ok := r.contacts[contactKey]; ok {
-		return c, nil
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if u, ok := r.users[userID]; ok {
+		if c, ok := u.contacts[contactID]; ok {
+			return c, nil
+		}
 	}
 
 	return contact.Contact{}, myerror.NewNotFoundError("inmem.GetContact: contact with ID %s not found for user %s", contactID, userID)
 }
 
+// SearchContacts returns the contacts matching the filters ordered by first name. The most selective index
+// available for the filters picks the candidates, which are then checked against the remaining filters.
 func (r *repository) SearchContacts(_ context.Context, filters contact.Filters) ([]contact.Contact, error) {
-	var userContacts []contact.Contact
-	for _, c := range r.contacts {
-		if c.UserID == filters.UserID {
-			userContacts = append(userContacts, c)
-		}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	u, ok := r.users[filters.UserID]
+	if !ok {
+		return nil, nil
 	}
 
-	sort.Slice(userContacts, func(i, j int) bool {
-		return userContacts[i].FirstName < userContacts[j].FirstName
-	})
+	var candidates nameIndex
+	switch {
+	case filters.Phone != "":
+		for contactID := range u.byPhone[filters.Phone] {
+			c := u.contacts[contactID]
+			candidates = append(candidates, nameEntry{name: c.FirstName, contactID: c.ID})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].less(candidates[j])
+		})
+	case filters.FirstName != "":
+		candidates = u.byFirstName.equalRange(filters.FirstName)
+	case filters.LastName != "":
+		for _, e := range u.byLastName.equalRange(filters.LastName) {
+			candidates = append(candidates, nameEntry{name: u.contacts[e.contactID].FirstName, contactID: e.contactID})
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].less(candidates[j])
+		})
+	default:
+		candidates = u.byFirstName
+	}
 
 	var contacts []contact.Contact
-	for i := filters.Offset; i < len(userContacts); i++ {
+	skipped := 0
+	for _, e := range candidates {
 		if len(contacts) == filters.Limit {
 			break
 		}
 
-		if (filters.Phone != "" && userContacts[i].Phone != filters.Phone) ||
-			(filters.FirstName != "" && userContacts[i].FirstName != filters.FirstName) ||
-			(filters.LastName != "" && userContacts[i].LastName != filters.LastName) ||
-			(filters.Address != "" && userContacts[i].Address != filters.Address) {
+		c := u.contacts[e.contactID]
+		if (filters.Phone != "" && c.Phone != filters.Phone) ||
+			(filters.FirstName != "" && c.FirstName != filters.FirstName) ||
+			(filters.LastName != "" && c.LastName != filters.LastName) ||
+			(filters.Address != "" && c.Address != filters.Address) {
 			continue
 		}
 
-		contacts = append(contacts, userContacts[i])
+		if skipped < filters.Offset {
+			skipped++
+			continue
+		}
+
+		contacts = append(contacts, c)
 	}
 
 	return contacts, nil
@@ -65,8 +192,7 @@ func (r *repository) UpdateContact(_ context.Context, c contact.Contact) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	contactKey := getContactKey(c.UserID, c.ID)
-	r.contacts[contactKey] = c
+	r.userContacts(c.UserID).add(c)
 	return nil
 }
 
@@ -74,8 +200,20 @@ func (r *repository) DeleteContact(_ context.Context, userID string, contactID s
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	contactKey := getContactKey(userID, contactID)
-	delete(r.contacts, contactKey)
+	u, ok := r.users[userID]
+	if !ok {
+		return nil
+	}
+
+	if c, ok := u.contacts[contactID]; ok {
+		u.remove(c)
+	}
+	if len(u.contacts) == 0 {
+		delete(r.users, userID)
+		i := sort.SearchStrings(r.userIDs, userID)
+		r.userIDs = append(r.userIDs[:i], r.userIDs[i+1:]...)
+	}
+
 	return nil
 }
 
@@ -83,8 +221,7 @@ func (r *repository) CreateContact(_ context.Context, c contact.Contact) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 
-	contactKey := getContactKey(c.UserID, c.ID)
-	r.contacts[contactKey] = c
+	r.userContacts(c.UserID).add(c)
 	return nil
 }
 
@@ -92,15 +229,43 @@ func (r *repository) IsPhoneExistsForUser(_ context.Context, userID, phone strin
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
-	for _, c := range r.contacts {
-		if c.UserID == userID && c.Phone == phone {
-			return true, nil
-		}
+	u, ok := r.users[userID]
+	if !ok {
+		return false, nil
 	}
 
-	return false, nil
+	return len(u.byPhone[phone]) > 0, nil
 }
 
-func getContactKey(userID, contactID string) string {
-	return fmt.Sprintf("%s:%s", userID, contactID)
+// CountContacts counts the contacts of the users whose ID starts with the prefix
+func (r *repository) CountContacts(_ context.Context, userIDPrefix string) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	count := 0
+	for i := sort.SearchStrings(r.userIDs, userIDPrefix); i < len(r.userIDs) && strings.HasPrefix(r.userIDs[i], userIDPrefix); i++ {
+		count += len(r.users[r.userIDs[i]].contacts)
+	}
+
+	return count, nil
+}
+
+// HealthCheck always succeeds, the repository lives in the process memory
+func (r *repository) HealthCheck(context.Context) error {
+	return nil
+}
+
+// userContacts must be called while holding the write lock
+func (r *repository) userContacts(userID string) *userContacts {
+	u, ok := r.users[userID]
+	if !ok {
+		u = newUserContacts()
+		r.users[userID] = u
+		i := sort.SearchStrings(r.userIDs, userID)
+		r.userIDs = append(r.userIDs, "")
+		copy(r.userIDs[i+1:], r.userIDs[i:])
+		r.userIDs[i] = userID
+	}
+
+	return u
 }
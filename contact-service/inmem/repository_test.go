@@ -0,0 +1,204 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	"contact-service/contact"
+)
+
+func Test_repository_SearchContacts(t *testing.T) {
+	ctx := context.Background()
+	r := NewUserRepository()
+
+	for _, c := range []contact.Contact{
+		{UserID: "1", ID: "a", Phone: "111", FirstName: "Dan", LastName: "Cohen", Address: "Main St"},
+		{UserID: "1", ID: "b", Phone: "222", FirstName: "Ann", LastName: "Levi", Address: "Main St"},
+		{UserID: "1", ID: "c", Phone: "333", FirstName: "Ann", LastName: "Cohen", Address: "Side St"},
+		{UserID: "1", ID: "d", Phone: "444", FirstName: "Bob", LastName: "Cohen", Address: "Main St"},
+		{UserID: "2", ID: "e", Phone: "111", FirstName: "Ann", LastName: "Cohen", Address: "Main St"},
+	} {
+		if err := r.CreateContact(ctx, c); err != nil {
+			t.Fatalf("CreateContact() error = %v", err)
+		}
+	}
+
+	// moving a contact between names must update the sorted indexes
+	if err := r.UpdateContact(ctx, contact.Contact{UserID: "1", ID: "d", Phone: "555", FirstName: "Abe", LastName: "Cohen", Address: "Main St"}); err != nil {
+		t.Fatalf("UpdateContact() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		filters contact.Filters
+		want    []string
+	}{
+		{name: "all contacts ordered by first name", filters: contact.Filters{UserID: "1", Limit: 10}, want: []string{"d", "b", "c", "a"}},
+		{name: "paginated", filters: contact.Filters{UserID: "1", Limit: 2, Offset: 1}, want: []string{"b", "c"}},
+		{name: "by phone", filters: contact.Filters{UserID: "1", Phone: "111", Limit: 10}, want: []string{"a"}},
+		{name: "by old phone after update", filters: contact.Filters{UserID: "1", Phone: "444", Limit: 10}, want: nil},
+		{name: "by first name", filters: contact.Filters{UserID: "1", FirstName: "Ann", Limit: 10}, want: []string{"b", "c"}},
+		{name: "by last name and address", filters: contact.Filters{UserID: "1", LastName: "Cohen", Address: "Main St", Limit: 10}, want: []string{"d", "a"}},
+		{name: "offset applies after filtering", filters: contact.Filters{UserID: "1", LastName: "Cohen", Limit: 10, Offset: 2}, want: []string{"a"}},
+		{name: "unknown user", filters: contact.Filters{UserID: "3", Limit: 10}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.SearchContacts(ctx, tt.filters)
+			if err != nil {
+				t.Fatalf("SearchContacts() error = %v", err)
+			}
+
+			var gotIDs []string
+			for _, c := range got {
+				gotIDs = append(gotIDs, c.ID)
+			}
+			if fmt.Sprint(gotIDs) != fmt.Sprint(tt.want) {
+				t.Errorf("SearchContacts() = %v, want %v", gotIDs, tt.want)
+			}
+		})
+	}
+
+	if exists, _ := r.IsPhoneExistsForUser(ctx, "1", "444"); exists {
+		t.Errorf("IsPhoneExistsForUser() = true for a phone replaced by an update")
+	}
+}
+
+func Test_repository_CountContacts(t *testing.T) {
+	ctx := context.Background()
+	r := NewUserRepository()
+
+	for _, c := range []contact.Contact{
+		{UserID: "acme/1", ID: "a", Phone: "111"},
+		{UserID: "acme/1", ID: "b", Phone: "222"},
+		{UserID: "acme/2", ID: "c", Phone: "333"},
+		{UserID: "acme2/1", ID: "d", Phone: "444"},
+		{UserID: "other/1", ID: "e", Phone: "555"},
+	} {
+		if err := r.CreateContact(ctx, c); err != nil {
+			t.Fatalf("CreateContact() error = %v", err)
+		}
+	}
+	// updates replace a contact without counting it again, deleting the last contact of a user drops the user
+	if err := r.UpdateContact(ctx, contact.Contact{UserID: "acme/1", ID: "a", Phone: "666"}); err != nil {
+		t.Fatalf("UpdateContact() error = %v", err)
+	}
+	if err := r.DeleteContact(ctx, "acme/2", "c"); err != nil {
+		t.Fatalf("DeleteContact() error = %v", err)
+	}
+
+	tests := []struct {
+		prefix string
+		want   int
+	}{
+		{prefix: "acme/", want: 2},
+		{prefix: "acme", want: 3},
+		{prefix: "other/", want: 1},
+		{prefix: "", want: 4},
+		{prefix: "none/", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			if got, err := r.CountContacts(ctx, tt.prefix); err != nil || got != tt.want {
+				t.Errorf("CountContacts(%q) = %d, %v, want %d", tt.prefix, got, err, tt.want)
+			}
+		})
+	}
+}
+
+const (
+	benchRepoUsers           = 10_000
+	benchRepoContactsPerUser = 100
+)
+
+var (
+	benchRepoOnce sync.Once
+	benchRepo     *repository
+)
+
+// newBenchRepository builds a repository of 1M contacts across 10k users once for all benchmarks
+func newBenchRepository(b *testing.B) *repository {
+	b.Helper()
+
+	benchRepoOnce.Do(func() {
+		ctx := context.Background()
+		benchRepo = NewUserRepository()
+		for u := 0; u < benchRepoUsers; u++ {
+			userID := fmt.Sprint(u)
+			for c := 0; c < benchRepoContactsPerUser; c++ {
+				benchRepo.CreateContact(ctx, contact.Contact{
+					UserID:    userID,
+					ID:        fmt.Sprint(c),
+					Phone:     fmt.Sprintf("05%08d", c),
+					FirstName: fmt.Sprintf("first%d", c%20),
+					LastName:  fmt.Sprintf("last%d", c%30),
+					Address:   fmt.Sprintf("%d Main St", c),
+				})
+			}
+		}
+	})
+
+	b.ResetTimer()
+
+	return benchRepo
+}
+
+func BenchmarkRepository_SearchContacts(b *testing.B) {
+	benchmarks := []struct {
+		name    string
+		filters contact.Filters
+	}{
+		{name: "no filters", filters: contact.Filters{Limit: 10, Offset: 20}},
+		{name: "phone", filters: contact.Filters{Phone: "0500000042", Limit: 10}},
+		{name: "first name", filters: contact.Filters{FirstName: "first7", Limit: 10}},
+		{name: "last name", filters: contact.Filters{LastName: "last7", Limit: 10}},
+	}
+
+	ctx := context.Background()
+	r := newBenchRepository(b)
+
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				filters := bm.filters
+				filters.UserID = fmt.Sprint(i % benchRepoUsers)
+				if _, err := r.SearchContacts(ctx, filters); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkRepository_IsPhoneExistsForUser(b *testing.B) {
+	ctx := context.Background()
+	r := newBenchRepository(b)
+
+	for i := 0; i < b.N; i++ {
+		if _, err := r.IsPhoneExistsForUser(ctx, fmt.Sprint(i%benchRepoUsers), "0500000042"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRepository_UpdateContact(b *testing.B) {
+	ctx := context.Background()
+	r := newBenchRepository(b)
+
+	for i := 0; i < b.N; i++ {
+		c := contact.Contact{
+			UserID:    fmt.Sprint(i % benchRepoUsers),
+			ID:        fmt.Sprint(i % benchRepoContactsPerUser),
+			Phone:     fmt.Sprintf("05%08d", i%benchRepoContactsPerUser),
+			FirstName: fmt.Sprintf("first%d", i%20),
+			LastName:  fmt.Sprintf("last%d", i%30),
+		}
+		if err := r.UpdateContact(ctx, c); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package inmem
+
+import (
+	"context"
+	"hash/fnv"
+	"time"
+
+	"contact-service/contact"
+)
+
+// shardedLRUCache spreads the cache over independent LRU segments, each with its own lock. Contacts are hashed by
+// user ID, so every entry of a user, including its cached search pages, lives in a single segment and is
+// invalidated there.
+type shardedLRUCache struct {
+	shards []*lruCache
+}
+
+// NewShardedLRUCacheRepository splits capacity evenly between shardCount LRU segments over the same repository
+func NewShardedLRUCacheRepository(repo Repository, shardCount, capacity int, ttl, negativeTTL time.Duration, logger Logger) *shardedLRUCache {
+	if shardCount < 1 {
+		shardCount = 1
+	}
+
+	shardCapacity := (capacity + shardCount - 1) / shardCount
+
+	shards := make([]*lruCache, shardCount)
+	for i := range shards {
+		shards[i] = NewLRUCacheRepository(repo, shardCapacity, ttl, negativeTTL, logger)
+	}
+
+	return &shardedLRUCache{
+		shards: shards,
+	}
+}
+
+func (s *shardedLRUCache) CreateContact(ctx context.Context, c contact.Contact) error {
+	return s.shard(c.UserID).CreateContact(ctx, c)
+}
+
+func (s *shardedLRUCache) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	return s.shard(userID).GetContact(ctx, userID, contactID)
+}
+
+func (s *shardedLRUCache) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	return s.shard(userID).DeleteContact(ctx, userID, contactID)
+}
+
+func (s *shardedLRUCache) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	return s.shard(filters.UserID).SearchContacts(ctx, filters)
+}
+
+func (s *shardedLRUCache) UpdateContact(ctx context.Context, c contact.Contact) error {
+	return s.shard(c.UserID).UpdateContact(ctx, c)
+}
+
+func (s *shardedLRUCache) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	return s.shard(userID).IsPhoneExistsForUser(ctx, userID, phone)
+}
+
+func (s *shardedLRUCache) Invalidate(ctx context.Context, userID, contactID string) {
+	s.shard(userID).Invalidate(ctx, userID, contactID)
+}
+
+// Stats sums the statistics of all segments
+func (s *shardedLRUCache) Stats() CacheStats {
+	var stats CacheStats
+	for _, shard := range s.shards {
+		shardStats := shard.Stats()
+		stats.Hits += shardStats.Hits
+		stats.Misses += shardStats.Misses
+		stats.Evictions += shardStats.Evictions
+		stats.Expirations += shardStats.Expirations
+		stats.Coalesced += shardStats.Coalesced
+		stats.Size += shardStats.Size
+		stats.Capacity += shardStats.Capacity
+	}
+
+	return stats
+}
+
+// HealthCheck always succeeds, the cache lives in the process memory
+func (s *shardedLRUCache) HealthCheck(context.Context) error {
+	return nil
+}
+
+func (s *shardedLRUCache) shard(userID string) *lruCache {
+	h := fnv.New32a()
+	h.Write([]byte(userID))
+
+	return s.shards[h.Sum32()%uint32(len(s.shards))]
+}
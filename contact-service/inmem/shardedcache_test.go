@@ -0,0 +1,91 @@
+package inmem
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"contact-service/contact"
+)
+
+const (
+	benchUsers           = 1000
+	benchContactsPerUser = 10
+)
+
+type nopLogger struct{}
+
+func (nopLogger) Info(context.Context, string, ...interface{})   {}
+func (nopLogger) Error(context.Context, error, ...interface{})   {}
+func (nopLogger) Warning(context.Context, error, ...interface{}) {}
+func (nopLogger) Debug(context.Context, string, ...interface{})  {}
+
+// benchmarkGetContact measures parallel cache hits, run with e.g. -cpu 1,2,4,8 to compare scaling across cores
+func benchmarkGetContact(b *testing.B, cache Repository) {
+	ctx := context.Background()
+	for u := 0; u < benchUsers; u++ {
+		for c := 0; c < benchContactsPerUser; c++ {
+			if err := cache.CreateContact(ctx, contact.Contact{UserID: fmt.Sprint(u), ID: fmt.Sprint(c)}); err != nil {
+				b.Fatalf("CreateContact() error = %v", err)
+			}
+		}
+	}
+
+	userIDs := make([]string, benchUsers)
+	for u := range userIDs {
+		userIDs[u] = fmt.Sprint(u)
+	}
+	contactIDs := make([]string, benchContactsPerUser)
+	for c := range contactIDs {
+		contactIDs[c] = fmt.Sprint(c)
+	}
+
+	var seed atomic.Uint64
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		// every goroutine starts at a different user so they do not walk the shards in lockstep
+		i := seed.Add(7919)
+		for pb.Next() {
+			i++
+			if _, err := cache.GetContact(ctx, userIDs[i%benchUsers], contactIDs[i%benchContactsPerUser]); err != nil {
+				b.Error(err)
+				return
+			}
+		}
+	})
+}
+
+func BenchmarkLRUCache_GetContact(b *testing.B) {
+	cache := NewLRUCacheRepository(NewUserRepository(), benchUsers*benchContactsPerUser, time.Hour, 0, nopLogger{})
+	benchmarkGetContact(b, cache)
+}
+
+func BenchmarkShardedLRUCache_GetContact(b *testing.B) {
+	for _, shards := range []int{4, 16, 64} {
+		b.Run(fmt.Sprintf("shards=%d", shards), func(b *testing.B) {
+			cache := NewShardedLRUCacheRepository(NewUserRepository(), shards, benchUsers*benchContactsPerUser*2, time.Hour, 0, nopLogger{})
+			benchmarkGetContact(b, cache)
+		})
+	}
+}
+
+func Test_shardedLRUCache_Stats(t *testing.T) {
+	ctx := context.Background()
+	cache := NewShardedLRUCacheRepository(NewUserRepository(), 4, 10, time.Minute, 0, nopLogger{})
+
+	for u := 0; u < 8; u++ {
+		if err := cache.CreateContact(ctx, contact.Contact{UserID: fmt.Sprint(u), ID: "a"}); err != nil {
+			t.Fatalf("CreateContact() error = %v", err)
+		}
+		if _, err := cache.GetContact(ctx, fmt.Sprint(u), "a"); err != nil {
+			t.Fatalf("GetContact() error = %v", err)
+		}
+	}
+
+	stats := cache.Stats()
+	if stats.Hits != 8 || stats.Capacity != 12 {
+		t.Errorf("Stats() = %+v, want 8 hits over a capacity of 12", stats)
+	}
+}
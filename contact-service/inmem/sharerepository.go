@@ -0,0 +1,140 @@
+package inmem
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"sort"
+	"sync"
+
+	"contact-service/contact"
+)
+
+// shareRepository indexes the shares by owner and by grantee, a share being identified by its owner, contact and
+// grantee
+type shareRepository struct {
+	mu        sync.RWMutex
+	shares    map[string]contact.Share
+	byOwner   map[string]map[string]struct{}
+	byGrantee map[string]map[string]struct{}
+}
+
+func NewShareRepository() *shareRepository {
+	return &shareRepository{
+		shares:    make(map[string]contact.Share),
+		byOwner:   make(map[string]map[string]struct{}),
+		byGrantee: make(map[string]map[string]struct{}),
+	}
+}
+
+// SaveShare creates the share, or replaces the permission of the existing share of the same contact and grantee
+func (r *shareRepository) SaveShare(_ context.Context, s contact.Share) (contact.Share, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := range r.byOwner[s.OwnerID] {
+		existing := r.shares[id]
+		if existing.ContactID == s.ContactID && existing.GranteeID == s.GranteeID {
+			existing.Permission = s.Permission
+			r.shares[id] = existing
+			return existing, nil
+		}
+	}
+
+	r.shares[s.ID] = s
+	addToSet(r.byOwner, s.OwnerID, s.ID)
+	addToSet(r.byGrantee, s.GranteeID, s.ID)
+
+	return s, nil
+}
+
+func (r *shareRepository) DeleteShare(_ context.Context, ownerID, shareID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, ok := r.shares[shareID]
+	if !ok || s.OwnerID != ownerID {
+		return myerror.NewNotFoundError("inmem.DeleteShare: share with ID %s not found for user %s", shareID, ownerID)
+	}
+
+	delete(r.shares, shareID)
+	removeFromSet(r.byOwner, s.OwnerID, shareID)
+	removeFromSet(r.byGrantee, s.GranteeID, shareID)
+
+	return nil
+}
+
+// DeleteSharesOfContact drops the shares of a single contact, e.g. once it is deleted
+func (r *shareRepository) DeleteSharesOfContact(_ context.Context, ownerID, contactID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for id := range r.byOwner[ownerID] {
+		s := r.shares[id]
+		if s.ContactID == contactID {
+			delete(r.shares, id)
+			removeFromSet(r.byOwner, s.OwnerID, id)
+			removeFromSet(r.byGrantee, s.GranteeID, id)
+		}
+	}
+
+	return nil
+}
+
+func (r *shareRepository) ListSharesByOwner(_ context.Context, ownerID string) ([]contact.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list(r.byOwner[ownerID]), nil
+}
+
+func (r *shareRepository) ListSharesByGrantee(_ context.Context, granteeID string) ([]contact.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return r.list(r.byGrantee[granteeID]), nil
+}
+
+// FindShares returns the shares granted by the owner to the grantee
+func (r *shareRepository) FindShares(_ context.Context, ownerID, granteeID string) ([]contact.Share, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var shares []contact.Share
+	for id := range r.byGrantee[granteeID] {
+		if s := r.shares[id]; s.OwnerID == ownerID {
+			shares = append(shares, s)
+		}
+	}
+
+	return shares, nil
+}
+
+// list must be called while holding the lock, shares are returned oldest first
+func (r *shareRepository) list(ids map[string]struct{}) []contact.Share {
+	shares := make([]contact.Share, 0, len(ids))
+	for id := range ids {
+		shares = append(shares, r.shares[id])
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if !shares[i].CreatedAt.Equal(shares[j].CreatedAt) {
+			return shares[i].CreatedAt.Before(shares[j].CreatedAt)
+		}
+		return shares[i].ID < shares[j].ID
+	})
+
+	return shares
+}
+
+func addToSet(sets map[string]map[string]struct{}, key, value string) {
+	if sets[key] == nil {
+		sets[key] = make(map[string]struct{})
+	}
+	sets[key][value] = struct{}{}
+}
+
+func removeFromSet(sets map[string]map[string]struct{}, key, value string) {
+	delete(sets[key], value)
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
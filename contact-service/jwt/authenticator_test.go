@@ -0,0 +1,186 @@
+package jwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"infrastructure/mycontext"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	gojwt "github.com/golang-jwt/jwt/v5"
+)
+
+const testSecret = "test-secret"
+
+func writeJWKS(t *testing.T, kid string, key *rsa.PublicKey) string {
+	t.Helper()
+
+	set := jsonWebKeySet{Keys: []jsonWebKey{{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}}}
+	data, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+
+	return path
+}
+
+type tenantClaims struct {
+	gojwt.RegisteredClaims
+	Tenant interface{} `json:"tenant"`
+}
+
+func sign(t *testing.T, method gojwt.SigningMethod, key interface{}, kid string, claims gojwt.Claims) string {
+	t.Helper()
+
+	token := gojwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString() error = %v", err)
+	}
+
+	return signed
+}
+
+func TestAuthenticator_Authenticate(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+
+	jwksPath := writeJWKS(t, "key-1", &rsaKey.PublicKey)
+	a, err := NewAuthenticator(testSecret, jwksPath, "issuer", "contact-service", "")
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	tenantAuthenticator, err := NewAuthenticator(testSecret, jwksPath, "issuer", "contact-service", "tenant")
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+
+	valid := gojwt.RegisteredClaims{
+		Subject:   "user-1",
+		Issuer:    "issuer",
+		Audience:  gojwt.ClaimStrings{"contact-service"},
+		ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+	}
+	expired := valid
+	expired.ExpiresAt = gojwt.NewNumericDate(time.Now().Add(-time.Hour))
+	wrongAudience := valid
+	wrongAudience.Audience = gojwt.ClaimStrings{"other-service"}
+	noSubject := valid
+	noSubject.Subject = ""
+
+	tests := []struct {
+		name         string
+		token        string
+		tenancy      bool
+		wantTenantID string
+		wantErr      bool
+	}{
+		{name: "HS256", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", valid)},
+		{name: "RS256 with kid", token: sign(t, gojwt.SigningMethodRS256, rsaKey, "key-1", valid)},
+		{name: "RS256 without kid", token: sign(t, gojwt.SigningMethodRS256, rsaKey, "", valid)},
+		{name: "RS256 unknown key", token: sign(t, gojwt.SigningMethodRS256, otherKey, "key-1", valid), wantErr: true},
+		{name: "HS256 wrong secret", token: sign(t, gojwt.SigningMethodHS256, []byte("other"), "", valid), wantErr: true},
+		{name: "HS512 not accepted", token: sign(t, gojwt.SigningMethodHS512, []byte(testSecret), "", valid), wantErr: true},
+		{name: "expired", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", expired), wantErr: true},
+		{name: "wrong audience", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", wrongAudience), wantErr: true},
+		{name: "no subject", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", noSubject), wantErr: true},
+		{name: "malformed", token: "not-a-token", wantErr: true},
+		{name: "tenant claim", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", tenantClaims{RegisteredClaims: valid, Tenant: "acme"}), tenancy: true, wantTenantID: "acme"},
+		{name: "tenant claim not a string", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", tenantClaims{RegisteredClaims: valid, Tenant: 42}), tenancy: true, wantErr: true},
+		{name: "tenant claim missing", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", valid), tenancy: true, wantErr: true},
+		{name: "tenant claim ignored without tenancy", token: sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", tenantClaims{RegisteredClaims: valid, Tenant: "acme"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authenticator := a
+			if tt.tenancy {
+				authenticator = tenantAuthenticator
+			}
+
+			identity, err := authenticator.Authenticate(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Authenticate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (identity.Subject != "user-1" || identity.TenantID != tt.wantTenantID) {
+				t.Errorf("Authenticate() = %+v, want user-1 of tenant %q", identity, tt.wantTenantID)
+			}
+		})
+	}
+}
+
+func TestNewHTTPMiddleware(t *testing.T) {
+	a, err := NewAuthenticator(testSecret, "", "", "", "")
+	if err != nil {
+		t.Fatalf("NewAuthenticator() error = %v", err)
+	}
+	token := sign(t, gojwt.SigningMethodHS256, []byte(testSecret), "", gojwt.RegisteredClaims{
+		Subject:   "user-1",
+		ExpiresAt: gojwt.NewNumericDate(time.Now().Add(time.Hour)),
+	})
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(NewHTTPMiddleware(a))
+	r.GET("/users/:userID/contacts", func(c *gin.Context) {
+		c.String(http.StatusOK, mycontext.Subject(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "own contacts", path: "/users/user-1/contacts", authorization: "Bearer " + token, wantStatus: http.StatusOK},
+		{name: "missing token", path: "/users/user-1/contacts", wantStatus: http.StatusUnauthorized},
+		{name: "invalid token", path: "/users/user-1/contacts", authorization: "Bearer nope", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && rec.Body.String() != "user-1" {
+				t.Errorf("subject in context = %q, want user-1", rec.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package jwt
+
+import (
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"infrastructure/myhttp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const bearerPrefix = "Bearer "
+
+type Authenticator interface {
+	Authenticate(token string) (Identity, error)
+}
+
+// NewHTTPMiddleware requires a valid bearer token and stores its subject in the request context as the caller, with
+// its tenant when tenancy is enabled. The routes decide which users' data the caller may reach.
+func NewHTTPMiddleware(authenticator Authenticator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if !strings.HasPrefix(header, bearerPrefix) {
+			myhttp.EncodeJSONError(c, myerror.NewUnauthorizedError("jwt: missing bearer token"))
+			c.Abort()
+			return
+		}
+
+		identity, err := authenticator.Authenticate(strings.TrimSpace(strings.TrimPrefix(header, bearerPrefix)))
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			c.Abort()
+			return
+		}
+
+		ctx := mycontext.WithSubject(c.Request.Context(), identity.Subject)
+		if identity.TenantID != "" {
+			ctx = mycontext.WithTenantID(ctx, identity.TenantID)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,123 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"infrastructure/myerror"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"contact-service/apikey"
+)
+
+// keyDocument is the stored form of an API key, a zero expiry or revocation time is kept as 0
+type keyDocument struct {
+	ID        string   `bson:"_id"`
+	Name      string   `bson:"name"`
+	Prefix    string   `bson:"prefix"`
+	Hash      string   `bson:"hash"`
+	UserID    string   `bson:"userID"`
+	TenantID  string   `bson:"tenantID"`
+	Scopes    []string `bson:"scopes"`
+	CreatedAt int64    `bson:"createdAt"`
+	ExpiresAt int64    `bson:"expiresAt"`
+	RevokedAt int64    `bson:"revokedAt"`
+}
+
+func toKeyDocument(k apikey.APIKey) keyDocument {
+	return keyDocument{
+		ID:        k.ID,
+		Name:      k.Name,
+		Prefix:    k.Prefix,
+		Hash:      k.Hash,
+		UserID:    k.UserID,
+		TenantID:  k.TenantID,
+		Scopes:    k.Scopes,
+		CreatedAt: k.CreatedAt.UnixNano(),
+		ExpiresAt: toUnixNano(k.ExpiresAt),
+		RevokedAt: toUnixNano(k.RevokedAt),
+	}
+}
+
+func (d keyDocument) toKey() apikey.APIKey {
+	return apikey.APIKey{
+		ID:        d.ID,
+		Name:      d.Name,
+		Prefix:    d.Prefix,
+		Hash:      d.Hash,
+		UserID:    d.UserID,
+		TenantID:  d.TenantID,
+		Scopes:    d.Scopes,
+		CreatedAt: time.Unix(0, d.CreatedAt),
+		ExpiresAt: fromUnixNano(d.ExpiresAt),
+		RevokedAt: fromUnixNano(d.RevokedAt),
+	}
+}
+
+func (r *repository) CreateKey(ctx context.Context, k apikey.APIKey) error {
+	if _, err := r.keys.InsertOne(ctx, toKeyDocument(k)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return myerror.NewBadRequestError("mongo.CreateKey: key already exists")
+		}
+		return myerror.Wrap(err, "mongo.CreateKey")
+	}
+
+	return nil
+}
+
+func (r *repository) GetKeyByHash(ctx context.Context, hash string) (apikey.APIKey, error) {
+	var doc keyDocument
+	err := r.keys.FindOne(ctx, bson.M{"hash": hash}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return apikey.APIKey{}, myerror.NewNotFoundError("mongo.GetKeyByHash: key not found")
+	}
+	if err != nil {
+		return apikey.APIKey{}, myerror.Wrap(err, "mongo.GetKeyByHash")
+	}
+
+	return doc.toKey(), nil
+}
+
+// ListKeys returns every key, revoked and expired ones included, oldest first
+func (r *repository) ListKeys(ctx context.Context) ([]apikey.APIKey, error) {
+	cursor, err := r.keys.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListKeys")
+	}
+	defer cursor.Close(ctx)
+
+	var docs []keyDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListKeys")
+	}
+
+	keys := make([]apikey.APIKey, 0, len(docs))
+	for _, doc := range docs {
+		keys = append(keys, doc.toKey())
+	}
+
+	return keys, nil
+}
+
+// RevokeKey keeps the first revocation time of a key revoked twice
+func (r *repository) RevokeKey(ctx context.Context, keyID string, revokedAt time.Time) error {
+	if _, err := r.keys.UpdateOne(ctx,
+		bson.M{"_id": keyID, "revokedAt": 0},
+		bson.M{"$set": bson.M{"revokedAt": revokedAt.UnixNano()}},
+	); err != nil {
+		return myerror.Wrap(err, "mongo.RevokeKey")
+	}
+
+	count, err := r.keys.CountDocuments(ctx, bson.M{"_id": keyID}, options.Count().SetLimit(1))
+	if err != nil {
+		return myerror.Wrap(err, "mongo.RevokeKey")
+	}
+	if count == 0 {
+		return myerror.NewNotFoundError("mongo.RevokeKey: key with ID %s not found", keyID)
+	}
+
+	return nil
+}
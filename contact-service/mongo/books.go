@@ -0,0 +1,169 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"infrastructure/myerror"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"contact-service/contact"
+)
+
+// bookDocument is the stored form of a book, which embeds its members
+type bookDocument struct {
+	ID        string           `bson:"_id"`
+	Name      string           `bson:"name"`
+	OwnerID   string           `bson:"ownerID"`
+	Members   []memberDocument `bson:"members"`
+	CreatedAt int64            `bson:"createdAt"`
+}
+
+// memberDocument keeps the join time of a pending invite as 0
+type memberDocument struct {
+	UserID    string `bson:"userID"`
+	Role      string `bson:"role"`
+	InvitedAt int64  `bson:"invitedAt"`
+	JoinedAt  int64  `bson:"joinedAt"`
+}
+
+func toBookDocument(b contact.Book) bookDocument {
+	members := make([]memberDocument, 0, len(b.Members))
+	for _, m := range b.Members {
+		members = append(members, toMemberDocument(m))
+	}
+
+	return bookDocument{
+		ID:        b.ID,
+		Name:      b.Name,
+		OwnerID:   b.OwnerID,
+		Members:   members,
+		CreatedAt: b.CreatedAt.UnixNano(),
+	}
+}
+
+func toMemberDocument(m contact.Member) memberDocument {
+	return memberDocument{
+		UserID:    m.UserID,
+		Role:      m.Role,
+		InvitedAt: toUnixNano(m.InvitedAt),
+		JoinedAt:  toUnixNano(m.JoinedAt),
+	}
+}
+
+func (d bookDocument) toBook() contact.Book {
+	var members []contact.Member
+	for _, m := range d.Members {
+		members = append(members, contact.Member{
+			UserID:    m.UserID,
+			Role:      m.Role,
+			InvitedAt: fromUnixNano(m.InvitedAt),
+			JoinedAt:  fromUnixNano(m.JoinedAt),
+		})
+	}
+
+	return contact.Book{
+		ID:        d.ID,
+		Name:      d.Name,
+		OwnerID:   d.OwnerID,
+		Members:   members,
+		CreatedAt: time.Unix(0, d.CreatedAt),
+	}
+}
+
+func (r *repository) CreateBook(ctx context.Context, b contact.Book) error {
+	if _, err := r.books.InsertOne(ctx, toBookDocument(b)); err != nil {
+		return myerror.Wrap(err, "mongo.CreateBook")
+	}
+
+	return nil
+}
+
+func (r *repository) GetBook(ctx context.Context, bookID string) (contact.Book, error) {
+	var doc bookDocument
+	err := r.books.FindOne(ctx, bson.M{"_id": bookID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return contact.Book{}, myerror.NewNotFoundError("mongo.GetBook: book with ID %s not found", bookID)
+	}
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "mongo.GetBook")
+	}
+
+	return doc.toBook(), nil
+}
+
+// ListBooksByMember returns the books the user owns, belongs to or is invited to, oldest first
+func (r *repository) ListBooksByMember(ctx context.Context, userID string) ([]contact.Book, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := r.books.Find(ctx, bson.M{"members.userID": userID}, opts)
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListBooksByMember")
+	}
+	defer cursor.Close(ctx)
+
+	var docs []bookDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListBooksByMember")
+	}
+
+	books := make([]contact.Book, 0, len(docs))
+	for _, doc := range docs {
+		books = append(books, doc.toBook())
+	}
+
+	return books, nil
+}
+
+// SaveMember adds the member to the book, or replaces the membership of the same user. A single pipeline update
+// drops the previous membership and appends the new one, so concurrent saves never leave the user twice.
+func (r *repository) SaveMember(ctx context.Context, bookID string, m contact.Member) error {
+	update := mongo.Pipeline{{{Key: "$set", Value: bson.M{"members": bson.M{"$concatArrays": bson.A{
+		bson.M{"$filter": bson.M{"input": "$members", "cond": bson.M{"$ne": bson.A{"$$this.userID", m.UserID}}}},
+		bson.A{toMemberDocument(m)},
+	}}}}}}
+
+	res, err := r.books.UpdateOne(ctx, bson.M{"_id": bookID}, update)
+	if err != nil {
+		return myerror.Wrap(err, "mongo.SaveMember")
+	}
+	if res.MatchedCount == 0 {
+		return myerror.NewNotFoundError("mongo.SaveMember: book with ID %s not found", bookID)
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteMember(ctx context.Context, bookID, userID string) error {
+	res, err := r.books.UpdateOne(ctx,
+		bson.M{"_id": bookID, "members.userID": userID},
+		bson.M{"$pull": bson.M{"members": bson.M{"userID": userID}}},
+	)
+	if err != nil {
+		return myerror.Wrap(err, "mongo.DeleteMember")
+	}
+	if res.MatchedCount > 0 {
+		return nil
+	}
+
+	if _, err := r.GetBook(ctx, bookID); err != nil {
+		return myerror.Wrap(err, "mongo.DeleteMember")
+	}
+
+	return myerror.NewNotFoundError("mongo.DeleteMember: user %s is not a member of book %s", userID, bookID)
+}
+
+// DeleteBook drops the book and its memberships, its contacts are stored with the contacts of the users
+func (r *repository) DeleteBook(ctx context.Context, bookID string) error {
+	res, err := r.books.DeleteOne(ctx, bson.M{"_id": bookID})
+	if err != nil {
+		return myerror.Wrap(err, "mongo.DeleteBook")
+	}
+	if res.DeletedCount == 0 {
+		return myerror.NewNotFoundError("mongo.DeleteBook: book with ID %s not found", bookID)
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"infrastructure/myerror"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"contact-service/contact"
+)
+
+// receiptDocument is the stored form of an erasure receipt
+type receiptDocument struct {
+	ID             string `bson:"_id"`
+	UserID         string `bson:"userID"`
+	TenantID       string `bson:"tenantID"`
+	RequestedBy    string `bson:"requestedBy"`
+	ErasedAt       int64  `bson:"erasedAt"`
+	Contacts       int    `bson:"contacts"`
+	BookContacts   int    `bson:"bookContacts"`
+	SharesGranted  int    `bson:"sharesGranted"`
+	SharesReceived int    `bson:"sharesReceived"`
+	Memberships    int    `bson:"memberships"`
+	Books          int    `bson:"books"`
+	Digest         string `bson:"digest"`
+}
+
+func toReceiptDocument(r contact.ErasureReceipt) receiptDocument {
+	return receiptDocument{
+		ID:             r.ID,
+		UserID:         r.UserID,
+		TenantID:       r.TenantID,
+		RequestedBy:    r.RequestedBy,
+		ErasedAt:       r.ErasedAt.UnixNano(),
+		Contacts:       r.Contacts,
+		BookContacts:   r.BookContacts,
+		SharesGranted:  r.SharesGranted,
+		SharesReceived: r.SharesReceived,
+		Memberships:    r.Memberships,
+		Books:          r.Books,
+		Digest:         r.Digest,
+	}
+}
+
+func (d receiptDocument) toReceipt() contact.ErasureReceipt {
+	return contact.ErasureReceipt{
+		ID:             d.ID,
+		UserID:         d.UserID,
+		TenantID:       d.TenantID,
+		RequestedBy:    d.RequestedBy,
+		ErasedAt:       time.Unix(0, d.ErasedAt),
+		Contacts:       d.Contacts,
+		BookContacts:   d.BookContacts,
+		SharesGranted:  d.SharesGranted,
+		SharesReceived: d.SharesReceived,
+		Memberships:    d.Memberships,
+		Books:          d.Books,
+		Digest:         d.Digest,
+	}
+}
+
+func (r *repository) SaveReceipt(ctx context.Context, receipt contact.ErasureReceipt) error {
+	if _, err := r.receipts.InsertOne(ctx, toReceiptDocument(receipt)); err != nil {
+		return myerror.Wrap(err, "mongo.SaveReceipt")
+	}
+
+	return nil
+}
+
+func (r *repository) GetReceipt(ctx context.Context, receiptID string) (contact.ErasureReceipt, error) {
+	var doc receiptDocument
+	err := r.receipts.FindOne(ctx, bson.M{"_id": receiptID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return contact.ErasureReceipt{}, myerror.NewNotFoundError("mongo.GetReceipt: receipt with ID %s not found", receiptID)
+	}
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "mongo.GetReceipt")
+	}
+
+	return doc.toReceipt(), nil
+}
+
+// ListReceipts returns the receipts oldest first
+func (r *repository) ListReceipts(ctx context.Context) ([]contact.ErasureReceipt, error) {
+	cursor, err := r.receipts.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "erasedAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListReceipts")
+	}
+	defer cursor.Close(ctx)
+
+	var docs []receiptDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListReceipts")
+	}
+
+	receipts := make([]contact.ErasureReceipt, 0, len(docs))
+	for _, doc := range docs {
+		receipts = append(receipts, doc.toReceipt())
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,292 @@
+package mongo
+
+import (
+	"context"
+	"errors"
+	"infrastructure/myerror"
+	"regexp"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"contact-service/contact"
+)
+
+const (
+	contactsCollection = "contacts"
+	receiptsCollection = "erasureReceipts"
+	sharesCollection   = "shares"
+	booksCollection    = "books"
+	keysCollection     = "apiKeys"
+)
+
+// contactDocument is the stored form of a contact. Timestamps are kept as unix nanoseconds since BSON dates only
+// have millisecond precision, and UpdatedAt is compared exactly for optimistic concurrency.
+type contactDocument struct {
+	ID             string `bson:"_id"`
+	UserID         string `bson:"userID"`
+	Phone          string `bson:"phone"`
+	FirstName      string `bson:"firstName"`
+	LastName       string `bson:"lastName"`
+	Address        string `bson:"address"`
+	EncryptedPhone string `bson:"encryptedPhone,omitempty"`
+	CreatedAt      int64  `bson:"createdAt"`
+	UpdatedAt      int64  `bson:"updatedAt"`
+}
+
+func toDocument(c contact.Contact) contactDocument {
+	return contactDocument{
+		ID:             c.ID,
+		UserID:         c.UserID,
+		Phone:          c.Phone,
+		FirstName:      c.FirstName,
+		LastName:       c.LastName,
+		Address:        c.Address,
+		EncryptedPhone: c.EncryptedPhone,
+		CreatedAt:      c.CreatedAt.UnixNano(),
+		UpdatedAt:      c.UpdatedAt.UnixNano(),
+	}
+}
+
+func (d contactDocument) toContact() contact.Contact {
+	return contact.Contact{
+		UserID:         d.UserID,
+		ID:             d.ID,
+		Phone:          d.Phone,
+		FirstName:      d.FirstName,
+		LastName:       d.LastName,
+		Address:        d.Address,
+		EncryptedPhone: d.EncryptedPhone,
+		CreatedAt:      time.Unix(0, d.CreatedAt),
+		UpdatedAt:      time.Unix(0, d.UpdatedAt),
+	}
+}
+
+type repository struct {
+	client   *mongo.Client
+	contacts *mongo.Collection
+	receipts *mongo.Collection
+	shares   *mongo.Collection
+	books    *mongo.Collection
+	keys     *mongo.Collection
+}
+
+func NewRepository(ctx context.Context, uri, database string) (*repository, error) {
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.NewRepository")
+	}
+
+	if err := client.Ping(ctx, nil); err != nil {
+		client.Disconnect(ctx)
+		return nil, myerror.Wrap(err, "mongo.NewRepository")
+	}
+
+	r := &repository{
+		client:   client,
+		contacts: client.Database(database).Collection(contactsCollection),
+		receipts: client.Database(database).Collection(receiptsCollection),
+		shares:   client.Database(database).Collection(sharesCollection),
+		books:    client.Database(database).Collection(booksCollection),
+		keys:     client.Database(database).Collection(keysCollection),
+	}
+
+	if err := r.ensureIndexes(ctx); err != nil {
+		client.Disconnect(ctx)
+		return nil, myerror.Wrap(err, "mongo.NewRepository")
+	}
+
+	return r, nil
+}
+
+func (r *repository) ensureIndexes(ctx context.Context) error {
+	_, err := r.contacts.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// backs IsPhoneExistsForUser and guarantees a phone is stored at most once per user
+			Keys:    bson.D{{Key: "userID", Value: 1}, {Key: "phone", Value: 1}},
+			Options: options.Index().SetName("userID_phone_unique").SetUnique(true),
+		},
+		{
+			// backs SearchContacts, which always filters by user and sorts by first name
+			Keys:    bson.D{{Key: "userID", Value: 1}, {Key: "firstName", Value: 1}},
+			Options: options.Index().SetName("userID_firstName"),
+		},
+	})
+	if err != nil {
+		return myerror.Wrap(err, "ensureIndexes")
+	}
+
+	_, err = r.shares.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			// backs SaveShare, a contact is shared at most once with each grantee
+			Keys:    bson.D{{Key: "ownerID", Value: 1}, {Key: "contactID", Value: 1}, {Key: "granteeID", Value: 1}},
+			Options: options.Index().SetName("ownerID_contactID_granteeID_unique").SetUnique(true),
+		},
+		{
+			// backs ListSharesByGrantee and FindShares
+			Keys:    bson.D{{Key: "granteeID", Value: 1}, {Key: "ownerID", Value: 1}},
+			Options: options.Index().SetName("granteeID_ownerID"),
+		},
+	})
+	if err != nil {
+		return myerror.Wrap(err, "ensureIndexes")
+	}
+
+	_, err = r.books.Indexes().CreateOne(ctx, mongo.IndexModel{
+		// backs ListBooksByMember
+		Keys:    bson.D{{Key: "members.userID", Value: 1}},
+		Options: options.Index().SetName("members_userID"),
+	})
+	if err != nil {
+		return myerror.Wrap(err, "ensureIndexes")
+	}
+
+	_, err = r.keys.Indexes().CreateOne(ctx, mongo.IndexModel{
+		// backs GetKeyByHash, the lookup of every authenticated request
+		Keys:    bson.D{{Key: "hash", Value: 1}},
+		Options: options.Index().SetName("hash_unique").SetUnique(true),
+	})
+	if err != nil {
+		return myerror.Wrap(err, "ensureIndexes")
+	}
+
+	return nil
+}
+
+func (r *repository) CreateContact(ctx context.Context, c contact.Contact) error {
+	if _, err := r.contacts.InsertOne(ctx, toDocument(c)); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return myerror.NewBadRequestError("mongo.CreateContact: contact with phone %s already exists for user %s", c.Phone, c.UserID)
+		}
+		return myerror.Wrap(err, "mongo.CreateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	var doc contactDocument
+	err := r.contacts.FindOne(ctx, bson.M{"_id": contactID, "userID": userID}).Decode(&doc)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return contact.Contact{}, myerror.NewNotFoundError("mongo.GetContact: contact with ID %s not found for user %s", contactID, userID)
+	}
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "mongo.GetContact")
+	}
+
+	return doc.toContact(), nil
+}
+
+func (r *repository) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	if _, err := r.contacts.DeleteOne(ctx, bson.M{"_id": contactID, "userID": userID}); err != nil {
+		return myerror.Wrap(err, "mongo.DeleteContact")
+	}
+
+	return nil
+}
+
+func (r *repository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	query := bson.M{"userID": filters.UserID}
+	if filters.Phone != "" {
+		query["phone"] = filters.Phone
+	}
+	if filters.FirstName != "" {
+		query["firstName"] = filters.FirstName
+	}
+	if filters.LastName != "" {
+		query["lastName"] = filters.LastName
+	}
+	if filters.Address != "" {
+		query["address"] = filters.Address
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "firstName", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64(filters.Offset)).
+		SetLimit(int64(filters.Limit))
+
+	cursor, err := r.contacts.Find(ctx, query, opts)
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.SearchContacts")
+	}
+	defer cursor.Close(ctx)
+
+	var docs []contactDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, myerror.Wrap(err, "mongo.SearchContacts")
+	}
+
+	contacts := make([]contact.Contact, 0, len(docs))
+	for _, doc := range docs {
+		contacts = append(contacts, doc.toContact())
+	}
+
+	return contacts, nil
+}
+
+func (r *repository) UpdateContact(ctx context.Context, c contact.Contact) error {
+	_, err := r.contacts.ReplaceOne(ctx, bson.M{"_id": c.ID, "userID": c.UserID}, toDocument(c))
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return myerror.NewBadRequestError("mongo.UpdateContact: contact with phone %s already exists for user %s", c.Phone, c.UserID)
+		}
+		return myerror.Wrap(err, "mongo.UpdateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	count, err := r.contacts.CountDocuments(ctx, bson.M{"userID": userID, "phone": phone}, options.Count().SetLimit(1))
+	if err != nil {
+		return false, myerror.Wrap(err, "mongo.IsPhoneExistsForUser")
+	}
+
+	return count > 0, nil
+}
+
+// CountContacts counts the contacts of the users whose ID starts with the prefix, an anchored prefix uses the
+// userID index
+func (r *repository) CountContacts(ctx context.Context, userIDPrefix string) (int, error) {
+	count, err := r.contacts.CountDocuments(ctx, bson.M{"userID": bson.M{"$regex": "^" + regexp.QuoteMeta(userIDPrefix)}})
+	if err != nil {
+		return 0, myerror.Wrap(err, "mongo.CountContacts")
+	}
+
+	return int(count), nil
+}
+
+func (r *repository) HealthCheck(ctx context.Context) error {
+	if err := r.client.Ping(ctx, nil); err != nil {
+		return myerror.Wrap(err, "mongo.HealthCheck")
+	}
+
+	return nil
+}
+
+func (r *repository) Close(ctx context.Context) error {
+	if err := r.client.Disconnect(ctx); err != nil {
+		return myerror.Wrap(err, "mongo.Close")
+	}
+
+	return nil
+}
+
+// toUnixNano stores the zero time as 0, which time.Unix would not map back to the zero time
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n)
+}
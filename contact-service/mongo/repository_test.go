@@ -0,0 +1,276 @@
+package mongo
+
+import (
+	"contact-service/apikey"
+	"contact-service/contact"
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"infrastructure/myerror"
+)
+
+// The integration tests run against a real mongod, for example:
+//
+//	docker run --rm -p 27017:27017 mongo:7
+//	MONGO_URI=mongodb://localhost:27017 go test ./mongo/...
+func newTestRepository(t *testing.T) *repository {
+	t.Helper()
+
+	uri := os.Getenv("MONGO_URI")
+	if uri == "" {
+		t.Skip("MONGO_URI is not set, skipping mongo integration tests")
+	}
+
+	ctx := context.Background()
+	database := fmt.Sprintf("contacts_test_%d", time.Now().UnixNano())
+
+	r, err := NewRepository(ctx, uri, database)
+	if err != nil {
+		t.Fatalf("NewRepository() error = %v", err)
+	}
+
+	t.Cleanup(func() {
+		r.client.Database(database).Drop(ctx)
+		r.Close(ctx)
+	})
+
+	return r
+}
+
+func Test_repository_CRUD(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	now := time.Now()
+	c := contact.Contact{UserID: "1", ID: "a", Phone: "111", FirstName: "John", LastName: "Doe", Address: "Main St", CreatedAt: now, UpdatedAt: now}
+	if err := r.CreateContact(ctx, c); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+
+	got, err := r.GetContact(ctx, c.UserID, c.ID)
+	if err != nil {
+		t.Fatalf("GetContact() error = %v", err)
+	}
+	if !got.UpdatedAt.Equal(now) {
+		t.Errorf("GetContact() updatedAt = %v, want %v", got.UpdatedAt, now)
+	}
+
+	if _, err := r.GetContact(ctx, "2", c.ID); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("GetContact() for another user error = %v, want not found", err)
+	}
+
+	c.Address = "Second St"
+	if err := r.UpdateContact(ctx, c); err != nil {
+		t.Fatalf("UpdateContact() error = %v", err)
+	}
+
+	contacts, err := r.SearchContacts(ctx, contact.Filters{UserID: c.UserID, Address: "Second St", Limit: 10})
+	if err != nil || len(contacts) != 1 {
+		t.Errorf("SearchContacts() = %v, %v, want 1 contact", contacts, err)
+	}
+
+	if err := r.DeleteContact(ctx, c.UserID, c.ID); err != nil {
+		t.Fatalf("DeleteContact() error = %v", err)
+	}
+	if _, err := r.GetContact(ctx, c.UserID, c.ID); err == nil {
+		t.Errorf("GetContact() after delete expected an error")
+	}
+}
+
+func Test_repository_IsPhoneExistsForUser(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		userID string
+		phone  string
+		want   bool
+	}{
+		{name: "existing phone", userID: "1", phone: "111", want: true},
+		{name: "phone of another user", userID: "2", phone: "111", want: false},
+		{name: "unknown phone", userID: "1", phone: "999", want: false},
+	}
+
+	if err := r.CreateContact(ctx, contact.Contact{UserID: "1", ID: "a", Phone: "111"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+
+	if err := r.CreateContact(ctx, contact.Contact{UserID: "1", ID: "b", Phone: "111"}); myerror.GetParsedError(err).Type != myerror.BadRequestError {
+		t.Errorf("CreateContact() duplicate phone error = %v, want bad request", err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.IsPhoneExistsForUser(ctx, tt.userID, tt.phone)
+			if err != nil {
+				t.Fatalf("IsPhoneExistsForUser() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("IsPhoneExistsForUser() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_repository_Receipts(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	receipt := contact.ErasureReceipt{ID: "r1", UserID: "1", ErasedAt: time.Unix(0, 1), Contacts: 2, Digest: "digest"}
+	if err := r.SaveReceipt(ctx, receipt); err != nil {
+		t.Fatalf("SaveReceipt() error = %v", err)
+	}
+
+	got, err := r.GetReceipt(ctx, receipt.ID)
+	if err != nil {
+		t.Fatalf("GetReceipt() error = %v", err)
+	}
+	if got != receipt {
+		t.Errorf("GetReceipt() = %+v, want %+v", got, receipt)
+	}
+
+	if receipts, err := r.ListReceipts(ctx); err != nil || len(receipts) != 1 {
+		t.Errorf("ListReceipts() = %v, %v, want 1 receipt", receipts, err)
+	}
+
+	if _, err := r.GetReceipt(ctx, "missing"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("GetReceipt() of a missing receipt error = %v, want not found", err)
+	}
+}
+
+func Test_repository_Shares(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	contactShare := contact.Share{ID: "s1", OwnerID: "1", ContactID: "a", GranteeID: "2", Permission: contact.PermissionRead, CreatedAt: time.Unix(0, 1)}
+	bookShare := contact.Share{ID: "s2", OwnerID: "1", GranteeID: "2", Permission: contact.PermissionRead, CreatedAt: time.Unix(0, 2)}
+	for _, s := range []contact.Share{bookShare, contactShare} {
+		if _, err := r.SaveShare(ctx, s); err != nil {
+			t.Fatalf("SaveShare() error = %v", err)
+		}
+	}
+
+	// sharing the contact again only changes the permission of the existing share
+	got, err := r.SaveShare(ctx, contact.Share{ID: "s3", OwnerID: "1", ContactID: "a", GranteeID: "2", Permission: contact.PermissionEdit})
+	if err != nil || got.ID != contactShare.ID || got.Permission != contact.PermissionEdit {
+		t.Fatalf("SaveShare() of an existing share = %+v, %v, want %s with edit", got, err, contactShare.ID)
+	}
+
+	shares, err := r.ListSharesByOwner(ctx, "1")
+	if err != nil || len(shares) != 2 || shares[0].ID != contactShare.ID {
+		t.Errorf("ListSharesByOwner() = %+v, %v, want %s first", shares, err, contactShare.ID)
+	}
+
+	if err := r.DeleteSharesOfContact(ctx, "1", "a"); err != nil {
+		t.Fatalf("DeleteSharesOfContact() error = %v", err)
+	}
+	if err := r.DeleteShare(ctx, "2", bookShare.ID); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("DeleteShare() by another owner error = %v, want not found", err)
+	}
+	if shares, err := r.ListSharesByGrantee(ctx, "2"); err != nil || len(shares) != 1 || shares[0] != bookShare {
+		t.Errorf("ListSharesByGrantee() = %+v, %v, want only %+v", shares, err, bookShare)
+	}
+}
+
+func Test_repository_Books(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	joinedAt := time.Unix(0, 1).UTC()
+	owner := contact.Member{UserID: "1", Role: contact.RoleOwner, InvitedAt: joinedAt, JoinedAt: joinedAt}
+	invited := contact.Member{UserID: "2", Role: contact.RoleViewer, InvitedAt: joinedAt}
+	for _, b := range []contact.Book{
+		{ID: "b2", Name: "team", OwnerID: "3", CreatedAt: time.Unix(0, 2).UTC(), Members: []contact.Member{
+			{UserID: "3", Role: contact.RoleOwner, InvitedAt: joinedAt, JoinedAt: joinedAt},
+			{UserID: "1", Role: contact.RoleEditor, InvitedAt: joinedAt, JoinedAt: joinedAt},
+		}},
+		{ID: "b1", Name: "family", OwnerID: "1", CreatedAt: time.Unix(0, 1).UTC(), Members: []contact.Member{owner, invited}},
+	} {
+		if err := r.CreateBook(ctx, b); err != nil {
+			t.Fatalf("CreateBook() error = %v", err)
+		}
+	}
+
+	// accepting the invite replaces the membership
+	invited.JoinedAt = joinedAt
+	if err := r.SaveMember(ctx, "b1", invited); err != nil {
+		t.Fatalf("SaveMember() error = %v", err)
+	}
+
+	books, err := r.ListBooksByMember(ctx, "1")
+	if err != nil || len(books) != 2 || books[0].ID != "b1" {
+		t.Fatalf("ListBooksByMember() = %+v, %v, want b1 first", books, err)
+	}
+	if m, ok := books[0].Member("2"); len(books[0].Members) != 2 || !ok || !m.IsActive() {
+		t.Errorf("members of b1 = %+v, want the owner and the active viewer", books[0].Members)
+	}
+	if m, _ := books[1].Member("1"); m.Role != contact.RoleEditor || !m.IsActive() {
+		t.Errorf("membership in b2 = %+v, want an active editor", m)
+	}
+
+	if err := r.SaveMember(ctx, "missing", invited); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("SaveMember() to a missing book error = %v, want not found", err)
+	}
+	if err := r.DeleteMember(ctx, "b2", "1"); err != nil {
+		t.Fatalf("DeleteMember() error = %v", err)
+	}
+	if err := r.DeleteMember(ctx, "b2", "1"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("DeleteMember() of a removed member error = %v, want not found", err)
+	}
+	if err := r.DeleteBook(ctx, "b1"); err != nil {
+		t.Fatalf("DeleteBook() error = %v", err)
+	}
+	if _, err := r.GetBook(ctx, "b1"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("GetBook() of a deleted book error = %v, want not found", err)
+	}
+	if books, err := r.ListBooksByMember(ctx, "1"); err != nil || len(books) != 0 {
+		t.Errorf("ListBooksByMember() after the deletes = %+v, %v, want none", books, err)
+	}
+}
+
+func Test_repository_APIKeys(t *testing.T) {
+	r := newTestRepository(t)
+	ctx := context.Background()
+
+	first := apikey.APIKey{ID: "k1", Name: "first", Prefix: "pbk_a", Hash: "h1", UserID: "1", Scopes: []string{apikey.ScopeRead, apikey.ScopeWrite}, CreatedAt: time.Unix(0, 1).UTC()}
+	second := apikey.APIKey{ID: "k2", Name: "second", Prefix: "pbk_b", Hash: "h2", Scopes: []string{apikey.ScopeAdmin}, CreatedAt: time.Unix(0, 2).UTC(), ExpiresAt: time.Unix(0, 3).UTC()}
+	for _, k := range []apikey.APIKey{second, first} {
+		if err := r.CreateKey(ctx, k); err != nil {
+			t.Fatalf("CreateKey() error = %v", err)
+		}
+	}
+	if err := r.CreateKey(ctx, apikey.APIKey{ID: "k3", Hash: first.Hash}); myerror.GetParsedError(err).Type != myerror.BadRequestError {
+		t.Errorf("CreateKey() of a stored hash error = %v, want bad request", err)
+	}
+	if err := r.RevokeKey(ctx, first.ID, time.Unix(0, 4)); err != nil {
+		t.Fatalf("RevokeKey() error = %v", err)
+	}
+
+	got, err := r.GetKeyByHash(ctx, first.Hash)
+	if err != nil {
+		t.Fatalf("GetKeyByHash() error = %v", err)
+	}
+	if got.ID != first.ID || len(got.Scopes) != 2 || !got.ExpiresAt.IsZero() || got.RevokedAt.UnixNano() != 4 {
+		t.Errorf("GetKeyByHash() = %+v, want %s revoked at 4 with two scopes", got, first.ID)
+	}
+
+	// a second revocation keeps the first one
+	if err := r.RevokeKey(ctx, first.ID, time.Unix(0, 5)); err != nil {
+		t.Fatalf("RevokeKey() of a revoked key error = %v", err)
+	}
+	keys, err := r.ListKeys(ctx)
+	if err != nil || len(keys) != 2 || keys[0].ID != first.ID || keys[0].RevokedAt.UnixNano() != 4 || !keys[1].ExpiresAt.Equal(second.ExpiresAt) {
+		t.Errorf("ListKeys() = %+v, %v, want %s first, revoked at 4", keys, err, first.ID)
+	}
+
+	if err := r.RevokeKey(ctx, "missing", time.Unix(0, 4)); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("RevokeKey() of a missing key error = %v, want not found", err)
+	}
+	if _, err := r.GetKeyByHash(ctx, "missing"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("GetKeyByHash() of a missing hash error = %v, want not found", err)
+	}
+}
@@ -0,0 +1,120 @@
+package mongo
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"contact-service/contact"
+)
+
+// shareDocument is the stored form of a share
+type shareDocument struct {
+	ID         string `bson:"_id"`
+	OwnerID    string `bson:"ownerID"`
+	ContactID  string `bson:"contactID"`
+	GranteeID  string `bson:"granteeID"`
+	Permission string `bson:"permission"`
+	CreatedAt  int64  `bson:"createdAt"`
+}
+
+func (d shareDocument) toShare() contact.Share {
+	return contact.Share{
+		ID:         d.ID,
+		OwnerID:    d.OwnerID,
+		ContactID:  d.ContactID,
+		GranteeID:  d.GranteeID,
+		Permission: d.Permission,
+		CreatedAt:  time.Unix(0, d.CreatedAt),
+	}
+}
+
+// SaveShare creates the share, or replaces the permission of the existing share of the same contact and grantee
+func (r *repository) SaveShare(ctx context.Context, s contact.Share) (contact.Share, error) {
+	filter := bson.M{"ownerID": s.OwnerID, "contactID": s.ContactID, "granteeID": s.GranteeID}
+	update := bson.M{
+		"$set":         bson.M{"permission": s.Permission},
+		"$setOnInsert": bson.M{"_id": s.ID, "createdAt": s.CreatedAt.UnixNano()},
+	}
+
+	var doc shareDocument
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	if err := r.shares.FindOneAndUpdate(ctx, filter, update, opts).Decode(&doc); err != nil {
+		return contact.Share{}, myerror.Wrap(err, "mongo.SaveShare")
+	}
+
+	return doc.toShare(), nil
+}
+
+func (r *repository) DeleteShare(ctx context.Context, ownerID, shareID string) error {
+	res, err := r.shares.DeleteOne(ctx, bson.M{"_id": shareID, "ownerID": ownerID})
+	if err != nil {
+		return myerror.Wrap(err, "mongo.DeleteShare")
+	}
+	if res.DeletedCount == 0 {
+		return myerror.NewNotFoundError("mongo.DeleteShare: share with ID %s not found for user %s", shareID, ownerID)
+	}
+
+	return nil
+}
+
+// DeleteSharesOfContact drops the shares of a single contact, e.g. once it is deleted
+func (r *repository) DeleteSharesOfContact(ctx context.Context, ownerID, contactID string) error {
+	if _, err := r.shares.DeleteMany(ctx, bson.M{"ownerID": ownerID, "contactID": contactID}); err != nil {
+		return myerror.Wrap(err, "mongo.DeleteSharesOfContact")
+	}
+
+	return nil
+}
+
+func (r *repository) ListSharesByOwner(ctx context.Context, ownerID string) ([]contact.Share, error) {
+	shares, err := r.findShares(ctx, bson.M{"ownerID": ownerID})
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListSharesByOwner")
+	}
+
+	return shares, nil
+}
+
+func (r *repository) ListSharesByGrantee(ctx context.Context, granteeID string) ([]contact.Share, error) {
+	shares, err := r.findShares(ctx, bson.M{"granteeID": granteeID})
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.ListSharesByGrantee")
+	}
+
+	return shares, nil
+}
+
+// FindShares returns the shares granted by the owner to the grantee
+func (r *repository) FindShares(ctx context.Context, ownerID, granteeID string) ([]contact.Share, error) {
+	shares, err := r.findShares(ctx, bson.M{"granteeID": granteeID, "ownerID": ownerID})
+	if err != nil {
+		return nil, myerror.Wrap(err, "mongo.FindShares")
+	}
+
+	return shares, nil
+}
+
+// findShares returns the shares matching the filter oldest first
+func (r *repository) findShares(ctx context.Context, filter bson.M) ([]contact.Share, error) {
+	cursor, err := r.shares.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, myerror.Wrap(err, "findShares")
+	}
+	defer cursor.Close(ctx)
+
+	var docs []shareDocument
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, myerror.Wrap(err, "findShares")
+	}
+
+	shares := make([]contact.Share, 0, len(docs))
+	for _, doc := range docs {
+		shares = append(shares, doc.toShare())
+	}
+
+	return shares, nil
+}
@@ -0,0 +1,53 @@
+package opentelemetry
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+type LockCache interface {
+	Lock(context.Context, string) (bool, error)
+	Unlock(context.Context, string) error
+}
+
+// lockCache starts a span around every lock acquire and release. Only the kind of the lock is recorded, the keys of
+// the service locks hold the phone of the contact.
+type lockCache struct {
+	next   LockCache
+	tracer trace.Tracer
+}
+
+func NewLockCache(next LockCache, provider trace.TracerProvider) *lockCache {
+	return &lockCache{
+		next:   next,
+		tracer: provider.Tracer(instrumentationName),
+	}
+}
+
+func (l *lockCache) Lock(ctx context.Context, key string) (bool, error) {
+	ctx, span := l.tracer.Start(ctx, "lock.Lock", trace.WithAttributes(attribute.String("lock.kind", lockKind(key))))
+
+	ok, err := l.next.Lock(ctx, key)
+	span.SetAttributes(attribute.Bool("lock.acquired", ok))
+	endSpan(span, err)
+
+	return ok, err
+}
+
+func (l *lockCache) Unlock(ctx context.Context, key string) error {
+	ctx, span := l.tracer.Start(ctx, "lock.Unlock", trace.WithAttributes(attribute.String("lock.kind", lockKind(key))))
+
+	err := l.next.Unlock(ctx, key)
+	endSpan(span, err)
+
+	return err
+}
+
+// lockKind is the part of the key before its first ':', e.g. create or update
+func lockKind(key string) string {
+	kind, _, _ := strings.Cut(key, ":")
+	return kind
+}
@@ -0,0 +1,107 @@
+package opentelemetry
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"contact-service/contact"
+	"contact-service/inmem"
+)
+
+// stubRepository finds no contact
+type stubRepository struct {
+	Repository
+}
+
+func (stubRepository) GetContact(_ context.Context, userID string, contactID string) (contact.Contact, error) {
+	return contact.Contact{}, myerror.NewNotFoundError("contact %s not found for user %s", contactID, userID)
+}
+
+// repositoryService reads contacts straight from the repository
+type repositoryService struct {
+	Service
+	repo Repository
+}
+
+func (s repositoryService) GetContact(ctx context.Context, userID, contactID string) (contact.Contact, error) {
+	return s.repo.GetContact(ctx, userID, contactID)
+}
+
+func TestTracing_PropagatesTraceparent(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	s := NewService(repositoryService{repo: NewRepository(stubRepository{}, provider)}, provider)
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.ContextWithFallback = true
+	r.Use(NewHTTPMiddleware(provider))
+	r.GET("/users/:userID/contacts/:contactID", func(c *gin.Context) {
+		if _, err := s.GetContact(c, c.Param("userID"), c.Param("contactID")); err != nil {
+			c.Status(http.StatusNotFound)
+		}
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/users/1/contacts/2", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	wantNames := []string{"repository.GetContact", "service.GetContact", "GET /users/:userID/contacts/:contactID"}
+	if len(spans) != len(wantNames) {
+		t.Fatalf("got %d spans, want %d", len(spans), len(wantNames))
+	}
+
+	for i, span := range spans {
+		if span.Name() != wantNames[i] {
+			t.Errorf("span %d name = %q, want %q", i, span.Name(), wantNames[i])
+		}
+		if got := span.SpanContext().TraceID().String(); got != traceID {
+			t.Errorf("span %q trace ID = %s, want %s", span.Name(), got, traceID)
+		}
+	}
+
+	if spans[0].Parent().SpanID() != spans[1].SpanContext().SpanID() {
+		t.Errorf("repository span is not a child of the service span")
+	}
+	if spans[0].Status().Code != codes.Error {
+		t.Errorf("repository span status = %v, want error", spans[0].Status().Code)
+	}
+	for _, span := range spans {
+		if strings.Contains(span.Status().Description, "not found for user") || len(span.Events()) > 0 {
+			t.Errorf("span %q records the error message: %q %v", span.Name(), span.Status().Description, span.Events())
+		}
+	}
+}
+
+func TestLockCache_KeyNotRecorded(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	locks := NewLockCache(inmem.NewLockCache(), provider)
+
+	if _, err := locks.Lock(context.Background(), "create:1:0501234567"); err != nil {
+		t.Fatalf("Lock() error = %v", err)
+	}
+	if err := locks.Unlock(context.Background(), "create:1:0501234567"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	for _, span := range recorder.Ended() {
+		for _, attr := range span.Attributes() {
+			if strings.Contains(attr.Value.Emit(), "0501234567") {
+				t.Errorf("span %q attribute %s holds the phone", span.Name(), attr.Key)
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package opentelemetry
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
+)
+
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+
+	instrumentationName = "contact-service"
+)
+
+// NewTracerProvider creates a provider exporting spans to stdout or to an OTLP/HTTP collector at endpoint, and
+// installs it globally together with the W3C trace context propagator. With ExporterNone spans are still created,
+// so trace IDs are propagated, but never exported.
+func NewTracerProvider(ctx context.Context, exporter, endpoint, serviceName string, sampleRatio float64) (*sdktrace.TracerProvider, error) {
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(serviceName),
+	))
+	if err != nil {
+		return nil, myerror.NewInternalError("opentelemetry.NewTracerProvider: %v", err)
+	}
+
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(sampleRatio))),
+	}
+
+	switch exporter {
+	case ExporterStdout:
+		spanExporter, err := stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+		if err != nil {
+			return nil, myerror.NewInternalError("opentelemetry.NewTracerProvider: %v", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(spanExporter))
+	case ExporterOTLP:
+		// collectors next to the service are usually reached without TLS
+		spanExporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpoint(endpoint), otlptracehttp.WithInsecure())
+		if err != nil {
+			return nil, myerror.NewInternalError("opentelemetry.NewTracerProvider: %v", err)
+		}
+		opts = append(opts, sdktrace.WithBatcher(spanExporter))
+	case ExporterNone:
+	default:
+		return nil, myerror.NewBadRequestError("opentelemetry.NewTracerProvider: unknown exporter %q", exporter)
+	}
+
+	provider := sdktrace.NewTracerProvider(opts...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider, nil
+}
@@ -0,0 +1,94 @@
+package opentelemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"contact-service/contact"
+)
+
+type Repository interface {
+	CreateContact(context.Context, contact.Contact) error
+	GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error)
+	DeleteContact(ctx context.Context, userID string, contactID string) error
+	SearchContacts(ctx context.Context, filters contact.Filters) (contacts []contact.Contact, err error)
+	UpdateContact(context.Context, contact.Contact) error
+	IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error)
+}
+
+// repository starts a client span around every storage call
+type repository struct {
+	next   Repository
+	tracer trace.Tracer
+}
+
+func NewRepository(next Repository, provider trace.TracerProvider) *repository {
+	return &repository{
+		next:   next,
+		tracer: provider.Tracer(instrumentationName),
+	}
+}
+
+func (r *repository) CreateContact(ctx context.Context, c contact.Contact) error {
+	ctx, span := r.start(ctx, "repository.CreateContact", c.UserID, attribute.String("contact.id", c.ID))
+
+	err := r.next.CreateContact(ctx, c)
+	endSpan(span, err)
+
+	return err
+}
+
+func (r *repository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	ctx, span := r.start(ctx, "repository.GetContact", userID, attribute.String("contact.id", contactID))
+
+	c, err := r.next.GetContact(ctx, userID, contactID)
+	endSpan(span, err)
+
+	return c, err
+}
+
+func (r *repository) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	ctx, span := r.start(ctx, "repository.DeleteContact", userID, attribute.String("contact.id", contactID))
+
+	err := r.next.DeleteContact(ctx, userID, contactID)
+	endSpan(span, err)
+
+	return err
+}
+
+func (r *repository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	ctx, span := r.start(ctx, "repository.SearchContacts", filters.UserID)
+
+	contacts, err := r.next.SearchContacts(ctx, filters)
+	span.SetAttributes(attribute.Int("search.results", len(contacts)))
+	endSpan(span, err)
+
+	return contacts, err
+}
+
+func (r *repository) UpdateContact(ctx context.Context, c contact.Contact) error {
+	ctx, span := r.start(ctx, "repository.UpdateContact", c.UserID, attribute.String("contact.id", c.ID))
+
+	err := r.next.UpdateContact(ctx, c)
+	endSpan(span, err)
+
+	return err
+}
+
+func (r *repository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	ctx, span := r.start(ctx, "repository.IsPhoneExistsForUser", userID)
+
+	exists, err := r.next.IsPhoneExistsForUser(ctx, userID, phone)
+	endSpan(span, err)
+
+	return exists, err
+}
+
+func (r *repository) start(ctx context.Context, name, userID string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return r.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(append(attrs, attribute.String("contact.user_id", userID))...),
+	)
+}
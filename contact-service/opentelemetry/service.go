@@ -0,0 +1,93 @@
+package opentelemetry
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"contact-service/contact"
+)
+
+type Service interface {
+	CreateContact(ctx context.Context, c contact.Contact) (string, error)
+	UpdateContact(ctx context.Context, c contact.Contact) error
+	GetContact(ctx context.Context, userID, contactID string) (contact.Contact, error)
+	SearchContacts(context.Context, contact.Filters) (contacts []contact.Contact, err error)
+	DeleteContact(ctx context.Context, userID, contactID string) error
+}
+
+// service starts a span around every service method
+type service struct {
+	next   Service
+	tracer trace.Tracer
+}
+
+func NewService(next Service, provider trace.TracerProvider) *service {
+	return &service{
+		next:   next,
+		tracer: provider.Tracer(instrumentationName),
+	}
+}
+
+func (s *service) CreateContact(ctx context.Context, c contact.Contact) (string, error) {
+	ctx, span := s.tracer.Start(ctx, "service.CreateContact", trace.WithAttributes(
+		attribute.String("contact.user_id", c.UserID),
+	))
+
+	id, err := s.next.CreateContact(ctx, c)
+	span.SetAttributes(attribute.String("contact.id", id))
+	endSpan(span, err)
+
+	return id, err
+}
+
+func (s *service) UpdateContact(ctx context.Context, c contact.Contact) error {
+	ctx, span := s.tracer.Start(ctx, "service.UpdateContact", trace.WithAttributes(
+		attribute.String("contact.user_id", c.UserID),
+		attribute.String("contact.id", c.ID),
+	))
+
+	err := s.next.UpdateContact(ctx, c)
+	endSpan(span, err)
+
+	return err
+}
+
+func (s *service) GetContact(ctx context.Context, userID, contactID string) (contact.Contact, error) {
+	ctx, span := s.tracer.Start(ctx, "service.GetContact", trace.WithAttributes(
+		attribute.String("contact.user_id", userID),
+		attribute.String("contact.id", contactID),
+	))
+
+	c, err := s.next.GetContact(ctx, userID, contactID)
+	endSpan(span, err)
+
+	return c, err
+}
+
+func (s *service) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	ctx, span := s.tracer.Start(ctx, "service.SearchContacts", trace.WithAttributes(
+		attribute.String("contact.user_id", filters.UserID),
+		attribute.Int("search.limit", filters.Limit),
+		attribute.Int("search.offset", filters.Offset),
+	))
+
+	contacts, err := s.next.SearchContacts(ctx, filters)
+	span.SetAttributes(attribute.Int("search.results", len(contacts)))
+	endSpan(span, err)
+
+	return contacts, err
+}
+
+func (s *service) DeleteContact(ctx context.Context, userID, contactID string) error {
+	ctx, span := s.tracer.Start(ctx, "service.DeleteContact", trace.WithAttributes(
+		attribute.String("contact.user_id", userID),
+		attribute.String("contact.id", contactID),
+	))
+
+	err := s.next.DeleteContact(ctx, userID, contactID)
+	endSpan(span, err)
+
+	return err
+}
@@ -0,0 +1,21 @@
+package opentelemetry
+
+import (
+	"infrastructure/myerror"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// endSpan records the type of the error of the call, if any, and ends its span. The error message is not recorded,
+// since it may hold the phone or other data of a contact.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		errorType := myerror.GetParsedError(err).Type.String()
+		span.SetAttributes(attribute.String("error.type", errorType))
+		span.SetStatus(codes.Error, errorType)
+	}
+
+	span.End()
+}
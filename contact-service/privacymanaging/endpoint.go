@@ -0,0 +1,65 @@
+package privacymanaging
+
+import (
+	"context"
+	"infrastructure/myerror"
+
+	"contact-service/contact"
+)
+
+type Service interface {
+	ExportUser(ctx context.Context, userID string) (Export, error)
+	EraseUser(ctx context.Context, userID string) (contact.ErasureReceipt, error)
+	GetReceipt(ctx context.Context, receiptID string) (contact.ErasureReceipt, error)
+	ListReceipts(ctx context.Context) ([]contact.ErasureReceipt, error)
+}
+
+// Export
+
+func endpointExportUser(ctx context.Context, s Service, userID string) (Export, error) {
+	if userID == "" {
+		return Export{}, myerror.NewBadRequestError("endpointExportUser: invalid request: userID is required")
+	}
+
+	export, err := s.ExportUser(ctx, userID)
+	if err != nil {
+		return Export{}, myerror.Wrap(err, "endpointExportUser")
+	}
+
+	return export, nil
+}
+
+// Erase
+
+func endpointEraseUser(ctx context.Context, s Service, userID string) (contact.ErasureReceipt, error) {
+	if userID == "" {
+		return contact.ErasureReceipt{}, myerror.NewBadRequestError("endpointEraseUser: invalid request: userID is required")
+	}
+
+	receipt, err := s.EraseUser(ctx, userID)
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "endpointEraseUser")
+	}
+
+	return receipt, nil
+}
+
+// Receipts
+
+func endpointGetReceipt(ctx context.Context, s Service, receiptID string) (contact.ErasureReceipt, error) {
+	receipt, err := s.GetReceipt(ctx, receiptID)
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "endpointGetReceipt")
+	}
+
+	return receipt, nil
+}
+
+func endpointListReceipts(ctx context.Context, s Service) ([]contact.ErasureReceipt, error) {
+	receipts, err := s.ListReceipts(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "endpointListReceipts")
+	}
+
+	return receipts, nil
+}
@@ -0,0 +1,316 @@
+package privacymanaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"infrastructure/myerror"
+	"infrastructure/myhttp"
+	"mime"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/apikey"
+	"contact-service/contact"
+	"contact-service/contactmanaging"
+)
+
+const (
+	exportUserURL   = "/users/:userID/export"
+	eraseUserURL    = "/users/:userID"
+	listReceiptsURL = "/erasures"
+	getReceiptURL   = "/erasures/:receiptID"
+
+	archiveContentType = "application/zip"
+)
+
+// RegisterHTTPEndpoints adds the export and erasure routes to r, each user reaching only their own data
+func RegisterHTTPEndpoints(r gin.IRouter, s Service) {
+	r.GET(exportUserURL, contactmanaging.RequireScope(apikey.ScopeRead), contactmanaging.AuthorizeUser(), makeHTTPEndpointExportUser(s))
+	r.DELETE(eraseUserURL, contactmanaging.RequireScope(apikey.ScopeWrite), contactmanaging.AuthorizeUser(), makeHTTPEndpointEraseUser(s))
+}
+
+// RegisterAdminHTTPEndpoints adds the routes auditing the erasures to the admin routes r
+func RegisterAdminHTTPEndpoints(r gin.IRouter, s Service) {
+	r.GET(listReceiptsURL, makeHTTPEndpointListReceipts(s))
+	r.GET(getReceiptURL, makeHTTPEndpointGetReceipt(s))
+}
+
+type contactJSON struct {
+	ID        string    `json:"id"`
+	Phone     string    `json:"phone"`
+	FirstName string    `json:"firstName"`
+	LastName  string    `json:"lastName"`
+	Address   string    `json:"address"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+type shareJSON struct {
+	ID         string    `json:"id"`
+	OwnerID    string    `json:"ownerID"`
+	ContactID  string    `json:"contactID,omitempty"`
+	GranteeID  string    `json:"granteeID"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type memberJSON struct {
+	UserID    string     `json:"userID"`
+	Role      string     `json:"role"`
+	InvitedAt time.Time  `json:"invitedAt"`
+	JoinedAt  *time.Time `json:"joinedAt,omitempty"`
+}
+
+type bookJSON struct {
+	ID        string       `json:"id"`
+	Name      string       `json:"name"`
+	OwnerID   string       `json:"ownerID"`
+	Members   []memberJSON `json:"members"`
+	CreatedAt time.Time    `json:"createdAt"`
+}
+
+type manifestJSON struct {
+	UserID     string         `json:"userID"`
+	TenantID   string         `json:"tenantID,omitempty"`
+	ExportedAt time.Time      `json:"exportedAt"`
+	Files      map[string]int `json:"files"`
+}
+
+type erasedJSON struct {
+	Contacts       int `json:"contacts"`
+	BookContacts   int `json:"bookContacts"`
+	SharesGranted  int `json:"sharesGranted"`
+	SharesReceived int `json:"sharesReceived"`
+	Memberships    int `json:"memberships"`
+	Books          int `json:"books"`
+}
+
+type receiptHTTPResponse struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"userID"`
+	TenantID    string     `json:"tenantID,omitempty"`
+	RequestedBy string     `json:"requestedBy,omitempty"`
+	ErasedAt    time.Time  `json:"erasedAt"`
+	Erased      erasedJSON `json:"erased"`
+	Digest      string     `json:"digest"`
+}
+
+type receiptsHTTPResponse struct {
+	Receipts []receiptHTTPResponse `json:"receipts"`
+}
+
+// Export
+func makeHTTPEndpointExportUser(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		export, err := endpointExportUser(c, s, c.Param("userID"))
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		archive, err := encodeArchive(export)
+		if err != nil {
+			myhttp.EncodeJSONError(c, myerror.Wrap(err, "makeHTTPEndpointExportUser"))
+			return
+		}
+
+		c.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+			"filename": "export-" + export.UserID + "-" + export.ExportedAt.UTC().Format("20060102T150405Z") + ".zip",
+		}))
+		c.Data(http.StatusOK, archiveContentType, archive)
+	}
+}
+
+// encodeArchive writes the export as a zip archive of JSON files, described by manifest.json. The contacts of the
+// books the user owns are in books/<bookID>/contacts.json.
+func encodeArchive(export Export) ([]byte, error) {
+	contacts := contactsToJSON(export.Contacts)
+
+	shares := struct {
+		Granted  []shareJSON `json:"granted"`
+		Received []shareJSON `json:"received"`
+	}{
+		Granted:  sharesToJSON(export.SharesGranted),
+		Received: sharesToJSON(export.SharesReceived),
+	}
+
+	books := make([]bookJSON, 0, len(export.Books))
+	for _, b := range export.Books {
+		books = append(books, bookToJSON(b))
+	}
+
+	type archiveFile struct {
+		name  string
+		value interface{}
+	}
+	files := []archiveFile{
+		{name: "contacts.json", value: contacts},
+		{name: "shares.json", value: shares},
+		{name: "books.json", value: books},
+	}
+
+	manifest := manifestJSON{
+		UserID:     export.UserID,
+		TenantID:   export.TenantID,
+		ExportedAt: export.ExportedAt,
+		Files: map[string]int{
+			"contacts.json": len(contacts),
+			"shares.json":   len(shares.Granted) + len(shares.Received),
+			"books.json":    len(books),
+		},
+	}
+
+	for _, b := range export.Books {
+		bookContacts, ok := export.BookContacts[b.ID]
+		if !ok {
+			continue
+		}
+		name := "books/" + b.ID + "/contacts.json"
+		files = append(files, archiveFile{name: name, value: contactsToJSON(bookContacts)})
+		manifest.Files[name] = len(bookContacts)
+	}
+
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for _, file := range append([]archiveFile{{name: "manifest.json", value: manifest}}, files...) {
+		f, err := w.CreateHeader(&zip.FileHeader{Name: file.name, Method: zip.Deflate, Modified: export.ExportedAt})
+		if err != nil {
+			return nil, myerror.NewInternalError("encodeArchive: %v", err)
+		}
+
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(file.value); err != nil {
+			return nil, myerror.NewInternalError("encodeArchive: %s: %v", file.name, err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		return nil, myerror.NewInternalError("encodeArchive: %v", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Erase
+func makeHTTPEndpointEraseUser(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receipt, err := endpointEraseUser(c, s, c.Param("userID"))
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		myhttp.EncodeJSONSuccess(c, receiptToJSON(receipt))
+	}
+}
+
+// Receipts
+func makeHTTPEndpointGetReceipt(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receipt, err := endpointGetReceipt(c, s, c.Param("receiptID"))
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		myhttp.EncodeJSONSuccess(c, receiptToJSON(receipt))
+	}
+}
+
+func makeHTTPEndpointListReceipts(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		receipts, err := endpointListReceipts(c, s)
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		jsonResponse := receiptsHTTPResponse{
+			Receipts: make([]receiptHTTPResponse, 0, len(receipts)),
+		}
+		for _, receipt := range receipts {
+			jsonResponse.Receipts = append(jsonResponse.Receipts, receiptToJSON(receipt))
+		}
+
+		myhttp.EncodeJSONSuccess(c, jsonResponse)
+	}
+}
+
+func contactsToJSON(contacts []contact.Contact) []contactJSON {
+	out := make([]contactJSON, 0, len(contacts))
+	for _, ct := range contacts {
+		out = append(out, contactJSON{
+			ID:        ct.ID,
+			Phone:     ct.Phone,
+			FirstName: ct.FirstName,
+			LastName:  ct.LastName,
+			Address:   ct.Address,
+			CreatedAt: ct.CreatedAt,
+			UpdatedAt: ct.UpdatedAt,
+		})
+	}
+
+	return out
+}
+
+func sharesToJSON(shares []contact.Share) []shareJSON {
+	out := make([]shareJSON, 0, len(shares))
+	for _, share := range shares {
+		out = append(out, shareJSON{
+			ID:         share.ID,
+			OwnerID:    share.OwnerID,
+			ContactID:  share.ContactID,
+			GranteeID:  share.GranteeID,
+			Permission: share.Permission,
+			CreatedAt:  share.CreatedAt,
+		})
+	}
+
+	return out
+}
+
+func bookToJSON(b contact.Book) bookJSON {
+	members := make([]memberJSON, 0, len(b.Members))
+	for _, m := range b.Members {
+		member := memberJSON{
+			UserID:    m.UserID,
+			Role:      m.Role,
+			InvitedAt: m.InvitedAt,
+		}
+		if m.IsActive() {
+			joinedAt := m.JoinedAt
+			member.JoinedAt = &joinedAt
+		}
+		members = append(members, member)
+	}
+
+	return bookJSON{
+		ID:        b.ID,
+		Name:      b.Name,
+		OwnerID:   b.OwnerID,
+		Members:   members,
+		CreatedAt: b.CreatedAt,
+	}
+}
+
+func receiptToJSON(receipt contact.ErasureReceipt) receiptHTTPResponse {
+	return receiptHTTPResponse{
+		ID:          receipt.ID,
+		UserID:      receipt.UserID,
+		TenantID:    receipt.TenantID,
+		RequestedBy: receipt.RequestedBy,
+		ErasedAt:    receipt.ErasedAt,
+		Erased: erasedJSON{
+			Contacts:       receipt.Contacts,
+			BookContacts:   receipt.BookContacts,
+			SharesGranted:  receipt.SharesGranted,
+			SharesReceived: receipt.SharesReceived,
+			Memberships:    receipt.Memberships,
+			Books:          receipt.Books,
+		},
+		Digest: receipt.Digest,
+	}
+}
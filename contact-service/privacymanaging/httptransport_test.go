@@ -0,0 +1,196 @@
+package privacymanaging
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/bookmanaging"
+	"contact-service/contact"
+	"contact-service/inmem"
+	"contact-service/sharemanaging"
+	"contact-service/testutil"
+)
+
+// newTestHandler serves the contacts, share, book and privacy routes over the data of alice: the contacts a1 and a2,
+// a share of her whole address book with bob, a share of carol's with her, the book b1 she owns with one contact, and
+// her membership in the book b2 of carol. Contacts are read through a cache, which the erasure must not leave stale.
+func newTestHandler(t *testing.T) http.Handler {
+	t.Helper()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	s := testutil.NewStores(t, testutil.Fixture{
+		Contacts: []contact.Contact{
+			{UserID: "alice", ID: "a1", Phone: "123", FirstName: "a", LastName: "b", Address: "c"},
+			{UserID: "alice", ID: "a2", Phone: "456", FirstName: "d", LastName: "e", Address: "f"},
+			{UserID: contact.BookOwnerID("b1"), ID: "bc1", Phone: "789", FirstName: "g", LastName: "h", Address: "i"},
+		},
+		Shares: []contact.Share{
+			{ID: "s1", OwnerID: "alice", GranteeID: "bob", Permission: contact.PermissionRead},
+			{ID: "s2", OwnerID: "carol", GranteeID: "alice", Permission: contact.PermissionRead},
+		},
+		Books: []contact.Book{
+			{ID: "b1", Name: "family", OwnerID: "alice", Members: []contact.Member{
+				{UserID: "alice", Role: contact.RoleOwner, InvitedAt: now, JoinedAt: now},
+			}},
+			{ID: "b2", Name: "team", OwnerID: "carol", Members: []contact.Member{
+				{UserID: "carol", Role: contact.RoleOwner, InvitedAt: now, JoinedAt: now},
+				{UserID: "alice", Role: contact.RoleEditor, InvitedAt: now, JoinedAt: now},
+			}},
+		},
+		Cached: true,
+	})
+	privacy := NewService(s.Contacts, s.Shares, s.Books, inmem.NewErasureRepository(), testutil.NopLogger{})
+
+	return testutil.NewHandler(s, []func(gin.IRouter){
+		func(r gin.IRouter) {
+			sharemanaging.RegisterHTTPEndpoints(r, sharemanaging.NewService(s.Shares, s.Contacts))
+		},
+		func(r gin.IRouter) {
+			bookmanaging.RegisterHTTPEndpoints(r, bookmanaging.NewService(s.Books), s.Service)
+		},
+		func(r gin.IRouter) { RegisterHTTPEndpoints(r, privacy) },
+	}, []func(gin.IRouter){
+		func(r gin.IRouter) { RegisterAdminHTTPEndpoints(r, privacy) },
+	})
+}
+
+func TestRegisterHTTPEndpoints_Export(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		user         string
+		wantStatus   int
+		wantContacts int
+		wantGranted  int
+		wantReceived int
+		wantBooks    int
+		// wantBookContacts counts the contacts of every book the user owns
+		wantBookContacts map[string]int
+	}{
+		{name: "user exports their data", user: "alice", wantStatus: http.StatusOK, wantContacts: 2, wantGranted: 1, wantReceived: 1, wantBooks: 2, wantBookContacts: map[string]int{"b1": 1}},
+		{name: "export by another user", user: "bob", wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := testutil.Do(newTestHandler(t), tt.user, http.MethodGet, "/users/alice/export", "")
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+			if rec.Header().Get("Content-Type") != archiveContentType {
+				t.Fatalf("content type = %q, want %q", rec.Header().Get("Content-Type"), archiveContentType)
+			}
+
+			archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+			if err != nil {
+				t.Fatalf("zip.NewReader() error = %v", err)
+			}
+			read := func(name string, v interface{}) {
+				t.Helper()
+				f, err := archive.Open(name)
+				if err != nil {
+					t.Fatalf("archive.Open(%s) error = %v", name, err)
+				}
+				data, _ := io.ReadAll(f)
+				if err := json.Unmarshal(data, v); err != nil {
+					t.Fatalf("Unmarshal(%s) error = %v", name, err)
+				}
+			}
+
+			var exported struct {
+				manifest manifestJSON
+				contacts []contactJSON
+				shares   struct {
+					Granted  []shareJSON `json:"granted"`
+					Received []shareJSON `json:"received"`
+				}
+				books []bookJSON
+			}
+			read("manifest.json", &exported.manifest)
+			read("contacts.json", &exported.contacts)
+			read("shares.json", &exported.shares)
+			read("books.json", &exported.books)
+			if len(exported.contacts) != tt.wantContacts || len(exported.shares.Granted) != tt.wantGranted ||
+				len(exported.shares.Received) != tt.wantReceived || len(exported.books) != tt.wantBooks {
+				t.Errorf("export = %+v", exported)
+			}
+
+			// only the books the user owns have their contacts exported
+			if len(exported.manifest.Files) != 3+len(tt.wantBookContacts) {
+				t.Errorf("manifest files = %v, want the contacts of %d books", exported.manifest.Files, len(tt.wantBookContacts))
+			}
+			for bookID, want := range tt.wantBookContacts {
+				name := "books/" + bookID + "/contacts.json"
+				var contacts []contactJSON
+				read(name, &contacts)
+				if len(contacts) != want || exported.manifest.Files[name] != want {
+					t.Errorf("%s = %+v, listed with %d contacts in the manifest, want %d", name, contacts, exported.manifest.Files[name], want)
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterHTTPEndpoints_Erasure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name string
+		// erased erases the data of alice before the request
+		erased     bool
+		user       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "erasure by another user", user: "bob", method: http.MethodDelete, path: "/users/alice", wantStatus: http.StatusForbidden},
+		{name: "erasure counts the erased records", user: "alice", method: http.MethodDelete, path: "/users/alice", wantStatus: http.StatusOK, wantBody: `"erased":{"contacts":2,"bookContacts":1,"sharesGranted":1,"sharesReceived":1,"memberships":1,"books":1}`},
+		{name: "cached contact is gone", erased: true, user: "alice", method: http.MethodGet, path: "/users/alice/contacts/a1", body: "{}", wantStatus: http.StatusNotFound},
+		{name: "no contacts left", erased: true, user: "alice", method: http.MethodGet, path: "/users/alice/contacts", wantStatus: http.StatusOK, wantBody: `"contacts":[]`},
+		{name: "grantee lost the share", erased: true, user: "bob", method: http.MethodGet, path: "/users/bob/shared", wantStatus: http.StatusOK, wantBody: `"shares":[]`},
+		{name: "owned book is deleted", erased: true, user: "alice", method: http.MethodGet, path: "/books/b1", wantStatus: http.StatusNotFound},
+		{name: "membership is removed", erased: true, user: "alice", method: http.MethodGet, path: "/books/b2/contacts", wantStatus: http.StatusForbidden},
+		{name: "erasing again finds nothing", erased: true, user: "alice", method: http.MethodDelete, path: "/users/alice", wantStatus: http.StatusOK, wantBody: `"erased":{"contacts":0,"bookContacts":0,"sharesGranted":0,"sharesReceived":0,"memberships":0,"books":0}`},
+		{name: "user cannot read receipts", erased: true, user: "alice", method: http.MethodGet, path: "/admin/erasures", wantStatus: http.StatusForbidden},
+		{name: "receipt is kept", erased: true, user: testutil.AdminUser, method: http.MethodGet, path: "/admin/erasures", wantStatus: http.StatusOK, wantBody: `"requestedBy":"alice"`},
+		{name: "missing receipt", erased: true, user: testutil.AdminUser, method: http.MethodGet, path: "/admin/erasures/missing", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(t)
+
+			// cached before the erasure
+			if rec := testutil.Do(handler, "bob", http.MethodGet, "/users/alice/contacts/a1", "{}"); rec.Code != http.StatusOK {
+				t.Fatalf("shared contact: status = %d: %s", rec.Code, rec.Body.String())
+			}
+			if tt.erased {
+				if rec := testutil.Do(handler, "alice", http.MethodDelete, "/users/alice", ""); rec.Code != http.StatusOK {
+					t.Fatalf("erase: status = %d: %s", rec.Code, rec.Body.String())
+				}
+			}
+
+			rec := testutil.Do(handler, tt.user, tt.method, tt.path, tt.body)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantBody != "" && !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want it to contain %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
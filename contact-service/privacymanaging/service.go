@@ -0,0 +1,260 @@
+package privacymanaging
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"contact-service/contact"
+)
+
+// contactsPageSize is the number of contacts read from the repository at a time
+const contactsPageSize = 500
+
+type ContactRepository interface {
+	SearchContacts(ctx context.Context, filters contact.Filters) (contacts []contact.Contact, err error)
+	DeleteContact(ctx context.Context, userID string, contactID string) error
+}
+
+type ShareRepository interface {
+	DeleteShare(ctx context.Context, ownerID, shareID string) error
+	ListSharesByOwner(ctx context.Context, ownerID string) ([]contact.Share, error)
+	ListSharesByGrantee(ctx context.Context, granteeID string) ([]contact.Share, error)
+}
+
+type BookRepository interface {
+	ListBooksByMember(ctx context.Context, userID string) ([]contact.Book, error)
+	DeleteMember(ctx context.Context, bookID, userID string) error
+	DeleteBook(ctx context.Context, bookID string) error
+}
+
+// ReceiptRepository keeps the erasure receipts, which outlive the data they describe
+type ReceiptRepository interface {
+	SaveReceipt(context.Context, contact.ErasureReceipt) error
+	GetReceipt(ctx context.Context, receiptID string) (contact.ErasureReceipt, error)
+	ListReceipts(context.Context) ([]contact.ErasureReceipt, error)
+}
+
+type Logger interface {
+	Info(ctx context.Context, msg string, keyvals ...interface{})
+	Error(ctx context.Context, err error, keyvals ...interface{})
+	Warning(ctx context.Context, err error, keyvals ...interface{})
+	Debug(ctx context.Context, msg string, keyvals ...interface{})
+}
+
+// Export is everything the service stores for a user
+type Export struct {
+	UserID         string
+	TenantID       string
+	ExportedAt     time.Time
+	Contacts       []contact.Contact
+	SharesGranted  []contact.Share
+	SharesReceived []contact.Share
+	Books          []contact.Book
+	// BookContacts holds the contacts of the books the user owns, by book ID
+	BookContacts map[string][]contact.Contact
+}
+
+type service struct {
+	contacts ContactRepository
+	shares   ShareRepository
+	books    BookRepository
+	receipts ReceiptRepository
+	logger   Logger
+}
+
+func NewService(contacts ContactRepository, shares ShareRepository, books BookRepository, receipts ReceiptRepository, logger Logger) *service {
+	return &service{
+		contacts: contacts,
+		shares:   shares,
+		books:    books,
+		receipts: receipts,
+		logger:   logger,
+	}
+}
+
+// ExportUser collects the contacts of the user, the shares granted by and to the user, the address books the user
+// owns, belongs to or is invited to, and the contacts of the books the user owns
+func (s service) ExportUser(ctx context.Context, userID string) (Export, error) {
+	export := Export{
+		UserID:       userID,
+		TenantID:     mycontext.TenantID(ctx),
+		ExportedAt:   time.Now(),
+		BookContacts: make(map[string][]contact.Contact),
+	}
+
+	var err error
+	if export.Contacts, err = s.allContacts(ctx, userID); err != nil {
+		return Export{}, myerror.Wrap(err, "service.ExportUser")
+	}
+	if export.SharesGranted, err = s.shares.ListSharesByOwner(ctx, userID); err != nil {
+		return Export{}, myerror.Wrap(err, "service.ExportUser")
+	}
+	if export.SharesReceived, err = s.shares.ListSharesByGrantee(ctx, userID); err != nil {
+		return Export{}, myerror.Wrap(err, "service.ExportUser")
+	}
+	if export.Books, err = s.books.ListBooksByMember(ctx, userID); err != nil {
+		return Export{}, myerror.Wrap(err, "service.ExportUser")
+	}
+	for _, b := range export.Books {
+		if b.OwnerID != userID {
+			continue
+		}
+		if export.BookContacts[b.ID], err = s.allContacts(ctx, contact.BookOwnerID(b.ID)); err != nil {
+			return Export{}, myerror.Wrap(err, "service.ExportUser")
+		}
+	}
+
+	return export, nil
+}
+
+// EraseUser deletes the contacts and shares of the user, removes the user from the address books of others and
+// deletes the books the user owns together with their contacts. Every step is idempotent, so an erasure that failed
+// half way is completed by erasing the user again. The receipt is saved once everything is erased.
+func (s service) EraseUser(ctx context.Context, userID string) (contact.ErasureReceipt, error) {
+	receipt := contact.ErasureReceipt{
+		ID:          uuid.New().String(),
+		UserID:      userID,
+		TenantID:    mycontext.TenantID(ctx),
+		RequestedBy: mycontext.Subject(ctx),
+	}
+	var erased []string
+
+	contacts, err := s.eraseContacts(ctx, userID)
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+	}
+	receipt.Contacts = len(contacts)
+	erased = append(erased, contacts...)
+
+	granted, err := s.shares.ListSharesByOwner(ctx, userID)
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+	}
+	received, err := s.shares.ListSharesByGrantee(ctx, userID)
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+	}
+	for _, share := range append(granted, received...) {
+		if err := s.shares.DeleteShare(ctx, share.OwnerID, share.ID); err != nil && !isNotFound(err) {
+			return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+		}
+		erased = append(erased, "share:"+share.ID)
+	}
+	receipt.SharesGranted, receipt.SharesReceived = len(granted), len(received)
+
+	books, err := s.books.ListBooksByMember(ctx, userID)
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+	}
+	for _, b := range books {
+		if b.OwnerID != userID {
+			if err := s.books.DeleteMember(ctx, b.ID, userID); err != nil && !isNotFound(err) {
+				return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+			}
+			receipt.Memberships++
+			erased = append(erased, "member:"+b.ID)
+			continue
+		}
+
+		// the contacts go first, so a failed erasure leaves the book to find them again
+		bookContacts, err := s.eraseContacts(ctx, contact.BookOwnerID(b.ID))
+		if err != nil {
+			return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+		}
+		if err := s.books.DeleteBook(ctx, b.ID); err != nil && !isNotFound(err) {
+			return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+		}
+		receipt.BookContacts += len(bookContacts)
+		receipt.Books++
+		erased = append(erased, bookContacts...)
+		erased = append(erased, "book:"+b.ID)
+	}
+
+	receipt.ErasedAt = time.Now()
+	receipt.Digest = digest(erased)
+	if err := s.receipts.SaveReceipt(ctx, receipt); err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "service.EraseUser")
+	}
+
+	s.logger.Info(ctx, "service.EraseUser: erased user", "receiptID", receipt.ID, "userID", userID, "digest", receipt.Digest)
+
+	return receipt, nil
+}
+
+func (s service) GetReceipt(ctx context.Context, receiptID string) (contact.ErasureReceipt, error) {
+	receipt, err := s.receipts.GetReceipt(ctx, receiptID)
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "service.GetReceipt")
+	}
+
+	return receipt, nil
+}
+
+func (s service) ListReceipts(ctx context.Context) ([]contact.ErasureReceipt, error) {
+	receipts, err := s.receipts.ListReceipts(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "service.ListReceipts")
+	}
+
+	return receipts, nil
+}
+
+// allContacts reads every contact of the user, a page at a time
+func (s service) allContacts(ctx context.Context, userID string) ([]contact.Contact, error) {
+	var contacts []contact.Contact
+	for {
+		page, err := s.contacts.SearchContacts(ctx, contact.Filters{
+			UserID: userID,
+			Limit:  contactsPageSize,
+			Offset: len(contacts),
+		})
+		if err != nil {
+			return nil, myerror.Wrap(err, "allContacts")
+		}
+
+		contacts = append(contacts, page...)
+		if len(page) < contactsPageSize {
+			return contacts, nil
+		}
+	}
+}
+
+// eraseContacts deletes every contact of the owner through the repository, so the caches drop them too, and returns
+// the IDs of the erased records
+func (s service) eraseContacts(ctx context.Context, ownerID string) ([]string, error) {
+	contacts, err := s.allContacts(ctx, ownerID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "eraseContacts")
+	}
+
+	erased := make([]string, 0, len(contacts))
+	for _, c := range contacts {
+		if err := s.contacts.DeleteContact(ctx, ownerID, c.ID); err != nil {
+			return nil, myerror.Wrap(err, "eraseContacts")
+		}
+		erased = append(erased, "contact:"+ownerID+"/"+c.ID)
+	}
+
+	return erased, nil
+}
+
+// digest hashes the erased record IDs in a canonical order
+func digest(erased []string) string {
+	sorted := append([]string(nil), erased...)
+	sort.Strings(sorted)
+
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func isNotFound(err error) bool {
+	return myerror.GetParsedError(err).Type == myerror.NotFoundError
+}
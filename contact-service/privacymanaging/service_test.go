@@ -0,0 +1,91 @@
+package privacymanaging
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"contact-service/contact"
+	"contact-service/inmem"
+	"contact-service/testutil"
+)
+
+func Test_digest(t *testing.T) {
+	tests := []struct {
+		name     string
+		a, b     []string
+		wantSame bool
+	}{
+		{name: "same records in another order", a: []string{"contact:alice/a1", "share:s1"}, b: []string{"share:s1", "contact:alice/a1"}, wantSame: true},
+		{name: "other records", a: []string{"contact:alice/a1", "share:s1"}, b: []string{"contact:alice/a2", "share:s1"}},
+		{name: "one record less", a: []string{"contact:alice/a1", "share:s1"}, b: []string{"contact:alice/a1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digest(tt.a) == digest(tt.b); got != tt.wantSame {
+				t.Errorf("digest(%v) == digest(%v) is %v, want %v", tt.a, tt.b, got, tt.wantSame)
+			}
+		})
+	}
+}
+
+func Test_service_EraseUser(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fixture := testutil.Fixture{
+		Contacts: []contact.Contact{
+			{UserID: "alice", ID: "a1", Phone: "123", FirstName: "a", LastName: "b", Address: "c"},
+			{UserID: "alice", ID: "a2", Phone: "456", FirstName: "d", LastName: "e", Address: "f"},
+			{UserID: contact.BookOwnerID("b1"), ID: "bc1", Phone: "789", FirstName: "g", LastName: "h", Address: "i"},
+		},
+		Shares: []contact.Share{
+			{ID: "s1", OwnerID: "alice", GranteeID: "bob", Permission: contact.PermissionRead},
+			{ID: "s2", OwnerID: "carol", GranteeID: "alice", Permission: contact.PermissionRead},
+		},
+		Books: []contact.Book{
+			{ID: "b1", Name: "family", OwnerID: "alice", Members: []contact.Member{
+				{UserID: "alice", Role: contact.RoleOwner, InvitedAt: now, JoinedAt: now},
+			}},
+			{ID: "b2", Name: "team", OwnerID: "carol", Members: []contact.Member{
+				{UserID: "carol", Role: contact.RoleOwner, InvitedAt: now, JoinedAt: now},
+				{UserID: "alice", Role: contact.RoleEditor, InvitedAt: now, JoinedAt: now},
+			}},
+		},
+	}
+	// the digest does not depend on the order in which the records were stored
+	reversed := testutil.Fixture{
+		Contacts: reverse(fixture.Contacts),
+		Shares:   reverse(fixture.Shares),
+		Books:    reverse(fixture.Books),
+	}
+	wantDigest := digest([]string{
+		"contact:alice/a1", "contact:alice/a2", "share:s1", "share:s2",
+		"contact:" + contact.BookOwnerID("b1") + "/bc1", "book:b1", "member:b2",
+	})
+
+	for _, f := range []testutil.Fixture{fixture, reversed} {
+		stores := testutil.NewStores(t, f)
+		s := NewService(stores.Contacts, stores.Shares, stores.Books, inmem.NewErasureRepository(), testutil.NopLogger{})
+
+		receipt, err := s.EraseUser(context.Background(), "alice")
+		if err != nil {
+			t.Fatalf("EraseUser() error = %v", err)
+		}
+		if receipt.Digest != wantDigest {
+			t.Errorf("EraseUser() digest = %s, want %s", receipt.Digest, wantDigest)
+		}
+		if receipt.Contacts != 2 || receipt.SharesGranted != 1 || receipt.SharesReceived != 1 ||
+			receipt.Books != 1 || receipt.BookContacts != 1 || receipt.Memberships != 1 {
+			t.Errorf("EraseUser() receipt = %+v, want 2 contacts, 1 share of each kind, 1 book with 1 contact and 1 membership", receipt)
+		}
+	}
+}
+
+func reverse[T any](s []T) []T {
+	reversed := make([]T, 0, len(s))
+	for i := len(s) - 1; i >= 0; i-- {
+		reversed = append(reversed, s[i])
+	}
+
+	return reversed
+}
@@ -0,0 +1,62 @@
+package prometheus
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"contact-service/inmem"
+)
+
+// StatsFunc returns the current counters of an LRU cache, e.g. lruCache.Stats or the sharded cache Stats
+type StatsFunc func() inmem.CacheStats
+
+// cacheCollector reads the cache counters on every scrape, so the cache itself does not depend on Prometheus. The hit
+// ratio is derived in queries from the hits and misses counters.
+type cacheCollector struct {
+	stats       StatsFunc
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	evictions   *prometheus.Desc
+	expirations *prometheus.Desc
+	coalesced   *prometheus.Desc
+	size        *prometheus.Desc
+	capacity    *prometheus.Desc
+}
+
+func NewCacheCollector(stats StatsFunc) *cacheCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", name), help, nil, nil)
+	}
+
+	return &cacheCollector{
+		stats:       stats,
+		hits:        desc("hits_total", "Cache lookups that found a live entry."),
+		misses:      desc("misses_total", "Cache lookups that found no live entry."),
+		evictions:   desc("evictions_total", "Entries evicted because the cache was full."),
+		expirations: desc("expirations_total", "Entries dropped because their TTL passed."),
+		coalesced:   desc("coalesced_total", "Misses that shared a repository fetch with a concurrent miss."),
+		size:        desc("entries", "Entries currently held by the cache."),
+		capacity:    desc("capacity", "Maximum number of entries the cache holds."),
+	}
+}
+
+func (c *cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.expirations
+	ch <- c.coalesced
+	ch <- c.size
+	ch <- c.capacity
+}
+
+func (c *cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.expirations, prometheus.CounterValue, float64(stats.Expirations))
+	ch <- prometheus.MustNewConstMetric(c.coalesced, prometheus.CounterValue, float64(stats.Coalesced))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity))
+}
@@ -0,0 +1,59 @@
+package prometheus
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	lockResultAcquired  = "acquired"
+	lockResultContended = "contended"
+	lockResultError     = "error"
+)
+
+type LockCache interface {
+	Lock(context.Context, string) (bool, error)
+	Unlock(context.Context, string) error
+}
+
+// lockCache counts lock attempts by result, a contended attempt is one that found the key already locked
+type lockCache struct {
+	next     LockCache
+	attempts *prometheus.CounterVec
+}
+
+func NewLockCache(next LockCache, registerer prometheus.Registerer) *lockCache {
+	attempts := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "lock",
+		Name:      "attempts_total",
+		Help:      "Lock attempts by result: acquired, contended or error.",
+	}, []string{"result"})
+
+	registerer.MustRegister(attempts)
+
+	return &lockCache{
+		next:     next,
+		attempts: attempts,
+	}
+}
+
+func (l *lockCache) Lock(ctx context.Context, key string) (bool, error) {
+	ok, err := l.next.Lock(ctx, key)
+
+	switch {
+	case err != nil:
+		l.attempts.WithLabelValues(lockResultError).Inc()
+	case !ok:
+		l.attempts.WithLabelValues(lockResultContended).Inc()
+	default:
+		l.attempts.WithLabelValues(lockResultAcquired).Inc()
+	}
+
+	return ok, err
+}
+
+func (l *lockCache) Unlock(ctx context.Context, key string) error {
+	return l.next.Unlock(ctx, key)
+}
@@ -0,0 +1,135 @@
+package prometheus
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"contact-service/contact"
+	"contact-service/inmem"
+)
+
+// stubService fails every call with err
+type stubService struct {
+	Service
+	err error
+}
+
+func (s stubService) GetContact(context.Context, string, string) (contact.Contact, error) {
+	return contact.Contact{}, s.err
+}
+
+// stubLockCache grants a key only once until it is unlocked
+type stubLockCache struct {
+	locked map[string]bool
+}
+
+func (l stubLockCache) Lock(_ context.Context, key string) (bool, error) {
+	if l.locked[key] {
+		return false, nil
+	}
+	l.locked[key] = true
+	return true, nil
+}
+
+func (l stubLockCache) Unlock(_ context.Context, key string) error {
+	delete(l.locked, key)
+	return nil
+}
+
+func TestService_ErrorsByType(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		wantType string
+	}{
+		{name: "not found", err: myerror.NewNotFoundError("missing"), wantType: "not_found"},
+		{name: "wrapped bad request", err: myerror.Wrap(myerror.NewBadRequestError("invalid"), "layer"), wantType: "bad_request"},
+		{name: "plain error", err: context.DeadlineExceeded, wantType: "internal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			s := NewService(stubService{err: tt.err}, registry)
+
+			_, _ = s.GetContact(context.Background(), "user", "contact")
+
+			if got := testutil.ToFloat64(s.errors.WithLabelValues("GetContact", tt.wantType)); got != 1 {
+				t.Errorf("errors{type=%q} = %v, want 1", tt.wantType, got)
+			}
+		})
+	}
+}
+
+func TestLockCache_Contention(t *testing.T) {
+	l := NewLockCache(stubLockCache{locked: make(map[string]bool)}, prometheus.NewRegistry())
+	ctx := context.Background()
+
+	_, _ = l.Lock(ctx, "key")
+	_, _ = l.Lock(ctx, "key")
+	_ = l.Unlock(ctx, "key")
+	_, _ = l.Lock(ctx, "key")
+
+	if got := testutil.ToFloat64(l.attempts.WithLabelValues(lockResultAcquired)); got != 2 {
+		t.Errorf("acquired = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(l.attempts.WithLabelValues(lockResultContended)); got != 1 {
+		t.Errorf("contended = %v, want 1", got)
+	}
+}
+
+func TestCacheCollector(t *testing.T) {
+	collector := NewCacheCollector(func() inmem.CacheStats {
+		return inmem.CacheStats{Hits: 3, Misses: 1, Size: 2, Capacity: 10}
+	})
+
+	expected := `
+# HELP contact_service_cache_hits_total Cache lookups that found a live entry.
+# TYPE contact_service_cache_hits_total counter
+contact_service_cache_hits_total 3
+# HELP contact_service_cache_misses_total Cache lookups that found no live entry.
+# TYPE contact_service_cache_misses_total counter
+contact_service_cache_misses_total 1
+# HELP contact_service_cache_entries Entries currently held by the cache.
+# TYPE contact_service_cache_entries gauge
+contact_service_cache_entries 2
+`
+	err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"contact_service_cache_hits_total", "contact_service_cache_misses_total", "contact_service_cache_entries")
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHTTPMiddleware_LabelsByRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	registry := prometheus.NewRegistry()
+
+	r := gin.New()
+	r.Use(NewHTTPMiddleware(registry))
+	r.GET("/users/:userID", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/unknown"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	expected := `
+# HELP contact_service_http_requests_total HTTP requests by route, method and status code.
+# TYPE contact_service_http_requests_total counter
+contact_service_http_requests_total{method="GET",route="/users/:userID",status="204"} 2
+contact_service_http_requests_total{method="GET",route="unmatched",status="404"} 1
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected), "contact_service_http_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,100 @@
+package prometheus
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"contact-service/contact"
+)
+
+type Repository interface {
+	CreateContact(context.Context, contact.Contact) error
+	GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error)
+	DeleteContact(ctx context.Context, userID string, contactID string) error
+	SearchContacts(ctx context.Context, filters contact.Filters) (contacts []contact.Contact, err error)
+	UpdateContact(context.Context, contact.Contact) error
+	IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error)
+}
+
+// repository observes the latency and the errors of every storage call
+type repository struct {
+	next     Repository
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+func NewRepository(next Repository, registerer prometheus.Registerer) *repository {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "call_duration_seconds",
+		Help:      "Repository call latency by method.",
+		Buckets:   prometheus.ExponentialBuckets(0.0005, 2, 14),
+	}, []string{"method"})
+
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "repository",
+		Name:      "errors_total",
+		Help:      "Repository calls that returned an error by method.",
+	}, []string{"method"})
+
+	registerer.MustRegister(duration, errors)
+
+	return &repository{
+		next:     next,
+		duration: duration,
+		errors:   errors,
+	}
+}
+
+func (r *repository) CreateContact(ctx context.Context, c contact.Contact) error {
+	start := time.Now()
+	err := r.next.CreateContact(ctx, c)
+	r.observe("CreateContact", start, err)
+	return err
+}
+
+func (r *repository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	start := time.Now()
+	c, err := r.next.GetContact(ctx, userID, contactID)
+	r.observe("GetContact", start, err)
+	return c, err
+}
+
+func (r *repository) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	start := time.Now()
+	err := r.next.DeleteContact(ctx, userID, contactID)
+	r.observe("DeleteContact", start, err)
+	return err
+}
+
+func (r *repository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	start := time.Now()
+	contacts, err := r.next.SearchContacts(ctx, filters)
+	r.observe("SearchContacts", start, err)
+	return contacts, err
+}
+
+func (r *repository) UpdateContact(ctx context.Context, c contact.Contact) error {
+	start := time.Now()
+	err := r.next.UpdateContact(ctx, c)
+	r.observe("UpdateContact", start, err)
+	return err
+}
+
+func (r *repository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	start := time.Now()
+	exists, err := r.next.IsPhoneExistsForUser(ctx, userID, phone)
+	r.observe("IsPhoneExistsForUser", start, err)
+	return exists, err
+}
+
+func (r *repository) observe(method string, start time.Time, err error) {
+	r.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		r.errors.WithLabelValues(method).Inc()
+	}
+}
@@ -0,0 +1,78 @@
+package prometheus
+
+import (
+	"context"
+	"infrastructure/myerror"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"contact-service/contact"
+)
+
+type Service interface {
+	CreateContact(ctx context.Context, c contact.Contact) (string, error)
+	UpdateContact(ctx context.Context, c contact.Contact) error
+	GetContact(ctx context.Context, userID, contactID string) (contact.Contact, error)
+	SearchContacts(context.Context, contact.Filters) (contacts []contact.Contact, err error)
+	DeleteContact(ctx context.Context, userID, contactID string) error
+}
+
+// service counts the errors returned by each service method by their myerror type
+type service struct {
+	next   Service
+	errors *prometheus.CounterVec
+}
+
+func NewService(next Service, registerer prometheus.Registerer) *service {
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "service",
+		Name:      "errors_total",
+		Help:      "Errors returned by the service by method and error type.",
+	}, []string{"method", "type"})
+
+	registerer.MustRegister(errors)
+
+	return &service{
+		next:   next,
+		errors: errors,
+	}
+}
+
+func (s *service) CreateContact(ctx context.Context, c contact.Contact) (string, error) {
+	id, err := s.next.CreateContact(ctx, c)
+	s.observe("CreateContact", err)
+	return id, err
+}
+
+func (s *service) UpdateContact(ctx context.Context, c contact.Contact) error {
+	err := s.next.UpdateContact(ctx, c)
+	s.observe("UpdateContact", err)
+	return err
+}
+
+func (s *service) GetContact(ctx context.Context, userID, contactID string) (contact.Contact, error) {
+	c, err := s.next.GetContact(ctx, userID, contactID)
+	s.observe("GetContact", err)
+	return c, err
+}
+
+func (s *service) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	contacts, err := s.next.SearchContacts(ctx, filters)
+	s.observe("SearchContacts", err)
+	return contacts, err
+}
+
+func (s *service) DeleteContact(ctx context.Context, userID, contactID string) error {
+	err := s.next.DeleteContact(ctx, userID, contactID)
+	s.observe("DeleteContact", err)
+	return err
+}
+
+func (s *service) observe(method string, err error) {
+	if err == nil {
+		return
+	}
+
+	s.errors.WithLabelValues(method, myerror.GetParsedError(err).Type.String()).Inc()
+}
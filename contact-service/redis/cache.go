@@ -0,0 +1,201 @@
+package redis
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"infrastructure/myerror"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"contact-service/contact"
+)
+
+const (
+	contactKeyPrefix    = "contact:"
+	invalidationChannel = "contact:invalidations"
+)
+
+type Repository interface {
+	CreateContact(context.Context, contact.Contact) error
+	GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error)
+	DeleteContact(ctx context.Context, userID string, contactID string) error
+	SearchContacts(ctx context.Context, filters contact.Filters) (contacts []contact.Contact, err error)
+	UpdateContact(context.Context, contact.Contact) error
+	IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error)
+}
+
+// InvalidateFunc is called for every contact changed through any replica's cache
+type InvalidateFunc func(ctx context.Context, userID, contactID string)
+
+// cacheRepository is a read-through cache over a Repository shared by all replicas. Updates and deletes drop the
+// cached entry and publish the `userID:contactID` key, so replicas can evict their own local copies. A Redis
+// failure is logged and served from the underlying repository, the cache never fails a request on its own.
+type cacheRepository struct {
+	repo   Repository
+	client redis.UniversalClient
+	ttl    time.Duration
+	logger Logger
+}
+
+func NewCacheRepository(repo Repository, client redis.UniversalClient, ttl time.Duration, logger Logger) *cacheRepository {
+	return &cacheRepository{
+		repo:   repo,
+		client: client,
+		ttl:    ttl,
+		logger: logger,
+	}
+}
+
+func (r *cacheRepository) CreateContact(ctx context.Context, c contact.Contact) error {
+	if err := r.repo.CreateContact(ctx, c); err != nil {
+		return myerror.Wrap(err, "redis.CreateContact")
+	}
+
+	r.set(ctx, c)
+
+	// a new contact changes the user's search results cached by other replicas
+	r.publish(ctx, c.UserID, c.ID)
+
+	return nil
+}
+
+func (r *cacheRepository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	data, err := r.client.Get(ctx, contactKeyPrefix+getCacheKey(userID, contactID)).Bytes()
+	switch {
+	case err == nil:
+		var c contact.Contact
+		if err := json.Unmarshal(data, &c); err == nil {
+			return c, nil
+		}
+		r.logger.Warning(ctx, myerror.Wrap(err, "redis.GetContact: failed to decode cached contact"))
+	case !errors.Is(err, redis.Nil):
+		r.logger.Warning(ctx, myerror.Wrap(err, "redis.GetContact"))
+	}
+
+	c, err := r.repo.GetContact(ctx, userID, contactID)
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "redis.GetContact")
+	}
+
+	r.set(ctx, c)
+
+	return c, nil
+}
+
+func (r *cacheRepository) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	if err := r.repo.DeleteContact(ctx, userID, contactID); err != nil {
+		return myerror.Wrap(err, "redis.DeleteContact")
+	}
+
+	r.invalidate(ctx, userID, contactID)
+
+	return nil
+}
+
+// SearchContacts is not cached
+func (r *cacheRepository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	contacts, err := r.repo.SearchContacts(ctx, filters)
+	if err != nil {
+		return nil, myerror.Wrap(err, "redis.SearchContacts")
+	}
+
+	return contacts, nil
+}
+
+func (r *cacheRepository) UpdateContact(ctx context.Context, c contact.Contact) error {
+	if err := r.repo.UpdateContact(ctx, c); err != nil {
+		return myerror.Wrap(err, "redis.UpdateContact")
+	}
+
+	r.invalidate(ctx, c.UserID, c.ID)
+
+	return nil
+}
+
+// IsPhoneExistsForUser is not cached
+func (r *cacheRepository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	exists, err := r.repo.IsPhoneExistsForUser(ctx, userID, phone)
+	if err != nil {
+		return false, myerror.Wrap(err, "redis.IsPhoneExistsForUser")
+	}
+
+	return exists, nil
+}
+
+func (r *cacheRepository) HealthCheck(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return myerror.Wrap(err, "redis.HealthCheck")
+	}
+
+	return nil
+}
+
+// SubscribeInvalidations calls onInvalidate for every invalidation published by any replica until ctx is done
+func SubscribeInvalidations(ctx context.Context, client redis.UniversalClient, logger Logger, onInvalidate InvalidateFunc) error {
+	pubsub := client.Subscribe(ctx, invalidationChannel)
+
+	// wait for the subscription to be confirmed so no invalidation published after Subscribe returns is missed
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return myerror.Wrap(err, "redis.SubscribeInvalidations")
+	}
+
+	go func() {
+		defer pubsub.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-pubsub.Channel():
+				if !ok {
+					return
+				}
+
+				idx := strings.LastIndex(msg.Payload, ":")
+				if idx < 0 {
+					logger.Warning(ctx, myerror.NewInternalError("redis.SubscribeInvalidations: malformed invalidation %q", msg.Payload))
+					continue
+				}
+
+				onInvalidate(ctx, msg.Payload[:idx], msg.Payload[idx+1:])
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (r *cacheRepository) set(ctx context.Context, c contact.Contact) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		r.logger.Warning(ctx, myerror.Wrap(err, "redis.set"))
+		return
+	}
+
+	if err := r.client.Set(ctx, contactKeyPrefix+getCacheKey(c.UserID, c.ID), data, r.ttl).Err(); err != nil {
+		r.logger.Warning(ctx, myerror.Wrap(err, "redis.set"))
+	}
+}
+
+func (r *cacheRepository) invalidate(ctx context.Context, userID, contactID string) {
+	if err := r.client.Del(ctx, contactKeyPrefix+getCacheKey(userID, contactID)).Err(); err != nil {
+		r.logger.Warning(ctx, myerror.Wrap(err, "redis.invalidate"))
+	}
+
+	r.publish(ctx, userID, contactID)
+}
+
+func (r *cacheRepository) publish(ctx context.Context, userID, contactID string) {
+	if err := r.client.Publish(ctx, invalidationChannel, getCacheKey(userID, contactID)).Err(); err != nil {
+		r.logger.Warning(ctx, myerror.Wrap(err, "redis.publish"))
+	}
+}
+
+func getCacheKey(userID, contactID string) string {
+	return fmt.Sprintf("%s:%s", userID, contactID)
+}
@@ -0,0 +1,73 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"contact-service/contact"
+	"contact-service/inmem"
+	"contact-service/stdout"
+)
+
+func Test_cacheRepository_Invalidation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+
+	logger := stdout.NewLogger()
+	repo := inmem.NewUserRepository()
+
+	// two replicas sharing the same store and Redis, each with its own local LRU in front
+	replicaA := NewCacheRepository(repo, client, time.Minute, logger)
+	replicaB := NewCacheRepository(repo, client, time.Minute, logger)
+	localB := inmem.NewLRUCacheRepository(replicaB, 5, time.Minute, 0, logger)
+
+	c := contact.Contact{UserID: "1", ID: "a", Phone: "111"}
+	if err := replicaA.CreateContact(ctx, c); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+	if !mr.Exists(contactKeyPrefix + "1:a") {
+		t.Fatalf("CreateContact() did not populate the shared cache")
+	}
+
+	invalidated := make(chan string, 1)
+	if err := SubscribeInvalidations(ctx, client, logger, func(ctx context.Context, userID, contactID string) {
+		localB.Invalidate(ctx, userID, contactID)
+		invalidated <- getCacheKey(userID, contactID)
+	}); err != nil {
+		t.Fatalf("SubscribeInvalidations() error = %v", err)
+	}
+
+	if _, err := localB.GetContact(ctx, c.UserID, c.ID); err != nil {
+		t.Fatalf("GetContact() error = %v", err)
+	}
+
+	c.Phone = "222"
+	if err := replicaA.UpdateContact(ctx, c); err != nil {
+		t.Fatalf("UpdateContact() error = %v", err)
+	}
+
+	select {
+	case key := <-invalidated:
+		if key != "1:a" {
+			t.Errorf("invalidated key = %s, want 1:a", key)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("no invalidation received")
+	}
+
+	got, err := localB.GetContact(ctx, c.UserID, c.ID)
+	if err != nil {
+		t.Fatalf("GetContact() error = %v", err)
+	}
+	if got.Phone != "222" {
+		t.Errorf("GetContact() phone = %s, want 222", got.Phone)
+	}
+}
@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"infrastructure/myerror"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const lockKeyPrefix = "lock:"
+
+// renewScript extends the lease only if it is still held by the caller
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0
+`)
+
+// unlockScript deletes the lease only if it is still held by the caller, so an expired lease taken over by
+// another replica is never released by mistake
+var unlockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+type Logger interface {
+	Info(ctx context.Context, msg string, keyvals ...interface{})
+	Error(ctx context.Context, err error, keyvals ...interface{})
+	Warning(ctx context.Context, err error, keyvals ...interface{})
+	Debug(ctx context.Context, msg string, keyvals ...interface{})
+}
+
+type lease struct {
+	value       string
+	stopRenewal context.CancelFunc
+}
+
+// lockCache is a distributed lock backed by Redis leases. A lease expires after ttl unless it is renewed, so a
+// crashed holder never keeps a key locked forever.
+type lockCache struct {
+	client redis.UniversalClient
+	ttl    time.Duration
+	logger Logger
+
+	mutex  sync.Mutex
+	leases map[string]*lease
+}
+
+// NewLockCache requires a ttl of at least a millisecond, the precision of Redis leases
+func NewLockCache(client redis.UniversalClient, ttl time.Duration, logger Logger) (*lockCache, error) {
+	if ttl < time.Millisecond {
+		return nil, myerror.NewBadRequestError("redis.NewLockCache: lock ttl %s is shorter than a millisecond", ttl)
+	}
+
+	return &lockCache{
+		client: client,
+		ttl:    ttl,
+		logger: logger,
+		leases: make(map[string]*lease),
+	}, nil
+}
+
+func (c *lockCache) Lock(ctx context.Context, key string) (bool, error) {
+	value := uuid.New().String()
+
+	ok, err := c.client.SetNX(ctx, lockKeyPrefix+key, value, c.ttl).Result()
+	if err != nil {
+		return false, myerror.Wrap(err, "redis.Lock")
+	}
+	if !ok {
+		return false, nil
+	}
+
+	// the renewal outlives the request context on purpose, it is stopped by Unlock
+	renewCtx, stopRenewal := context.WithCancel(context.Background())
+
+	c.mutex.Lock()
+	c.leases[key] = &lease{
+		value:       value,
+		stopRenewal: stopRenewal,
+	}
+	c.mutex.Unlock()
+
+	go c.renew(renewCtx, key, value)
+
+	return true, nil
+}
+
+func (c *lockCache) Unlock(ctx context.Context, key string) error {
+	c.mutex.Lock()
+	l, ok := c.leases[key]
+	delete(c.leases, key)
+	c.mutex.Unlock()
+
+	if !ok {
+		return myerror.NewInternalError("redis.Unlock: key %s is not locked by this instance", key)
+	}
+
+	l.stopRenewal()
+
+	if err := c.release(ctx, key, l.value); err != nil {
+		return myerror.Wrap(err, "redis.Unlock")
+	}
+
+	return nil
+}
+
+// Close stops renewing and releases every lease still held by this instance
+func (c *lockCache) Close(ctx context.Context) error {
+	c.mutex.Lock()
+	leases := c.leases
+	c.leases = make(map[string]*lease)
+	c.mutex.Unlock()
+
+	var errs []error
+	for key, l := range leases {
+		l.stopRenewal()
+		if err := c.release(ctx, key, l.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return myerror.Wrap(err, "redis.Close")
+	}
+
+	return nil
+}
+
+func (c *lockCache) HealthCheck(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return myerror.Wrap(err, "redis.HealthCheck")
+	}
+
+	return nil
+}
+
+func (c *lockCache) release(ctx context.Context, key, value string) error {
+	released, err := unlockScript.Run(ctx, c.client, []string{lockKeyPrefix + key}, value).Int()
+	if err != nil {
+		return myerror.Wrap(err, "release")
+	}
+	if released == 0 {
+		return myerror.NewInternalError("release: lease for key %s expired before it was released", key)
+	}
+
+	return nil
+}
+
+// renew keeps extending the lease at a third of its ttl until the lease is released or lost
+func (c *lockCache) renew(ctx context.Context, key, value string) {
+	ticker := time.NewTicker(c.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			renewed, err := renewScript.Run(ctx, c.client, []string{lockKeyPrefix + key}, value, c.ttl.Milliseconds()).Int()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				c.logger.Warning(ctx, myerror.Wrap(err, "redis.renew: failed to renew lease"), "key", key)
+				continue
+			}
+			if renewed == 0 {
+				c.logger.Warning(ctx, myerror.NewInternalError("redis.renew: lease for key %s was lost", key))
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package redis
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"contact-service/stdout"
+)
+
+func newTestLockCache(t *testing.T, ttl time.Duration) (*lockCache, *miniredis.Miniredis) {
+	t.Helper()
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	c, err := NewLockCache(client, ttl, stdout.NewLogger())
+	if err != nil {
+		t.Fatalf("NewLockCache() error = %v", err)
+	}
+
+	return c, mr
+}
+
+func TestNewLockCache(t *testing.T) {
+	for _, ttl := range []time.Duration{0, time.Nanosecond, time.Millisecond - 1} {
+		if _, err := NewLockCache(nil, ttl, stdout.NewLogger()); err == nil {
+			t.Errorf("NewLockCache() with ttl %s expected an error", ttl)
+		}
+	}
+}
+
+func Test_lockCache_LockUnlock(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestLockCache(t, time.Minute)
+
+	ok, err := c.Lock(ctx, "key")
+	if err != nil || !ok {
+		t.Fatalf("Lock() = %v, %v, want true", ok, err)
+	}
+
+	ok, err = c.Lock(ctx, "key")
+	if err != nil || ok {
+		t.Fatalf("Lock() on a held key = %v, %v, want false", ok, err)
+	}
+
+	if err := c.Unlock(ctx, "key"); err != nil {
+		t.Fatalf("Unlock() error = %v", err)
+	}
+
+	ok, err = c.Lock(ctx, "key")
+	if err != nil || !ok {
+		t.Fatalf("Lock() after Unlock() = %v, %v, want true", ok, err)
+	}
+	defer c.Unlock(ctx, "key")
+}
+
+func Test_lockCache_LeaseExpiry(t *testing.T) {
+	ctx := context.Background()
+	c, mr := newTestLockCache(t, time.Minute)
+
+	// another replica whose lease expired must not be able to release the new holder's lease
+	crashed, err := NewLockCache(c.client, time.Minute, stdout.NewLogger())
+	if err != nil {
+		t.Fatalf("NewLockCache() error = %v", err)
+	}
+	if ok, err := crashed.Lock(ctx, "key"); err != nil || !ok {
+		t.Fatalf("Lock() = %v, %v, want true", ok, err)
+	}
+	crashed.leases["key"].stopRenewal()
+
+	mr.FastForward(2 * time.Minute)
+
+	ok, err := c.Lock(ctx, "key")
+	if err != nil || !ok {
+		t.Fatalf("Lock() after lease expiry = %v, %v, want true", ok, err)
+	}
+
+	if err := crashed.Unlock(ctx, "key"); err == nil {
+		t.Errorf("Unlock() of an expired lease expected an error")
+	}
+
+	if ok, _ := c.Lock(ctx, "key"); ok {
+		t.Errorf("Lock() succeeded while the lease is held by another instance")
+	}
+}
+
+func Test_lockCache_Renewal(t *testing.T) {
+	ctx := context.Background()
+	ttl := 300 * time.Millisecond
+	c, mr := newTestLockCache(t, ttl)
+
+	if ok, err := c.Lock(ctx, "key"); err != nil || !ok {
+		t.Fatalf("Lock() = %v, %v, want true", ok, err)
+	}
+	defer c.Unlock(ctx, "key")
+
+	mr.FastForward(250 * time.Millisecond)
+	time.Sleep(ttl / 2)
+
+	if remaining := mr.TTL(lockKeyPrefix + "key"); remaining <= 50*time.Millisecond {
+		t.Errorf("lease ttl = %s after renewal, want it extended", remaining)
+	}
+}
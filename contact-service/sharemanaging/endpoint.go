@@ -0,0 +1,126 @@
+package sharemanaging
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"strings"
+
+	"contact-service/contact"
+)
+
+type Service interface {
+	ShareContact(ctx context.Context, share contact.Share) (contact.Share, error)
+	RevokeShare(ctx context.Context, ownerID, shareID string) error
+	ListShares(ctx context.Context, ownerID string) ([]contact.Share, error)
+	ListSharedWith(ctx context.Context, granteeID string) ([]contact.Share, error)
+}
+
+// Share
+
+type shareContactRequest struct {
+	OwnerID    string
+	ContactID  string
+	GranteeID  string
+	Permission string
+}
+
+func (r shareContactRequest) Validate() error {
+	var errorMessages []string
+
+	if r.OwnerID == "" {
+		errorMessages = append(errorMessages, "userID is required")
+	}
+
+	if r.GranteeID == "" {
+		errorMessages = append(errorMessages, "granteeID is required")
+	}
+
+	if r.Permission != contact.PermissionRead && r.Permission != contact.PermissionEdit {
+		errorMessages = append(errorMessages, "permission must be read or edit")
+	}
+
+	if len(errorMessages) > 0 {
+		return myerror.NewBadRequestError("invalid request: %s", strings.Join(errorMessages, ", "))
+	}
+
+	return nil
+}
+
+func (r shareContactRequest) ToShare() contact.Share {
+	return contact.Share{
+		OwnerID:    r.OwnerID,
+		ContactID:  r.ContactID,
+		GranteeID:  r.GranteeID,
+		Permission: r.Permission,
+	}
+}
+
+func endpointShareContact(ctx context.Context, s Service, request shareContactRequest) (contact.Share, error) {
+	if err := request.Validate(); err != nil {
+		return contact.Share{}, myerror.Wrap(err, "endpointShareContact")
+	}
+
+	share, err := s.ShareContact(ctx, request.ToShare())
+	if err != nil {
+		return contact.Share{}, myerror.Wrap(err, "endpointShareContact")
+	}
+
+	return share, nil
+}
+
+// Revoke
+
+type revokeShareRequest struct {
+	OwnerID string
+	ShareID string
+}
+
+func (r revokeShareRequest) Validate() error {
+	var errorMessages []string
+
+	if r.OwnerID == "" {
+		errorMessages = append(errorMessages, "userID is required")
+	}
+
+	if r.ShareID == "" {
+		errorMessages = append(errorMessages, "shareID is required")
+	}
+
+	if len(errorMessages) > 0 {
+		return myerror.NewBadRequestError("invalid request: %s", strings.Join(errorMessages, ", "))
+	}
+
+	return nil
+}
+
+func endpointRevokeShare(ctx context.Context, s Service, request revokeShareRequest) error {
+	if err := request.Validate(); err != nil {
+		return myerror.Wrap(err, "endpointRevokeShare")
+	}
+
+	if err := s.RevokeShare(ctx, request.OwnerID, request.ShareID); err != nil {
+		return myerror.Wrap(err, "endpointRevokeShare")
+	}
+
+	return nil
+}
+
+// List
+
+func endpointListShares(ctx context.Context, s Service, ownerID string) ([]contact.Share, error) {
+	shares, err := s.ListShares(ctx, ownerID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "endpointListShares")
+	}
+
+	return shares, nil
+}
+
+func endpointListSharedWith(ctx context.Context, s Service, granteeID string) ([]contact.Share, error) {
+	shares, err := s.ListSharedWith(ctx, granteeID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "endpointListSharedWith")
+	}
+
+	return shares, nil
+}
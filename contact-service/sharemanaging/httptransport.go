@@ -0,0 +1,129 @@
+package sharemanaging
+
+import (
+	"infrastructure/myerror"
+	"infrastructure/myhttp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/apikey"
+	"contact-service/contact"
+	"contact-service/contactmanaging"
+)
+
+const (
+	shareContactURL   = "/users/:userID/shares"
+	listSharesURL     = "/users/:userID/shares"
+	revokeShareURL    = "/users/:userID/shares/:shareID"
+	listSharedWithURL = "/users/:userID/shared"
+)
+
+// RegisterHTTPEndpoints adds the sharing routes to r, each user managing only their own shares
+func RegisterHTTPEndpoints(r gin.IRouter, s Service) {
+	r.POST(shareContactURL, contactmanaging.RequireScope(apikey.ScopeWrite), contactmanaging.AuthorizeUser(), makeHTTPEndpointShareContact(s))
+	r.GET(listSharesURL, contactmanaging.RequireScope(apikey.ScopeRead), contactmanaging.AuthorizeUser(), makeHTTPEndpointListShares(s))
+	r.DELETE(revokeShareURL, contactmanaging.RequireScope(apikey.ScopeWrite), contactmanaging.AuthorizeUser(), makeHTTPEndpointRevokeShare(s))
+	r.GET(listSharedWithURL, contactmanaging.RequireScope(apikey.ScopeRead), contactmanaging.AuthorizeUser(), makeHTTPEndpointListSharedWith(s))
+}
+
+type shareHTTPResponse struct {
+	ID         string    `json:"id"`
+	OwnerID    string    `json:"ownerID"`
+	ContactID  string    `json:"contactID,omitempty"`
+	GranteeID  string    `json:"granteeID"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type sharesHTTPResponse struct {
+	Shares []shareHTTPResponse `json:"shares"`
+}
+
+// Share
+type shareContactHTTPRequest struct {
+	ContactID  string `json:"contactID"`
+	GranteeID  string `json:"granteeID"`
+	Permission string `json:"permission"`
+}
+
+func makeHTTPEndpointShareContact(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req shareContactHTTPRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			myhttp.EncodeJSONError(c, myerror.NewBadRequestError("makeHTTPEndpointShareContact: %v", err))
+			return
+		}
+
+		share, err := endpointShareContact(c, s, shareContactRequest{
+			OwnerID:    c.Param("userID"),
+			ContactID:  req.ContactID,
+			GranteeID:  req.GranteeID,
+			Permission: req.Permission,
+		})
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		myhttp.EncodeJSONSuccess(c, shareToJSON(share))
+	}
+}
+
+// Revoke
+func makeHTTPEndpointRevokeShare(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		err := endpointRevokeShare(c, s, revokeShareRequest{
+			OwnerID: c.Param("userID"),
+			ShareID: c.Param("shareID"),
+		})
+		if err != nil {
+			myhttp.EncodeJSONError(c, err)
+			return
+		}
+
+		myhttp.EncodeJSONSuccess(c, struct{}{})
+	}
+}
+
+// List
+func makeHTTPEndpointListShares(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shares, err := endpointListShares(c, s, c.Param("userID"))
+		encodeSharesResponse(c, shares, err)
+	}
+}
+
+func makeHTTPEndpointListSharedWith(s Service) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		shares, err := endpointListSharedWith(c, s, c.Param("userID"))
+		encodeSharesResponse(c, shares, err)
+	}
+}
+
+func encodeSharesResponse(c *gin.Context, shares []contact.Share, err error) {
+	if err != nil {
+		myhttp.EncodeJSONError(c, err)
+		return
+	}
+
+	jsonResponse := sharesHTTPResponse{
+		Shares: make([]shareHTTPResponse, 0, len(shares)),
+	}
+	for _, share := range shares {
+		jsonResponse.Shares = append(jsonResponse.Shares, shareToJSON(share))
+	}
+
+	myhttp.EncodeJSONSuccess(c, jsonResponse)
+}
+
+func shareToJSON(share contact.Share) shareHTTPResponse {
+	return shareHTTPResponse{
+		ID:         share.ID,
+		OwnerID:    share.OwnerID,
+		ContactID:  share.ContactID,
+		GranteeID:  share.GranteeID,
+		Permission: share.Permission,
+		CreatedAt:  share.CreatedAt,
+	}
+}
@@ -0,0 +1,82 @@
+package sharemanaging
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/contact"
+	"contact-service/testutil"
+)
+
+// updatedAt is the last update of the contacts of the test handler, which updates must send back
+var updatedAt = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+var (
+	readContactShare = contact.Share{ID: "s1", OwnerID: "owner", ContactID: "c1", GranteeID: "guest", Permission: contact.PermissionRead}
+	readBookShare    = contact.Share{ID: "s2", OwnerID: "owner", GranteeID: "guest", Permission: contact.PermissionRead}
+	editBookShare    = contact.Share{ID: "s3", OwnerID: "owner", GranteeID: "guest", Permission: contact.PermissionEdit}
+)
+
+// newTestHandler serves the contacts and share routes over the contacts c1 and c2 of owner, shared by the shares
+func newTestHandler(t *testing.T, shares ...contact.Share) http.Handler {
+	t.Helper()
+
+	s := testutil.NewStores(t, testutil.Fixture{
+		Contacts: []contact.Contact{
+			{UserID: "owner", ID: "c1", Phone: "123", FirstName: "a", LastName: "b", Address: "c", CreatedAt: updatedAt, UpdatedAt: updatedAt},
+			{UserID: "owner", ID: "c2", Phone: "456", FirstName: "d", LastName: "e", Address: "f", CreatedAt: updatedAt, UpdatedAt: updatedAt},
+		},
+		Shares: shares,
+	})
+
+	return testutil.NewHandler(s, []func(gin.IRouter){
+		func(r gin.IRouter) { RegisterHTTPEndpoints(r, NewService(s.Shares, s.Contacts)) },
+	}, nil)
+}
+
+func TestRegisterHTTPEndpoints(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	update := fmt.Sprintf(`{"phone":"789","firstName":"x","lastName":"y","address":"z","updatedAt":%q}`, updatedAt.Format(time.RFC3339Nano))
+
+	tests := []struct {
+		name       string
+		shares     []contact.Share
+		user       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{name: "get without a share", user: "guest", method: http.MethodGet, path: "/users/owner/contacts/c1", body: "{}", wantStatus: http.StatusForbidden},
+		{name: "grantee reads shared contact", shares: []contact.Share{readContactShare}, user: "guest", method: http.MethodGet, path: "/users/owner/contacts/c1", body: "{}", wantStatus: http.StatusOK},
+		{name: "grantee cannot read other contact", shares: []contact.Share{readContactShare}, user: "guest", method: http.MethodGet, path: "/users/owner/contacts/c2", body: "{}", wantStatus: http.StatusForbidden},
+		{name: "reader cannot update", shares: []contact.Share{readContactShare}, user: "guest", method: http.MethodPut, path: "/users/owner/contacts/c1", body: update, wantStatus: http.StatusForbidden},
+		{name: "grantee of a contact cannot search owner's contacts", shares: []contact.Share{readContactShare}, user: "guest", method: http.MethodGet, path: "/users/owner/contacts", wantStatus: http.StatusForbidden},
+		{name: "grantee of the whole book searches owner's contacts", shares: []contact.Share{readBookShare}, user: "guest", method: http.MethodGet, path: "/users/owner/contacts", wantStatus: http.StatusOK},
+		{name: "editor of the whole book updates any contact", shares: []contact.Share{editBookShare}, user: "guest", method: http.MethodPut, path: "/users/owner/contacts/c2", body: update, wantStatus: http.StatusOK},
+		{name: "grantee cannot delete", shares: []contact.Share{editBookShare}, user: "guest", method: http.MethodDelete, path: "/users/owner/contacts/c1", wantStatus: http.StatusForbidden},
+		{name: "owner shares a contact", user: "owner", method: http.MethodPost, path: "/users/owner/shares", body: `{"contactID":"c1","granteeID":"guest","permission":"read"}`, wantStatus: http.StatusOK},
+		{name: "owner shares the whole book", user: "owner", method: http.MethodPost, path: "/users/owner/shares", body: `{"granteeID":"guest","permission":"edit"}`, wantStatus: http.StatusOK},
+		{name: "sharing with oneself", user: "owner", method: http.MethodPost, path: "/users/owner/shares", body: `{"granteeID":"owner","permission":"read"}`, wantStatus: http.StatusBadRequest},
+		{name: "sharing a missing contact", user: "owner", method: http.MethodPost, path: "/users/owner/shares", body: `{"contactID":"missing","granteeID":"guest","permission":"read"}`, wantStatus: http.StatusNotFound},
+		{name: "grantee cannot share owner's contacts", shares: []contact.Share{editBookShare}, user: "guest", method: http.MethodPost, path: "/users/owner/shares", body: `{"granteeID":"other","permission":"read"}`, wantStatus: http.StatusForbidden},
+		{name: "grantee cannot list owner's shares", shares: []contact.Share{readContactShare}, user: "guest", method: http.MethodGet, path: "/users/owner/shares", wantStatus: http.StatusForbidden},
+		{name: "grantee lists shares granted to them", shares: []contact.Share{readContactShare}, user: "guest", method: http.MethodGet, path: "/users/guest/shared", wantStatus: http.StatusOK},
+		{name: "owner revokes share", shares: []contact.Share{readContactShare}, user: "owner", method: http.MethodDelete, path: "/users/owner/shares/s1", wantStatus: http.StatusOK},
+		{name: "revoking a missing share", user: "owner", method: http.MethodDelete, path: "/users/owner/shares/s1", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestHandler(t, tt.shares...)
+			if rec := testutil.Do(handler, tt.user, tt.method, tt.path, tt.body); rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+}
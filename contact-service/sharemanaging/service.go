@@ -0,0 +1,87 @@
+package sharemanaging
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"time"
+
+	"github.com/google/uuid"
+
+	"contact-service/contact"
+)
+
+type Repository interface {
+	SaveShare(context.Context, contact.Share) (contact.Share, error)
+	DeleteShare(ctx context.Context, ownerID, shareID string) error
+	ListSharesByOwner(ctx context.Context, ownerID string) ([]contact.Share, error)
+	ListSharesByGrantee(ctx context.Context, granteeID string) ([]contact.Share, error)
+}
+
+// ContactRepository finds the contacts being shared
+type ContactRepository interface {
+	GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error)
+}
+
+type service struct {
+	repo     Repository
+	contacts ContactRepository
+}
+
+func NewService(repo Repository, contacts ContactRepository) *service {
+	return &service{
+		repo:     repo,
+		contacts: contacts,
+	}
+}
+
+// ShareContact grants the grantee a permission on a contact of the owner, or on all of the owner's contacts when the
+// share has no contact ID. Sharing the same contact with the same grantee again replaces its permission.
+func (s service) ShareContact(ctx context.Context, share contact.Share) (contact.Share, error) {
+	if share.OwnerID == share.GranteeID {
+		return contact.Share{}, myerror.NewBadRequestError("service.ShareContact: contacts cannot be shared with their owner")
+	}
+
+	if share.ContactID != "" {
+		if _, err := s.contacts.GetContact(ctx, share.OwnerID, share.ContactID); err != nil {
+			return contact.Share{}, myerror.Wrap(err, "service.ShareContact")
+		}
+	}
+
+	share.ID = uuid.New().String()
+	share.CreatedAt = time.Now()
+
+	share, err := s.repo.SaveShare(ctx, share)
+	if err != nil {
+		return contact.Share{}, myerror.Wrap(err, "service.ShareContact")
+	}
+
+	return share, nil
+}
+
+func (s service) RevokeShare(ctx context.Context, ownerID, shareID string) error {
+	if err := s.repo.DeleteShare(ctx, ownerID, shareID); err != nil {
+		return myerror.Wrap(err, "service.RevokeShare")
+	}
+
+	return nil
+}
+
+// ListShares returns the shares granted by the owner
+func (s service) ListShares(ctx context.Context, ownerID string) ([]contact.Share, error) {
+	shares, err := s.repo.ListSharesByOwner(ctx, ownerID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "service.ListShares")
+	}
+
+	return shares, nil
+}
+
+// ListSharedWith returns the shares granted to the grantee by other users
+func (s service) ListSharedWith(ctx context.Context, granteeID string) ([]contact.Share, error) {
+	shares, err := s.repo.ListSharesByGrantee(ctx, granteeID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "service.ListSharedWith")
+	}
+
+	return shares, nil
+}
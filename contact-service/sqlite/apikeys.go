@@ -0,0 +1,112 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"infrastructure/myerror"
+	"strings"
+	"time"
+
+	"contact-service/apikey"
+)
+
+const keyColumns = "id, name, prefix, hash, user_id, tenant_id, scopes, created_at, expires_at, revoked_at"
+
+// scopesSeparator joins the scopes of a key in a single column, scopes are validated words
+const scopesSeparator = ","
+
+func (r *repository) CreateKey(ctx context.Context, k apikey.APIKey) error {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO api_keys (`+keyColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT (hash) DO NOTHING`,
+		k.ID, k.Name, k.Prefix, k.Hash, k.UserID, k.TenantID, strings.Join(k.Scopes, scopesSeparator),
+		k.CreatedAt.UnixNano(), toUnixNano(k.ExpiresAt), toUnixNano(k.RevokedAt),
+	)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.CreateKey")
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return myerror.Wrap(err, "sqlite.CreateKey")
+	} else if n == 0 {
+		return myerror.NewBadRequestError("sqlite.CreateKey: key already exists")
+	}
+
+	return nil
+}
+
+func (r *repository) GetKeyByHash(ctx context.Context, hash string) (apikey.APIKey, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM api_keys WHERE hash = ?`, hash)
+
+	k, err := scanKey(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return apikey.APIKey{}, myerror.NewNotFoundError("sqlite.GetKeyByHash: key not found")
+	}
+	if err != nil {
+		return apikey.APIKey{}, myerror.Wrap(err, "sqlite.GetKeyByHash")
+	}
+
+	return k, nil
+}
+
+// ListKeys returns every key, revoked and expired ones included, oldest first
+func (r *repository) ListKeys(ctx context.Context) ([]apikey.APIKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+keyColumns+` FROM api_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.ListKeys")
+	}
+	defer rows.Close()
+
+	keys := make([]apikey.APIKey, 0)
+	for rows.Next() {
+		k, err := scanKey(rows)
+		if err != nil {
+			return nil, myerror.Wrap(err, "sqlite.ListKeys")
+		}
+		keys = append(keys, k)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, myerror.Wrap(err, "sqlite.ListKeys")
+	}
+
+	return keys, nil
+}
+
+// RevokeKey keeps the first revocation time of a key revoked twice
+func (r *repository) RevokeKey(ctx context.Context, keyID string, revokedAt time.Time) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = CASE WHEN revoked_at = 0 THEN ? ELSE revoked_at END WHERE id = ?`,
+		revokedAt.UnixNano(), keyID,
+	)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.RevokeKey")
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return myerror.Wrap(err, "sqlite.RevokeKey")
+	} else if n == 0 {
+		return myerror.NewNotFoundError("sqlite.RevokeKey: key with ID %s not found", keyID)
+	}
+
+	return nil
+}
+
+func scanKey(s scanner) (apikey.APIKey, error) {
+	var (
+		k                               apikey.APIKey
+		scopes                          string
+		createdAt, expiresAt, revokedAt int64
+	)
+	if err := s.Scan(&k.ID, &k.Name, &k.Prefix, &k.Hash, &k.UserID, &k.TenantID, &scopes, &createdAt, &expiresAt, &revokedAt); err != nil {
+		return apikey.APIKey{}, err
+	}
+
+	if scopes != "" {
+		k.Scopes = strings.Split(scopes, scopesSeparator)
+	}
+	k.CreatedAt = time.Unix(0, createdAt)
+	k.ExpiresAt = fromUnixNano(expiresAt)
+	k.RevokedAt = fromUnixNano(revokedAt)
+
+	return k, nil
+}
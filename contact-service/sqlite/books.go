@@ -0,0 +1,234 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"infrastructure/myerror"
+	"time"
+
+	"contact-service/contact"
+)
+
+const (
+	bookColumns   = "id, name, owner_id, created_at"
+	memberColumns = "book_id, user_id, role, invited_at, joined_at"
+)
+
+func (r *repository) CreateBook(ctx context.Context, b contact.Book) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.CreateBook")
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO books (`+bookColumns+`) VALUES (?, ?, ?, ?)`,
+		b.ID, b.Name, b.OwnerID, b.CreatedAt.UnixNano(),
+	); err != nil {
+		return myerror.Wrap(err, "sqlite.CreateBook")
+	}
+
+	for _, m := range b.Members {
+		if err := saveMember(ctx, tx, b.ID, m); err != nil {
+			return myerror.Wrap(err, "sqlite.CreateBook")
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return myerror.Wrap(err, "sqlite.CreateBook")
+	}
+
+	return nil
+}
+
+func (r *repository) GetBook(ctx context.Context, bookID string) (contact.Book, error) {
+	books, err := r.queryBooks(ctx, `WHERE id = ?`, bookID)
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "sqlite.GetBook")
+	}
+	if len(books) == 0 {
+		return contact.Book{}, myerror.NewNotFoundError("sqlite.GetBook: book with ID %s not found", bookID)
+	}
+
+	return books[0], nil
+}
+
+// ListBooksByMember returns the books the user owns, belongs to or is invited to, oldest first
+func (r *repository) ListBooksByMember(ctx context.Context, userID string) ([]contact.Book, error) {
+	books, err := r.queryBooks(ctx, `WHERE id IN (SELECT book_id FROM book_members WHERE user_id = ?)`, userID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.ListBooksByMember")
+	}
+
+	return books, nil
+}
+
+// SaveMember adds the member to the book, or replaces the membership of the same user
+func (r *repository) SaveMember(ctx context.Context, bookID string, m contact.Member) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.SaveMember")
+	}
+	defer tx.Rollback()
+
+	if err := bookExists(ctx, tx, bookID); err != nil {
+		return myerror.Wrap(err, "sqlite.SaveMember")
+	}
+
+	if err := saveMember(ctx, tx, bookID, m); err != nil {
+		return myerror.Wrap(err, "sqlite.SaveMember")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return myerror.Wrap(err, "sqlite.SaveMember")
+	}
+
+	return nil
+}
+
+func (r *repository) DeleteMember(ctx context.Context, bookID, userID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteMember")
+	}
+	defer tx.Rollback()
+
+	if err := bookExists(ctx, tx, bookID); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteMember")
+	}
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM book_members WHERE book_id = ? AND user_id = ?`, bookID, userID)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteMember")
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteMember")
+	} else if n == 0 {
+		return myerror.NewNotFoundError("sqlite.DeleteMember: user %s is not a member of book %s", userID, bookID)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteMember")
+	}
+
+	return nil
+}
+
+// DeleteBook drops the book and its memberships, its contacts are stored with the contacts of the users
+func (r *repository) DeleteBook(ctx context.Context, bookID string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteBook")
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM books WHERE id = ?`, bookID)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteBook")
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteBook")
+	} else if n == 0 {
+		return myerror.NewNotFoundError("sqlite.DeleteBook: book with ID %s not found", bookID)
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM book_members WHERE book_id = ?`, bookID); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteBook")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteBook")
+	}
+
+	return nil
+}
+
+// queryBooks returns the books matching the where clause oldest first, with their members in the order they were
+// saved
+func (r *repository) queryBooks(ctx context.Context, where string, args ...interface{}) ([]contact.Book, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+bookColumns+` FROM books `+where+` ORDER BY created_at, id`, args...)
+	if err != nil {
+		return nil, myerror.Wrap(err, "queryBooks")
+	}
+	defer rows.Close()
+
+	books := make([]contact.Book, 0)
+	for rows.Next() {
+		var (
+			b         contact.Book
+			createdAt int64
+		)
+		if err := rows.Scan(&b.ID, &b.Name, &b.OwnerID, &createdAt); err != nil {
+			return nil, myerror.Wrap(err, "queryBooks")
+		}
+		b.CreatedAt = time.Unix(0, createdAt)
+		books = append(books, b)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, myerror.Wrap(err, "queryBooks")
+	}
+	rows.Close()
+
+	// the connection pool holds a single connection, so the members are read once the books rows are closed
+	for i := range books {
+		members, err := r.queryMembers(ctx, books[i].ID)
+		if err != nil {
+			return nil, myerror.Wrap(err, "queryBooks")
+		}
+		books[i].Members = members
+	}
+
+	return books, nil
+}
+
+func (r *repository) queryMembers(ctx context.Context, bookID string) ([]contact.Member, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT user_id, role, invited_at, joined_at FROM book_members WHERE book_id = ? ORDER BY rowid`, bookID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "queryMembers")
+	}
+	defer rows.Close()
+
+	var members []contact.Member
+	for rows.Next() {
+		var (
+			m                   contact.Member
+			invitedAt, joinedAt int64
+		)
+		if err := rows.Scan(&m.UserID, &m.Role, &invitedAt, &joinedAt); err != nil {
+			return nil, myerror.Wrap(err, "queryMembers")
+		}
+		m.InvitedAt = fromUnixNano(invitedAt)
+		m.JoinedAt = fromUnixNano(joinedAt)
+		members = append(members, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, myerror.Wrap(err, "queryMembers")
+	}
+
+	return members, nil
+}
+
+func bookExists(ctx context.Context, tx *sql.Tx, bookID string) error {
+	err := tx.QueryRowContext(ctx, `SELECT id FROM books WHERE id = ?`, bookID).Scan(new(string))
+	if errors.Is(err, sql.ErrNoRows) {
+		return myerror.NewNotFoundError("bookExists: book with ID %s not found", bookID)
+	}
+	if err != nil {
+		return myerror.Wrap(err, "bookExists")
+	}
+
+	return nil
+}
+
+// saveMember replaces the membership of the same user, which moves the member after the others as in memory
+func saveMember(ctx context.Context, tx *sql.Tx, bookID string, m contact.Member) error {
+	if _, err := tx.ExecContext(ctx,
+		`INSERT OR REPLACE INTO book_members (`+memberColumns+`) VALUES (?, ?, ?, ?, ?)`,
+		bookID, m.UserID, m.Role, toUnixNano(m.InvitedAt), toUnixNano(m.JoinedAt),
+	); err != nil {
+		return myerror.Wrap(err, "saveMember")
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"infrastructure/myerror"
+)
+
+type migration struct {
+	version     int
+	description string
+	statements  []string
+}
+
+// migrations are applied in order and must never be edited once released; add a new version instead
+var migrations = []migration{
+	{
+		version:     1,
+		description: "create contacts table",
+		statements: []string{
+			`CREATE TABLE contacts (
+				user_id    TEXT    NOT NULL,
+				id         TEXT    NOT NULL,
+				phone      TEXT    NOT NULL,
+				first_name TEXT    NOT NULL,
+				last_name  TEXT    NOT NULL,
+				address    TEXT    NOT NULL,
+				created_at INTEGER NOT NULL,
+				updated_at INTEGER NOT NULL,
+				PRIMARY KEY (user_id, id)
+			)`,
+		},
+	},
+	{
+		version:     2,
+		description: "index contacts by user phone and user first name",
+		statements: []string{
+			`CREATE INDEX idx_contacts_user_phone ON contacts (user_id, phone)`,
+			`CREATE INDEX idx_contacts_user_first_name ON contacts (user_id, first_name)`,
+		},
+	},
+	{
+		version:     3,
+		description: "add the encrypted phone of contacts encrypted at rest",
+		statements: []string{
+			`ALTER TABLE contacts ADD COLUMN encrypted_phone TEXT NOT NULL DEFAULT ''`,
+		},
+	},
+	{
+		version:     4,
+		description: "create erasure receipts table",
+		statements: []string{
+			`CREATE TABLE erasure_receipts (
+				id              TEXT    PRIMARY KEY,
+				user_id         TEXT    NOT NULL,
+				tenant_id       TEXT    NOT NULL,
+				requested_by    TEXT    NOT NULL,
+				erased_at       INTEGER NOT NULL,
+				contacts        INTEGER NOT NULL,
+				book_contacts   INTEGER NOT NULL,
+				shares_granted  INTEGER NOT NULL,
+				shares_received INTEGER NOT NULL,
+				memberships     INTEGER NOT NULL,
+				books           INTEGER NOT NULL,
+				digest          TEXT    NOT NULL
+			)`,
+		},
+	},
+	{
+		version:     5,
+		description: "create shares table",
+		statements: []string{
+			`CREATE TABLE shares (
+				id         TEXT    PRIMARY KEY,
+				owner_id   TEXT    NOT NULL,
+				contact_id TEXT    NOT NULL,
+				grantee_id TEXT    NOT NULL,
+				permission TEXT    NOT NULL,
+				created_at INTEGER NOT NULL,
+				UNIQUE (owner_id, contact_id, grantee_id)
+			)`,
+			`CREATE INDEX idx_shares_grantee ON shares (grantee_id, owner_id)`,
+		},
+	},
+	{
+		version:     6,
+		description: "create books and book members tables",
+		statements: []string{
+			`CREATE TABLE books (
+				id         TEXT    PRIMARY KEY,
+				name       TEXT    NOT NULL,
+				owner_id   TEXT    NOT NULL,
+				created_at INTEGER NOT NULL
+			)`,
+			`CREATE TABLE book_members (
+				book_id    TEXT    NOT NULL,
+				user_id    TEXT    NOT NULL,
+				role       TEXT    NOT NULL,
+				invited_at INTEGER NOT NULL,
+				joined_at  INTEGER NOT NULL,
+				PRIMARY KEY (book_id, user_id)
+			)`,
+			`CREATE INDEX idx_book_members_user ON book_members (user_id)`,
+		},
+	},
+	{
+		version:     7,
+		description: "create API keys table",
+		statements: []string{
+			`CREATE TABLE api_keys (
+				id         TEXT    PRIMARY KEY,
+				name       TEXT    NOT NULL,
+				prefix     TEXT    NOT NULL,
+				hash       TEXT    NOT NULL UNIQUE,
+				user_id    TEXT    NOT NULL,
+				tenant_id  TEXT    NOT NULL,
+				scopes     TEXT    NOT NULL,
+				created_at INTEGER NOT NULL,
+				expires_at INTEGER NOT NULL,
+				revoked_at INTEGER NOT NULL
+			)`,
+		},
+	},
+}
+
+// migrate brings the schema up to the latest version, applying each pending migration in its own transaction
+func migrate(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version     INTEGER PRIMARY KEY,
+		description TEXT    NOT NULL,
+		applied_at  INTEGER NOT NULL
+	)`); err != nil {
+		return myerror.Wrap(err, "migrate")
+	}
+
+	var current int
+	if err := db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&current); err != nil {
+		return myerror.Wrap(err, "migrate")
+	}
+
+	for _, m := range migrations {
+		if m.version <= current {
+			continue
+		}
+
+		if err := applyMigration(ctx, db, m); err != nil {
+			return myerror.Wrap(err, "migrate: version %d", m.version)
+		}
+	}
+
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m migration) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return myerror.Wrap(err, "applyMigration")
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range m.statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return myerror.Wrap(err, "applyMigration")
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, description, applied_at) VALUES (?, ?, strftime('%s', 'now'))`,
+		m.version, m.description,
+	); err != nil {
+		return myerror.Wrap(err, "applyMigration")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return myerror.Wrap(err, "applyMigration")
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"infrastructure/myerror"
+	"time"
+
+	"contact-service/contact"
+)
+
+const receiptColumns = "id, user_id, tenant_id, requested_by, erased_at, contacts, book_contacts, shares_granted, shares_received, memberships, books, digest"
+
+func (r *repository) SaveReceipt(ctx context.Context, receipt contact.ErasureReceipt) error {
+	if _, err := r.db.ExecContext(ctx,
+		`INSERT INTO erasure_receipts (`+receiptColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		receipt.ID, receipt.UserID, receipt.TenantID, receipt.RequestedBy, receipt.ErasedAt.UnixNano(),
+		receipt.Contacts, receipt.BookContacts, receipt.SharesGranted, receipt.SharesReceived, receipt.Memberships, receipt.Books,
+		receipt.Digest,
+	); err != nil {
+		return myerror.Wrap(err, "sqlite.SaveReceipt")
+	}
+
+	return nil
+}
+
+func (r *repository) GetReceipt(ctx context.Context, receiptID string) (contact.ErasureReceipt, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+receiptColumns+` FROM erasure_receipts WHERE id = ?`, receiptID)
+
+	receipt, err := scanReceipt(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return contact.ErasureReceipt{}, myerror.NewNotFoundError("sqlite.GetReceipt: receipt with ID %s not found", receiptID)
+	}
+	if err != nil {
+		return contact.ErasureReceipt{}, myerror.Wrap(err, "sqlite.GetReceipt")
+	}
+
+	return receipt, nil
+}
+
+// ListReceipts returns the receipts oldest first
+func (r *repository) ListReceipts(ctx context.Context) ([]contact.ErasureReceipt, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+receiptColumns+` FROM erasure_receipts ORDER BY erased_at, rowid`)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.ListReceipts")
+	}
+	defer rows.Close()
+
+	var receipts []contact.ErasureReceipt
+	for rows.Next() {
+		receipt, err := scanReceipt(rows)
+		if err != nil {
+			return nil, myerror.Wrap(err, "sqlite.ListReceipts")
+		}
+		receipts = append(receipts, receipt)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, myerror.Wrap(err, "sqlite.ListReceipts")
+	}
+
+	return receipts, nil
+}
+
+func scanReceipt(s scanner) (contact.ErasureReceipt, error) {
+	var (
+		receipt  contact.ErasureReceipt
+		erasedAt int64
+	)
+	if err := s.Scan(&receipt.ID, &receipt.UserID, &receipt.TenantID, &receipt.RequestedBy, &erasedAt,
+		&receipt.Contacts, &receipt.BookContacts, &receipt.SharesGranted, &receipt.SharesReceived, &receipt.Memberships, &receipt.Books,
+		&receipt.Digest,
+	); err != nil {
+		return contact.ErasureReceipt{}, err
+	}
+
+	receipt.ErasedAt = time.Unix(0, erasedAt)
+
+	return receipt, nil
+}
@@ -0,0 +1,229 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"infrastructure/myerror"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"contact-service/contact"
+)
+
+const contactColumns = "user_id, id, phone, first_name, last_name, address, encrypted_phone, created_at, updated_at"
+
+type repository struct {
+	db *sql.DB
+}
+
+func NewRepository(ctx context.Context, path string) (*repository, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.NewRepository")
+	}
+
+	// SQLite allows a single writer, serializing through one connection avoids SQLITE_BUSY errors
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(ctx, `PRAGMA journal_mode = WAL`); err != nil {
+		db.Close()
+		return nil, myerror.Wrap(err, "sqlite.NewRepository")
+	}
+
+	if err := migrate(ctx, db); err != nil {
+		db.Close()
+		return nil, myerror.Wrap(err, "sqlite.NewRepository")
+	}
+
+	return &repository{
+		db: db,
+	}, nil
+}
+
+func (r *repository) CreateContact(ctx context.Context, c contact.Contact) error {
+	if _, err := r.db.ExecContext(ctx,
+		`INSERT INTO contacts (`+contactColumns+`) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		c.UserID, c.ID, c.Phone, c.FirstName, c.LastName, c.Address, c.EncryptedPhone, c.CreatedAt.UnixNano(), c.UpdatedAt.UnixNano(),
+	); err != nil {
+		return myerror.Wrap(err, "sqlite.CreateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+contactColumns+` FROM contacts WHERE user_id = ? AND id = ?`,
+		userID, contactID,
+	)
+
+	c, err := scanContact(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return contact.Contact{}, myerror.NewNotFoundError("sqlite.GetContact: contact with ID %s not found for user %s", contactID, userID)
+	}
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "sqlite.GetContact")
+	}
+
+	return c, nil
+}
+
+func (r *repository) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM contacts WHERE user_id = ? AND id = ?`, userID, contactID); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteContact")
+	}
+
+	return nil
+}
+
+func (r *repository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	conditions := []string{"user_id = ?"}
+	args := []interface{}{filters.UserID}
+
+	for column, value := range map[string]string{
+		"phone":      filters.Phone,
+		"first_name": filters.FirstName,
+		"last_name":  filters.LastName,
+		"address":    filters.Address,
+	} {
+		if value != "" {
+			conditions = append(conditions, column+" = ?")
+			args = append(args, value)
+		}
+	}
+	args = append(args, filters.Limit, filters.Offset)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+contactColumns+` FROM contacts WHERE `+strings.Join(conditions, " AND ")+
+			` ORDER BY first_name, id LIMIT ? OFFSET ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.SearchContacts")
+	}
+	defer rows.Close()
+
+	var contacts []contact.Contact
+	for rows.Next() {
+		c, err := scanContact(rows)
+		if err != nil {
+			return nil, myerror.Wrap(err, "sqlite.SearchContacts")
+		}
+		contacts = append(contacts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, myerror.Wrap(err, "sqlite.SearchContacts")
+	}
+
+	return contacts, nil
+}
+
+func (r *repository) UpdateContact(ctx context.Context, c contact.Contact) error {
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE contacts SET phone = ?, first_name = ?, last_name = ?, address = ?, encrypted_phone = ?, created_at = ?,
+		updated_at = ? WHERE user_id = ? AND id = ?`,
+		c.Phone, c.FirstName, c.LastName, c.Address, c.EncryptedPhone, c.CreatedAt.UnixNano(), c.UpdatedAt.UnixNano(), c.UserID, c.ID,
+	); err != nil {
+		return myerror.Wrap(err, "sqlite.UpdateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT EXISTS (SELECT 1 FROM contacts WHERE user_id = ? AND phone = ?)`,
+		userID, phone,
+	).Scan(&exists); err != nil {
+		return false, myerror.Wrap(err, "sqlite.IsPhoneExistsForUser")
+	}
+
+	return exists, nil
+}
+
+// CountContacts counts the contacts of the users whose ID starts with the prefix, as a range of the user ID index
+func (r *repository) CountContacts(ctx context.Context, userIDPrefix string) (int, error) {
+	query := `SELECT COUNT(*) FROM contacts WHERE user_id >= ?`
+	args := []interface{}{userIDPrefix}
+	if end, ok := prefixEnd(userIDPrefix); ok {
+		query += ` AND user_id < ?`
+		args = append(args, end)
+	}
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, myerror.Wrap(err, "sqlite.CountContacts")
+	}
+
+	return count, nil
+}
+
+func (r *repository) HealthCheck(ctx context.Context) error {
+	if err := r.db.PingContext(ctx); err != nil {
+		return myerror.Wrap(err, "sqlite.HealthCheck")
+	}
+
+	return nil
+}
+
+func (r *repository) Close() error {
+	if err := r.db.Close(); err != nil {
+		return myerror.Wrap(err, "sqlite.Close")
+	}
+
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanContact(s scanner) (contact.Contact, error) {
+	var (
+		c                    contact.Contact
+		createdAt, updatedAt int64
+	)
+	if err := s.Scan(&c.UserID, &c.ID, &c.Phone, &c.FirstName, &c.LastName, &c.Address, &c.EncryptedPhone, &createdAt, &updatedAt); err != nil {
+		return contact.Contact{}, err
+	}
+
+	c.CreatedAt = time.Unix(0, createdAt)
+	c.UpdatedAt = time.Unix(0, updatedAt)
+
+	return c, nil
+}
+
+// prefixEnd is the smallest string greater than every string starting with the prefix, there is none when the prefix
+// is empty or all 0xff bytes
+func prefixEnd(prefix string) (string, bool) {
+	end := []byte(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return string(end[:i+1]), true
+		}
+	}
+
+	return "", false
+}
+
+// toUnixNano stores the zero time, e.g. the join time of a pending invite, as 0 so fromUnixNano maps it back
+func toUnixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+
+	return t.UnixNano()
+}
+
+func fromUnixNano(n int64) time.Time {
+	if n == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, n)
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"infrastructure/myerror"
+	"time"
+
+	"contact-service/contact"
+)
+
+const shareColumns = "id, owner_id, contact_id, grantee_id, permission, created_at"
+
+// SaveShare creates the share, or replaces the permission of the existing share of the same contact and grantee
+func (r *repository) SaveShare(ctx context.Context, s contact.Share) (contact.Share, error) {
+	row := r.db.QueryRowContext(ctx,
+		`INSERT INTO shares (`+shareColumns+`) VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT (owner_id, contact_id, grantee_id) DO UPDATE SET permission = excluded.permission
+		RETURNING `+shareColumns,
+		s.ID, s.OwnerID, s.ContactID, s.GranteeID, s.Permission, s.CreatedAt.UnixNano(),
+	)
+
+	saved, err := scanShare(row)
+	if err != nil {
+		return contact.Share{}, myerror.Wrap(err, "sqlite.SaveShare")
+	}
+
+	return saved, nil
+}
+
+func (r *repository) DeleteShare(ctx context.Context, ownerID, shareID string) error {
+	res, err := r.db.ExecContext(ctx, `DELETE FROM shares WHERE id = ? AND owner_id = ?`, shareID, ownerID)
+	if err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteShare")
+	}
+
+	if n, err := res.RowsAffected(); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteShare")
+	} else if n == 0 {
+		return myerror.NewNotFoundError("sqlite.DeleteShare: share with ID %s not found for user %s", shareID, ownerID)
+	}
+
+	return nil
+}
+
+// DeleteSharesOfContact drops the shares of a single contact, e.g. once it is deleted
+func (r *repository) DeleteSharesOfContact(ctx context.Context, ownerID, contactID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM shares WHERE owner_id = ? AND contact_id = ?`, ownerID, contactID); err != nil {
+		return myerror.Wrap(err, "sqlite.DeleteSharesOfContact")
+	}
+
+	return nil
+}
+
+func (r *repository) ListSharesByOwner(ctx context.Context, ownerID string) ([]contact.Share, error) {
+	shares, err := r.queryShares(ctx, `WHERE owner_id = ?`, ownerID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.ListSharesByOwner")
+	}
+
+	return shares, nil
+}
+
+func (r *repository) ListSharesByGrantee(ctx context.Context, granteeID string) ([]contact.Share, error) {
+	shares, err := r.queryShares(ctx, `WHERE grantee_id = ?`, granteeID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.ListSharesByGrantee")
+	}
+
+	return shares, nil
+}
+
+// FindShares returns the shares granted by the owner to the grantee
+func (r *repository) FindShares(ctx context.Context, ownerID, granteeID string) ([]contact.Share, error) {
+	shares, err := r.queryShares(ctx, `WHERE grantee_id = ? AND owner_id = ?`, granteeID, ownerID)
+	if err != nil {
+		return nil, myerror.Wrap(err, "sqlite.FindShares")
+	}
+
+	return shares, nil
+}
+
+// queryShares returns the shares matching the where clause oldest first
+func (r *repository) queryShares(ctx context.Context, where string, args ...interface{}) ([]contact.Share, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+shareColumns+` FROM shares `+where+` ORDER BY created_at, id`, args...)
+	if err != nil {
+		return nil, myerror.Wrap(err, "queryShares")
+	}
+	defer rows.Close()
+
+	shares := make([]contact.Share, 0)
+	for rows.Next() {
+		s, err := scanShare(rows)
+		if err != nil {
+			return nil, myerror.Wrap(err, "queryShares")
+		}
+		shares = append(shares, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, myerror.Wrap(err, "queryShares")
+	}
+
+	return shares, nil
+}
+
+func scanShare(s scanner) (contact.Share, error) {
+	var (
+		share     contact.Share
+		createdAt int64
+	)
+	if err := s.Scan(&share.ID, &share.OwnerID, &share.ContactID, &share.GranteeID, &share.Permission, &createdAt); err != nil {
+		return contact.Share{}, err
+	}
+
+	share.CreatedAt = time.Unix(0, createdAt)
+
+	return share, nil
+}
@@ -0,0 +1,137 @@
+package stdout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"infrastructure/mycontext"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type jsonLogger struct {
+	mu       sync.Mutex
+	out      io.Writer
+	minLevel int
+
+	// debugSampleEvery keeps the first and then every n-th debug line of each message, 1 or less keeps them all
+	debugSampleEvery uint64
+	debugCounts      sync.Map
+}
+
+// NewJSONLogger writes one JSON object per line to out, dropping every line below minLevel. The request ID, user ID
+// and trace ID found in the context are added to every line.
+func NewJSONLogger(out io.Writer, minLevel string, debugSampleEvery int) *jsonLogger {
+	if debugSampleEvery < 1 {
+		debugSampleEvery = 1
+	}
+
+	return &jsonLogger{
+		out:              out,
+		minLevel:         levelSeverity[minLevel],
+		debugSampleEvery: uint64(debugSampleEvery),
+	}
+}
+
+func (l *jsonLogger) Info(ctx context.Context, msg string, keyvals ...interface{}) {
+	l.log(ctx, LevelInfo, msg, nil, keyvals...)
+}
+
+func (l *jsonLogger) Error(ctx context.Context, err error, keyvals ...interface{}) {
+	l.log(ctx, LevelError, "", err, keyvals...)
+}
+
+func (l *jsonLogger) Warning(ctx context.Context, err error, keyvals ...interface{}) {
+	l.log(ctx, LevelWarning, "", err, keyvals...)
+}
+
+func (l *jsonLogger) Debug(ctx context.Context, msg string, keyvals ...interface{}) {
+	if !l.sampled(msg) {
+		return
+	}
+	l.log(ctx, LevelDebug, msg, nil, keyvals...)
+}
+
+func (l *jsonLogger) sampled(msg string) bool {
+	if l.debugSampleEvery == 1 {
+		return true
+	}
+
+	count, _ := l.debugCounts.LoadOrStore(msg, new(uint64))
+	n := atomic.AddUint64(count.(*uint64), 1) - 1
+
+	return n%l.debugSampleEvery == 0
+}
+
+func (l *jsonLogger) log(ctx context.Context, level, msg string, err error, keyvals ...interface{}) {
+	if levelSeverity[level] < l.minLevel {
+		return
+	}
+
+	var line bytes.Buffer
+	line.WriteByte('{')
+	writeField(&line, "time", time.Now().UTC().Format(time.RFC3339Nano), true)
+	writeField(&line, "level", level, false)
+	if msg != "" {
+		writeField(&line, "msg", msg, false)
+	}
+	if err != nil {
+		writeField(&line, "error", err.Error(), false)
+	}
+
+	if ctx != nil {
+		if requestID := mycontext.RequestID(ctx); requestID != "" {
+			writeField(&line, "requestID", requestID, false)
+		}
+		if tenantID := mycontext.TenantID(ctx); tenantID != "" {
+			writeField(&line, "tenantID", tenantID, false)
+		}
+		if userID := mycontext.UserID(ctx); userID != "" {
+			writeField(&line, "userID", userID, false)
+		}
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			writeField(&line, "traceID", spanContext.TraceID().String(), false)
+			writeField(&line, "spanID", spanContext.SpanID().String(), false)
+		}
+	}
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		var value interface{} = "!MISSING"
+		if i+1 < len(keyvals) {
+			value = keyvals[i+1]
+		}
+		writeField(&line, key, value, false)
+	}
+	line.WriteString("}\n")
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, _ = l.out.Write(line.Bytes())
+}
+
+// writeField appends a JSON member, values that cannot be encoded as JSON are written as their string form
+func writeField(line *bytes.Buffer, key string, value interface{}, first bool) {
+	if !first {
+		line.WriteByte(',')
+	}
+
+	if err, ok := value.(error); ok {
+		value = err.Error()
+	}
+
+	encodedKey, _ := json.Marshal(key)
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		encodedValue, _ = json.Marshal(fmt.Sprint(value))
+	}
+
+	line.Write(encodedKey)
+	line.WriteByte(':')
+	line.Write(encodedValue)
+}
@@ -0,0 +1,102 @@
+package stdout
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"infrastructure/mycontext"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestJSONLogger(t *testing.T) {
+	traceID, _ := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	spanID, _ := trace.SpanIDFromHex("00f067aa0ba902b7")
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+	ctx = mycontext.WithUserID(mycontext.WithRequestID(ctx, "req-1"), "user-1")
+
+	tests := []struct {
+		name        string
+		minLevel    string
+		sampleEvery int
+		log         func(l *jsonLogger)
+		want        []map[string]interface{}
+	}{
+		{
+			name:     "context fields and keyvals",
+			minLevel: LevelDebug,
+			log: func(l *jsonLogger) {
+				l.Info(ctx, "created", "contactID", "c-1", "count", 2)
+			},
+			want: []map[string]interface{}{{
+				"level": "info", "msg": "created", "requestID": "req-1", "userID": "user-1",
+				"traceID": "4bf92f3577b34da6a3ce929d0e0e4736", "spanID": "00f067aa0ba902b7",
+				"contactID": "c-1", "count": float64(2),
+			}},
+		},
+		{
+			name:     "error and odd keyvals",
+			minLevel: LevelDebug,
+			log: func(l *jsonLogger) {
+				l.Error(context.Background(), errors.New("boom"), "key")
+			},
+			want: []map[string]interface{}{{"level": "error", "error": "boom", "key": "!MISSING"}},
+		},
+		{
+			name:     "below minimum level",
+			minLevel: LevelWarning,
+			log: func(l *jsonLogger) {
+				l.Info(ctx, "dropped")
+				l.Debug(ctx, "dropped")
+				l.Warning(ctx, errors.New("kept"))
+			},
+			want: []map[string]interface{}{{"level": "warning", "error": "kept"}},
+		},
+		{
+			name:        "debug sampling per message",
+			minLevel:    LevelDebug,
+			sampleEvery: 2,
+			log: func(l *jsonLogger) {
+				for i := 0; i < 3; i++ {
+					l.Debug(ctx, "hot", "i", i)
+				}
+				l.Debug(ctx, "cold")
+			},
+			want: []map[string]interface{}{
+				{"msg": "hot", "i": float64(0)},
+				{"msg": "hot", "i": float64(2)},
+				{"msg": "cold"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tt.log(NewJSONLogger(&out, tt.minLevel, tt.sampleEvery))
+
+			lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+			if len(lines) != len(tt.want) {
+				t.Fatalf("got %d lines, want %d: %s", len(lines), len(tt.want), out.String())
+			}
+
+			for i, line := range lines {
+				var got map[string]interface{}
+				if err := json.Unmarshal([]byte(line), &got); err != nil {
+					t.Fatalf("line %q is not JSON: %v", line, err)
+				}
+				for k, v := range tt.want[i] {
+					if got[k] != v {
+						t.Errorf("line %d field %q = %v, want %v", i, k, got[k], v)
+					}
+				}
+			}
+		})
+	}
+}
@@ -14,13 +14,27 @@ const (
 	LevelWarning = "warning"
 )
 
+var levelSeverity = map[string]int{
+	LevelDebug:   0,
+	LevelInfo:    1,
+	LevelWarning: 2,
+	LevelError:   3,
+}
+
 type logger struct {
-	logger *log.Logger
+	logger   *log.Logger
+	minLevel int
 }
 
 func NewLogger() *logger {
+	return NewLoggerWithLevel(LevelDebug)
+}
+
+// NewLoggerWithLevel drops every line below minLevel, an unknown level logs everything
+func NewLoggerWithLevel(minLevel string) *logger {
 	return &logger{
-		logger: log.New(os.Stdout, "", log.LstdFlags),
+		logger:   log.New(os.Stdout, "", log.LstdFlags),
+		minLevel: levelSeverity[minLevel],
 	}
 }
 
@@ -41,6 +55,10 @@ func (l *logger) Debug(_ context.Context, msg string, keyvals ...interface{}) {
 }
 
 func (l *logger) log(level, msg string, err error, keyvals ...interface{}) {
+	if levelSeverity[level] < l.minLevel {
+		return
+	}
+
 	var logMsg string
 	if err != nil {
 		logMsg = fmt.Sprintf("%s: %v", msg, err)
@@ -0,0 +1,212 @@
+package stdout
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+)
+
+const (
+	FieldPhone     = "phone"
+	FieldFirstName = "firstName"
+	FieldLastName  = "lastName"
+	FieldAddress   = "address"
+
+	redactedValue = "***"
+)
+
+// AllFields are the personal data fields of a contact that can be redacted
+var AllFields = []string{FieldPhone, FieldFirstName, FieldLastName, FieldAddress}
+
+// phonePattern matches phone numbers written in free text, such as error messages and lock keys. Names and addresses
+// cannot be told apart from other text, so only their structured occurrences are redacted.
+var phonePattern = regexp.MustCompile(`\+?\b\d{7,15}\b`)
+
+type Logger interface {
+	Info(ctx context.Context, msg string, keyvals ...interface{})
+	Error(ctx context.Context, err error, keyvals ...interface{})
+	Warning(ctx context.Context, err error, keyvals ...interface{})
+	Debug(ctx context.Context, msg string, keyvals ...interface{})
+}
+
+// redactingLogger masks personal data before it reaches the next logger. Keyvals whose key is a redacted field, and
+// fields of the same name in logged structs, slices and maps, are masked. Phone numbers are also masked in free text.
+type redactingLogger struct {
+	next   Logger
+	fields map[string]struct{}
+}
+
+// NewRedactingLogger masks the given fields, matched case-insensitively, in everything logged through next
+func NewRedactingLogger(next Logger, fields []string) *redactingLogger {
+	l := &redactingLogger{
+		next:   next,
+		fields: make(map[string]struct{}, len(fields)),
+	}
+	for _, field := range fields {
+		l.fields[strings.ToLower(field)] = struct{}{}
+	}
+
+	return l
+}
+
+func (l *redactingLogger) Info(ctx context.Context, msg string, keyvals ...interface{}) {
+	l.next.Info(ctx, l.redactString(msg), l.redactKeyvals(keyvals)...)
+}
+
+func (l *redactingLogger) Error(ctx context.Context, err error, keyvals ...interface{}) {
+	l.next.Error(ctx, l.redactError(err), l.redactKeyvals(keyvals)...)
+}
+
+func (l *redactingLogger) Warning(ctx context.Context, err error, keyvals ...interface{}) {
+	l.next.Warning(ctx, l.redactError(err), l.redactKeyvals(keyvals)...)
+}
+
+func (l *redactingLogger) Debug(ctx context.Context, msg string, keyvals ...interface{}) {
+	l.next.Debug(ctx, l.redactString(msg), l.redactKeyvals(keyvals)...)
+}
+
+// redactedError keeps the redacted message of an error, the original error is not kept so it cannot leak through
+// unwrapping
+type redactedError string
+
+func (e redactedError) Error() string {
+	return string(e)
+}
+
+func (l *redactingLogger) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return redactedError(l.redactString(err.Error()))
+}
+
+func (l *redactingLogger) redactKeyvals(keyvals []interface{}) []interface{} {
+	if len(keyvals) == 0 {
+		return keyvals
+	}
+
+	redacted := make([]interface{}, len(keyvals))
+	for i := 0; i < len(keyvals); i += 2 {
+		redacted[i] = keyvals[i]
+		if i+1 == len(keyvals) {
+			break
+		}
+
+		if key, ok := keyvals[i].(string); ok && l.isRedacted(key) {
+			redacted[i+1] = maskField(key, fmt.Sprint(keyvals[i+1]))
+		} else {
+			redacted[i+1] = l.redact(keyvals[i+1])
+		}
+	}
+
+	return redacted
+}
+
+func (l *redactingLogger) isRedacted(field string) bool {
+	_, ok := l.fields[strings.ToLower(field)]
+	return ok
+}
+
+func (l *redactingLogger) redactString(s string) string {
+	if !l.isRedacted(FieldPhone) {
+		return s
+	}
+
+	return phonePattern.ReplaceAllStringFunc(s, maskPhone)
+}
+
+// redact walks the value and returns a copy, as maps and slices of plain values, in which the redacted fields are
+// masked. Values without nested data are returned unchanged.
+func (l *redactingLogger) redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return l.redactString(v)
+	case error:
+		return l.redactString(v.Error())
+	case time.Time, time.Duration:
+		return v
+	case fmt.Stringer:
+		return l.redactString(v.String())
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return l.redact(rv.Elem().Interface())
+	case reflect.Struct:
+		fields := make(map[string]interface{}, rv.NumField())
+		for i := 0; i < rv.NumField(); i++ {
+			field := rv.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fields[field.Name] = l.redactField(field.Name, rv.Field(i))
+		}
+		return fields
+	case reflect.Map:
+		entries := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key := fmt.Sprint(iter.Key().Interface())
+			entries[l.redactString(key)] = l.redactField(key, iter.Value())
+		}
+		return entries
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() == reflect.Uint8 {
+			return value
+		}
+		items := make([]interface{}, rv.Len())
+		for i := range items {
+			items[i] = l.redact(rv.Index(i).Interface())
+		}
+		return items
+	default:
+		return value
+	}
+}
+
+func (l *redactingLogger) redactField(name string, value reflect.Value) interface{} {
+	if l.isRedacted(name) {
+		return maskField(name, fmt.Sprint(value.Interface()))
+	}
+
+	return l.redact(value.Interface())
+}
+
+// maskField keeps the last digits of a phone number so support can still match it with the caller, every other field
+// is masked entirely
+func maskField(field, value string) string {
+	if value == "" {
+		return ""
+	}
+	if strings.EqualFold(field, FieldPhone) {
+		return maskPhone(value)
+	}
+
+	return redactedValue
+}
+
+func maskPhone(phone string) string {
+	const visibleDigits = 2
+
+	if len(phone) <= visibleDigits*2 {
+		return redactedValue
+	}
+
+	return strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return '*'
+		}
+		return r
+	}, phone[:len(phone)-visibleDigits]) + phone[len(phone)-visibleDigits:]
+}
@@ -0,0 +1,85 @@
+package stdout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"contact-service/contact"
+)
+
+func TestRedactingLogger(t *testing.T) {
+	c := contact.Contact{
+		UserID:    "user-1",
+		ID:        "contact-1",
+		Phone:     "0541234567",
+		FirstName: "Dana",
+		LastName:  "Levi",
+		Address:   "1 Herzl St",
+		CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+
+	tests := []struct {
+		name        string
+		fields      []string
+		log         func(l *redactingLogger)
+		wantContain []string
+		wantAbsent  []string
+	}{
+		{
+			name:   "contact value",
+			fields: AllFields,
+			log: func(l *redactingLogger) {
+				l.Info(context.Background(), "added", "key", "user-1:contact-1", "value", c)
+			},
+			wantContain: []string{`"Phone":"********67"`, `"FirstName":"***"`, `"Address":"***"`, `"ID":"contact-1"`, `"CreatedAt":"2024-01-02T03:04:05Z"`},
+			wantAbsent:  []string{"0541234567", "Dana", "Levi", "Herzl"},
+		},
+		{
+			name:   "nested slice of contacts",
+			fields: AllFields,
+			log: func(l *redactingLogger) {
+				l.Debug(context.Background(), "page", "value", struct{ Contacts []contact.Contact }{Contacts: []contact.Contact{c}})
+			},
+			wantAbsent: []string{"0541234567", "Dana"},
+		},
+		{
+			name:   "keyval by field name and phone in error text",
+			fields: AllFields,
+			log: func(l *redactingLogger) {
+				l.Warning(context.Background(), errors.New("contact with phone 0541234567 already exists"), "firstName", "Dana", "lockKey", "create:user-1:0541234567")
+			},
+			wantContain: []string{"phone ********67 already exists", `"firstName":"***"`, `"lockKey":"create:user-1:********67"`},
+			wantAbsent:  []string{"0541234567", "Dana"},
+		},
+		{
+			name:   "only configured fields",
+			fields: []string{FieldAddress},
+			log: func(l *redactingLogger) {
+				l.Info(context.Background(), "added", "value", c)
+			},
+			wantContain: []string{"0541234567", "Dana", `"Address":"***"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			tt.log(NewRedactingLogger(NewJSONLogger(&out, LevelDebug, 1), tt.fields))
+
+			for _, want := range tt.wantContain {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("log %s does not contain %s", out.String(), want)
+				}
+			}
+			for _, absent := range tt.wantAbsent {
+				if strings.Contains(out.String(), absent) {
+					t.Errorf("log %s contains %s", out.String(), absent)
+				}
+			}
+		})
+	}
+}
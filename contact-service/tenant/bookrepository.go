@@ -0,0 +1,154 @@
+package tenant
+
+import (
+	"context"
+	"infrastructure/myerror"
+
+	"contact-service/contact"
+)
+
+type BookRepository interface {
+	CreateBook(context.Context, contact.Book) error
+	GetBook(ctx context.Context, bookID string) (contact.Book, error)
+	ListBooksByMember(ctx context.Context, userID string) ([]contact.Book, error)
+	SaveMember(ctx context.Context, bookID string, m contact.Member) error
+	DeleteMember(ctx context.Context, bookID, userID string) error
+	DeleteBook(ctx context.Context, bookID string) error
+}
+
+// bookRepository prefixes the owner and the members of every book with the tenant, and hides the books of other
+// tenants as if they did not exist
+type bookRepository struct {
+	next BookRepository
+}
+
+func NewBookRepository(next BookRepository) *bookRepository {
+	return &bookRepository{
+		next: next,
+	}
+}
+
+func (r *bookRepository) CreateBook(ctx context.Context, b contact.Book) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.CreateBook")
+	}
+
+	b.OwnerID = scope(tenantID, b.OwnerID)
+	members := make([]contact.Member, 0, len(b.Members))
+	for _, m := range b.Members {
+		m.UserID = scope(tenantID, m.UserID)
+		members = append(members, m)
+	}
+	b.Members = members
+
+	if err := r.next.CreateBook(ctx, b); err != nil {
+		return myerror.Wrap(err, "tenant.CreateBook")
+	}
+
+	return nil
+}
+
+func (r *bookRepository) GetBook(ctx context.Context, bookID string) (contact.Book, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "tenant.GetBook")
+	}
+
+	b, err := r.getBook(ctx, tenantID, bookID)
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "tenant.GetBook")
+	}
+
+	return unscopeBook(tenantID, b), nil
+}
+
+func (r *bookRepository) ListBooksByMember(ctx context.Context, userID string) ([]contact.Book, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.ListBooksByMember")
+	}
+
+	books, err := r.next.ListBooksByMember(ctx, scope(tenantID, userID))
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.ListBooksByMember")
+	}
+	for i := range books {
+		books[i] = unscopeBook(tenantID, books[i])
+	}
+
+	return books, nil
+}
+
+func (r *bookRepository) SaveMember(ctx context.Context, bookID string, m contact.Member) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.SaveMember")
+	}
+
+	if _, err := r.getBook(ctx, tenantID, bookID); err != nil {
+		return myerror.Wrap(err, "tenant.SaveMember")
+	}
+
+	m.UserID = scope(tenantID, m.UserID)
+	if err := r.next.SaveMember(ctx, bookID, m); err != nil {
+		return myerror.Wrap(err, "tenant.SaveMember")
+	}
+
+	return nil
+}
+
+func (r *bookRepository) DeleteMember(ctx context.Context, bookID, userID string) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.DeleteMember")
+	}
+
+	if _, err := r.getBook(ctx, tenantID, bookID); err != nil {
+		return myerror.Wrap(err, "tenant.DeleteMember")
+	}
+
+	if err := r.next.DeleteMember(ctx, bookID, scope(tenantID, userID)); err != nil {
+		return myerror.Wrap(err, "tenant.DeleteMember")
+	}
+
+	return nil
+}
+
+func (r *bookRepository) DeleteBook(ctx context.Context, bookID string) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.DeleteBook")
+	}
+
+	if _, err := r.getBook(ctx, tenantID, bookID); err != nil {
+		return myerror.Wrap(err, "tenant.DeleteBook")
+	}
+
+	if err := r.next.DeleteBook(ctx, bookID); err != nil {
+		return myerror.Wrap(err, "tenant.DeleteBook")
+	}
+
+	return nil
+}
+
+// getBook returns the book as stored, if it belongs to the tenant
+func (r *bookRepository) getBook(ctx context.Context, tenantID, bookID string) (contact.Book, error) {
+	b, err := r.next.GetBook(ctx, bookID)
+	if err != nil {
+		return contact.Book{}, myerror.Wrap(err, "getBook")
+	}
+	if !belongsTo(tenantID, b.OwnerID) {
+		return contact.Book{}, myerror.NewNotFoundError("getBook: book with ID %s not found", bookID)
+	}
+
+	return b, nil
+}
+
+func unscopeBook(tenantID string, b contact.Book) contact.Book {
+	b.OwnerID = unscope(tenantID, b.OwnerID)
+	for i := range b.Members {
+		b.Members[i].UserID = unscope(tenantID, b.Members[i].UserID)
+	}
+	return b
+}
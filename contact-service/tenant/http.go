@@ -0,0 +1,55 @@
+package tenant
+
+import (
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"infrastructure/myhttp"
+	"math"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewHTTPMiddleware names the tenant of the request and enforces its request rate. The tenant authenticated with
+// the caller, from a token claim or an API key, wins over the header, which may only repeat it. Users must be
+// authenticated with a tenant, the header only names the tenant of callers that are not users, i.e. operator API
+// keys bound to no user or tenant, or every caller when authentication is disabled. It must run after the
+// authentication.
+func NewHTTPMiddleware(header string, quotas QuotaFunc) gin.HandlerFunc {
+	limiter := newRateLimiter()
+
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		tenantID := mycontext.TenantID(ctx)
+		requested := c.GetHeader(header)
+		switch {
+		case tenantID == "" && mycontext.Subject(ctx) != "":
+			myhttp.EncodeJSONError(c, myerror.NewForbiddenError("tenant: user %s was not authenticated with a tenant", mycontext.Subject(ctx)))
+			c.Abort()
+			return
+		case tenantID == "":
+			tenantID = requested
+		case requested != "" && requested != tenantID:
+			myhttp.EncodeJSONError(c, myerror.NewForbiddenError("tenant: the caller may not reach tenant %s", requested))
+			c.Abort()
+			return
+		}
+
+		if !IsValidID(tenantID) {
+			myhttp.EncodeJSONError(c, myerror.NewBadRequestError("tenant: the %s header must name a tenant of up to 64 letters, digits, '.', '_' or '-'", header))
+			c.Abort()
+			return
+		}
+
+		if ok, retryAfter := limiter.allow(tenantID, quotas(tenantID)); !ok {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			myhttp.EncodeJSONError(c, myerror.NewQuotaExceededError("tenant: request rate of tenant %s exceeded", tenantID))
+			c.Abort()
+			return
+		}
+
+		c.Request = c.Request.WithContext(mycontext.WithTenantID(c.Request.Context(), tenantID))
+
+		c.Next()
+	}
+}
@@ -0,0 +1,49 @@
+package tenant
+
+import (
+	"context"
+	"infrastructure/myerror"
+)
+
+type LockCache interface {
+	Lock(context.Context, string) (bool, error)
+	Unlock(context.Context, string) error
+}
+
+// lockCache prefixes every lock key with the tenant, so tenants never contend for the same lock
+type lockCache struct {
+	next LockCache
+}
+
+func NewLockCache(next LockCache) *lockCache {
+	return &lockCache{
+		next: next,
+	}
+}
+
+func (l *lockCache) Lock(ctx context.Context, key string) (bool, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return false, myerror.Wrap(err, "tenant.Lock")
+	}
+
+	ok, err := l.next.Lock(ctx, scope(tenantID, key))
+	if err != nil {
+		return false, myerror.Wrap(err, "tenant.Lock")
+	}
+
+	return ok, nil
+}
+
+func (l *lockCache) Unlock(ctx context.Context, key string) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.Unlock")
+	}
+
+	if err := l.next.Unlock(ctx, scope(tenantID, key)); err != nil {
+		return myerror.Wrap(err, "tenant.Unlock")
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package tenant
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets of idle tenants are dropped
+const sweepInterval = time.Minute
+
+// bucket holds the requests a tenant may still send, refilled at the rate of its quota
+type bucket struct {
+	tokens float64
+	burst  float64
+	rate   float64
+	last   time.Time
+}
+
+// full tells whether the bucket refilled up to its burst, a tenant without a bucket gets a full one
+func (b *bucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// rateLimiter keeps one token bucket per tenant that sent requests recently. Full buckets are dropped, so the
+// buckets are bounded by the tenants active within the time of a refill, not by every tenant ID ever sent.
+type rateLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// allow takes a token from the bucket of the tenant, or tells how long to wait for the next one
+func (l *rateLimiter) allow(tenantID string, q Quota) (bool, time.Duration) {
+	if q.RequestsPerSecond <= 0 {
+		return true, 0
+	}
+
+	burst := float64(q.Burst)
+	if burst < 1 {
+		burst = math.Max(1, math.Ceil(q.RequestsPerSecond))
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[tenantID]
+	if !ok {
+		b = &bucket{tokens: burst, last: now}
+		l.buckets[tenantID] = b
+	}
+
+	b.tokens = math.Min(burst, b.tokens+now.Sub(b.last).Seconds()*q.RequestsPerSecond)
+	b.burst, b.rate, b.last = burst, q.RequestsPerSecond, now
+
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / q.RequestsPerSecond * float64(time.Second))
+	}
+	b.tokens--
+
+	return true, 0
+}
+
+// sweep drops the full buckets, must be called while holding the lock
+func (l *rateLimiter) sweep(now time.Time) {
+	for tenantID, b := range l.buckets {
+		if b.full(now) {
+			delete(l.buckets, tenantID)
+		}
+	}
+	l.lastSweep = now
+}
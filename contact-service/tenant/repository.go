@@ -0,0 +1,156 @@
+package tenant
+
+import (
+	"context"
+	"hash/fnv"
+	"infrastructure/myerror"
+	"sync"
+
+	"contact-service/contact"
+)
+
+type Repository interface {
+	CreateContact(context.Context, contact.Contact) error
+	GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error)
+	DeleteContact(ctx context.Context, userID string, contactID string) error
+	SearchContacts(ctx context.Context, filters contact.Filters) (contacts []contact.Contact, err error)
+	UpdateContact(context.Context, contact.Contact) error
+	IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error)
+}
+
+// lockStripes bounds the mutexes serializing the creates of the tenants
+const lockStripes = 64
+
+// Counter counts the stored contacts of the users whose ID starts with a prefix, i.e. of a tenant
+type Counter interface {
+	CountContacts(ctx context.Context, userIDPrefix string) (int, error)
+}
+
+// repository stores the contacts of every tenant under user IDs prefixed by the tenant, so the repository and the
+// caches below it never mix the data of two tenants. The contacts quota of a tenant is checked against the count of
+// its contacts in storage, so it holds across restarts. Creates of the same tenant are serialized within the
+// process, replicas creating contacts of one tenant at the same time may exceed its quota by one contact each.
+type repository struct {
+	next    Repository
+	counter Counter
+	quotas  QuotaFunc
+
+	stripes [lockStripes]sync.Mutex
+}
+
+func NewRepository(next Repository, counter Counter, quotas QuotaFunc) *repository {
+	return &repository{
+		next:    next,
+		counter: counter,
+		quotas:  quotas,
+	}
+}
+
+func (r *repository) CreateContact(ctx context.Context, c contact.Contact) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.CreateContact")
+	}
+
+	if limit := r.quotas(tenantID).MaxContacts; limit > 0 {
+		mu := r.stripe(tenantID)
+		mu.Lock()
+		defer mu.Unlock()
+
+		count, err := r.counter.CountContacts(ctx, scope(tenantID, ""))
+		if err != nil {
+			return myerror.Wrap(err, "tenant.CreateContact")
+		}
+		if count >= limit {
+			return myerror.NewQuotaExceededError("tenant.CreateContact: tenant %s reached its quota of %d contacts", tenantID, limit)
+		}
+	}
+
+	c.UserID = scope(tenantID, c.UserID)
+	if err := r.next.CreateContact(ctx, c); err != nil {
+		return myerror.Wrap(err, "tenant.CreateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) GetContact(ctx context.Context, userID string, contactID string) (contact.Contact, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "tenant.GetContact")
+	}
+
+	c, err := r.next.GetContact(ctx, scope(tenantID, userID), contactID)
+	if err != nil {
+		return contact.Contact{}, myerror.Wrap(err, "tenant.GetContact")
+	}
+	c.UserID = unscope(tenantID, c.UserID)
+
+	return c, nil
+}
+
+func (r *repository) DeleteContact(ctx context.Context, userID string, contactID string) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.DeleteContact")
+	}
+
+	if err := r.next.DeleteContact(ctx, scope(tenantID, userID), contactID); err != nil {
+		return myerror.Wrap(err, "tenant.DeleteContact")
+	}
+
+	return nil
+}
+
+func (r *repository) SearchContacts(ctx context.Context, filters contact.Filters) ([]contact.Contact, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.SearchContacts")
+	}
+
+	filters.UserID = scope(tenantID, filters.UserID)
+	contacts, err := r.next.SearchContacts(ctx, filters)
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.SearchContacts")
+	}
+	for i := range contacts {
+		contacts[i].UserID = unscope(tenantID, contacts[i].UserID)
+	}
+
+	return contacts, nil
+}
+
+func (r *repository) UpdateContact(ctx context.Context, c contact.Contact) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.UpdateContact")
+	}
+
+	c.UserID = scope(tenantID, c.UserID)
+	if err := r.next.UpdateContact(ctx, c); err != nil {
+		return myerror.Wrap(err, "tenant.UpdateContact")
+	}
+
+	return nil
+}
+
+func (r *repository) IsPhoneExistsForUser(ctx context.Context, userID, phone string) (bool, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return false, myerror.Wrap(err, "tenant.IsPhoneExistsForUser")
+	}
+
+	exists, err := r.next.IsPhoneExistsForUser(ctx, scope(tenantID, userID), phone)
+	if err != nil {
+		return false, myerror.Wrap(err, "tenant.IsPhoneExistsForUser")
+	}
+
+	return exists, nil
+}
+
+func (r *repository) stripe(tenantID string) *sync.Mutex {
+	h := fnv.New32a()
+	h.Write([]byte(tenantID))
+
+	return &r.stripes[h.Sum32()%lockStripes]
+}
@@ -0,0 +1,125 @@
+package tenant
+
+import (
+	"context"
+	"infrastructure/myerror"
+
+	"contact-service/contact"
+)
+
+type ShareRepository interface {
+	SaveShare(context.Context, contact.Share) (contact.Share, error)
+	DeleteShare(ctx context.Context, ownerID, shareID string) error
+	DeleteSharesOfContact(ctx context.Context, ownerID, contactID string) error
+	ListSharesByOwner(ctx context.Context, ownerID string) ([]contact.Share, error)
+	ListSharesByGrantee(ctx context.Context, granteeID string) ([]contact.Share, error)
+	FindShares(ctx context.Context, ownerID, granteeID string) ([]contact.Share, error)
+}
+
+// shareRepository prefixes the owner and the grantee of every share with the tenant, so shares never cross tenants
+type shareRepository struct {
+	next ShareRepository
+}
+
+func NewShareRepository(next ShareRepository) *shareRepository {
+	return &shareRepository{
+		next: next,
+	}
+}
+
+func (r *shareRepository) SaveShare(ctx context.Context, s contact.Share) (contact.Share, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return contact.Share{}, myerror.Wrap(err, "tenant.SaveShare")
+	}
+
+	s.OwnerID = scope(tenantID, s.OwnerID)
+	s.GranteeID = scope(tenantID, s.GranteeID)
+	s, err = r.next.SaveShare(ctx, s)
+	if err != nil {
+		return contact.Share{}, myerror.Wrap(err, "tenant.SaveShare")
+	}
+
+	return unscopeShare(tenantID, s), nil
+}
+
+func (r *shareRepository) DeleteShare(ctx context.Context, ownerID, shareID string) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.DeleteShare")
+	}
+
+	if err := r.next.DeleteShare(ctx, scope(tenantID, ownerID), shareID); err != nil {
+		return myerror.Wrap(err, "tenant.DeleteShare")
+	}
+
+	return nil
+}
+
+func (r *shareRepository) DeleteSharesOfContact(ctx context.Context, ownerID, contactID string) error {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return myerror.Wrap(err, "tenant.DeleteSharesOfContact")
+	}
+
+	if err := r.next.DeleteSharesOfContact(ctx, scope(tenantID, ownerID), contactID); err != nil {
+		return myerror.Wrap(err, "tenant.DeleteSharesOfContact")
+	}
+
+	return nil
+}
+
+func (r *shareRepository) ListSharesByOwner(ctx context.Context, ownerID string) ([]contact.Share, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.ListSharesByOwner")
+	}
+
+	shares, err := r.next.ListSharesByOwner(ctx, scope(tenantID, ownerID))
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.ListSharesByOwner")
+	}
+
+	return unscopeShares(tenantID, shares), nil
+}
+
+func (r *shareRepository) ListSharesByGrantee(ctx context.Context, granteeID string) ([]contact.Share, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.ListSharesByGrantee")
+	}
+
+	shares, err := r.next.ListSharesByGrantee(ctx, scope(tenantID, granteeID))
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.ListSharesByGrantee")
+	}
+
+	return unscopeShares(tenantID, shares), nil
+}
+
+func (r *shareRepository) FindShares(ctx context.Context, ownerID, granteeID string) ([]contact.Share, error) {
+	tenantID, err := fromContext(ctx)
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.FindShares")
+	}
+
+	shares, err := r.next.FindShares(ctx, scope(tenantID, ownerID), scope(tenantID, granteeID))
+	if err != nil {
+		return nil, myerror.Wrap(err, "tenant.FindShares")
+	}
+
+	return unscopeShares(tenantID, shares), nil
+}
+
+func unscopeShare(tenantID string, s contact.Share) contact.Share {
+	s.OwnerID = unscope(tenantID, s.OwnerID)
+	s.GranteeID = unscope(tenantID, s.GranteeID)
+	return s
+}
+
+func unscopeShares(tenantID string, shares []contact.Share) []contact.Share {
+	for i := range shares {
+		shares[i] = unscopeShare(tenantID, shares[i])
+	}
+	return shares
+}
@@ -0,0 +1,51 @@
+package tenant
+
+import (
+	"context"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"regexp"
+	"strings"
+)
+
+// separator joins the tenant to the keys of its data, tenant IDs cannot contain it so no two tenants share a key
+const separator = "/"
+
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// Quota limits the usage of a tenant, zero values are unlimited
+type Quota struct {
+	MaxContacts       int
+	RequestsPerSecond float64
+	// Burst is the number of requests a tenant may send at once, it defaults to one second of requests
+	Burst int
+}
+
+// QuotaFunc returns the quota of a tenant
+type QuotaFunc func(tenantID string) Quota
+
+func IsValidID(tenantID string) bool {
+	return idPattern.MatchString(tenantID)
+}
+
+// fromContext returns the tenant of the request, the decorators refuse to reach the data of no tenant
+func fromContext(ctx context.Context) (string, error) {
+	tenantID := mycontext.TenantID(ctx)
+	if tenantID == "" {
+		return "", myerror.NewInternalError("tenant: no tenant in context")
+	}
+
+	return tenantID, nil
+}
+
+func scope(tenantID, key string) string {
+	return tenantID + separator + key
+}
+
+func unscope(tenantID, key string) string {
+	return strings.TrimPrefix(key, tenantID+separator)
+}
+
+func belongsTo(tenantID, key string) bool {
+	return strings.HasPrefix(key, tenantID+separator)
+}
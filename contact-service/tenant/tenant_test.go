@@ -0,0 +1,274 @@
+package tenant
+
+import (
+	"context"
+	"infrastructure/mycontext"
+	"infrastructure/myerror"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/contact"
+	"contact-service/disk"
+	"contact-service/inmem"
+	"contact-service/sqlite"
+	"contact-service/stdout"
+)
+
+func TestRepository_Isolation(t *testing.T) {
+	store := inmem.NewUserRepository()
+	repo := NewRepository(store, store, func(string) Quota { return Quota{} })
+	acme := mycontext.WithTenantID(context.Background(), "acme")
+	globex := mycontext.WithTenantID(context.Background(), "globex")
+
+	if err := repo.CreateContact(acme, contact.Contact{UserID: "1", ID: "c1", Phone: "123", FirstName: "a"}); err != nil {
+		t.Fatalf("CreateContact() error = %v", err)
+	}
+
+	c, err := repo.GetContact(acme, "1", "c1")
+	if err != nil {
+		t.Fatalf("GetContact() error = %v", err)
+	}
+	if c.UserID != "1" {
+		t.Errorf("GetContact() userID = %q, want 1", c.UserID)
+	}
+
+	if _, err := repo.GetContact(globex, "1", "c1"); myerror.GetParsedError(err).Type != myerror.NotFoundError {
+		t.Errorf("GetContact() of another tenant error = %v, want not found", err)
+	}
+	if exists, _ := repo.IsPhoneExistsForUser(globex, "1", "123"); exists {
+		t.Error("IsPhoneExistsForUser() of another tenant = true")
+	}
+	if contacts, _ := repo.SearchContacts(globex, contact.Filters{UserID: "1", Limit: 10}); len(contacts) != 0 {
+		t.Errorf("SearchContacts() of another tenant = %v", contacts)
+	}
+	if _, err := repo.GetContact(context.Background(), "1", "c1"); err == nil {
+		t.Error("GetContact() without a tenant succeeded")
+	}
+}
+
+func TestRepository_ContactsQuota(t *testing.T) {
+	store := inmem.NewUserRepository()
+	repo := NewRepository(store, store, func(tenantID string) Quota {
+		if tenantID == "acme" {
+			return Quota{MaxContacts: 2}
+		}
+		return Quota{}
+	})
+	acme := mycontext.WithTenantID(context.Background(), "acme")
+	globex := mycontext.WithTenantID(context.Background(), "globex")
+
+	for _, id := range []string{"c1", "c2"} {
+		if err := repo.CreateContact(acme, contact.Contact{UserID: "1", ID: id}); err != nil {
+			t.Fatalf("CreateContact(%s) error = %v", id, err)
+		}
+	}
+
+	err := repo.CreateContact(acme, contact.Contact{UserID: "2", ID: "c3"})
+	if myerror.GetParsedError(err).Type != myerror.QuotaExceededError {
+		t.Fatalf("CreateContact() over quota error = %v, want quota exceeded", err)
+	}
+	if err := repo.CreateContact(globex, contact.Contact{UserID: "1", ID: "c3"}); err != nil {
+		t.Errorf("CreateContact() of another tenant error = %v", err)
+	}
+
+	// deleting a missing contact must not give quota back
+	if err := repo.DeleteContact(acme, "1", "missing"); err != nil {
+		t.Fatalf("DeleteContact() error = %v", err)
+	}
+	if err := repo.CreateContact(acme, contact.Contact{UserID: "2", ID: "c3"}); err == nil {
+		t.Fatal("CreateContact() after deleting a missing contact succeeded")
+	}
+
+	if err := repo.DeleteContact(acme, "1", "c1"); err != nil {
+		t.Fatalf("DeleteContact() error = %v", err)
+	}
+	if err := repo.CreateContact(acme, contact.Contact{UserID: "2", ID: "c3"}); err != nil {
+		t.Errorf("CreateContact() after a delete error = %v", err)
+	}
+}
+
+// persistentStore is a storage backend that keeps its contacts when it is closed and opened again
+type persistentStore interface {
+	Repository
+	Counter
+}
+
+func TestRepository_ContactsQuotaAfterRestart(t *testing.T) {
+	tests := []struct {
+		name string
+		open func(t *testing.T, dir string) (persistentStore, func() error)
+	}{
+		{
+			name: "disk",
+			open: func(t *testing.T, dir string) (persistentStore, func() error) {
+				r, err := disk.NewRepository(dir, 0, stdout.NewLogger())
+				if err != nil {
+					t.Fatalf("disk.NewRepository() error = %v", err)
+				}
+				return r, r.Close
+			},
+		},
+		{
+			name: "sqlite",
+			open: func(t *testing.T, dir string) (persistentStore, func() error) {
+				r, err := sqlite.NewRepository(context.Background(), filepath.Join(dir, "contacts.db"))
+				if err != nil {
+					t.Fatalf("sqlite.NewRepository() error = %v", err)
+				}
+				return r, r.Close
+			},
+		},
+	}
+
+	quotas := func(string) Quota { return Quota{MaxContacts: 2} }
+	acme := mycontext.WithTenantID(context.Background(), "acme")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			store, closeStore := tt.open(t, dir)
+			repo := NewRepository(store, store, quotas)
+			for _, id := range []string{"c1", "c2"} {
+				if err := repo.CreateContact(acme, contact.Contact{UserID: "1", ID: id, Phone: id}); err != nil {
+					t.Fatalf("CreateContact(%s) error = %v", id, err)
+				}
+			}
+			if err := closeStore(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+
+			store, closeStore = tt.open(t, dir)
+			defer closeStore()
+			repo = NewRepository(store, store, quotas)
+
+			err := repo.CreateContact(acme, contact.Contact{UserID: "2", ID: "c3", Phone: "c3"})
+			if myerror.GetParsedError(err).Type != myerror.QuotaExceededError {
+				t.Errorf("CreateContact() over quota after a restart error = %v, want quota exceeded", err)
+			}
+		})
+	}
+}
+
+func TestLockCache(t *testing.T) {
+	locks := NewLockCache(inmem.NewLockCache())
+	acme := mycontext.WithTenantID(context.Background(), "acme")
+	globex := mycontext.WithTenantID(context.Background(), "globex")
+
+	if ok, err := locks.Lock(acme, "create:1:123"); err != nil || !ok {
+		t.Fatalf("Lock() = %v, %v", ok, err)
+	}
+	if ok, _ := locks.Lock(globex, "create:1:123"); !ok {
+		t.Error("Lock() of another tenant was contended")
+	}
+	if ok, _ := locks.Lock(acme, "create:1:123"); ok {
+		t.Error("Lock() of a held key succeeded")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	now := time.Now()
+	l := newRateLimiter()
+	l.now = func() time.Time { return now }
+	q := Quota{RequestsPerSecond: 2, Burst: 3}
+
+	for i := 0; i < 3; i++ {
+		if ok, _ := l.allow("acme", q); !ok {
+			t.Fatalf("request %d within the burst was refused", i)
+		}
+	}
+	ok, retryAfter := l.allow("acme", q)
+	if ok || retryAfter != 500*time.Millisecond {
+		t.Fatalf("allow() over the burst = %v, %v, want refused for 500ms", ok, retryAfter)
+	}
+	if ok, _ := l.allow("globex", q); !ok {
+		t.Error("another tenant was limited")
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if ok, _ := l.allow("acme", q); !ok {
+		t.Error("request after the refill was refused")
+	}
+
+	// the buckets of idle tenants are dropped once refilled
+	now = now.Add(sweepInterval)
+	l.allow("initech", q)
+	if len(l.buckets) != 1 {
+		t.Errorf("buckets after a sweep = %d, want only the active tenant", len(l.buckets))
+	}
+}
+
+func TestNewHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if tenantID := c.GetHeader("X-Token-Tenant"); tenantID != "" {
+			c.Request = c.Request.WithContext(mycontext.WithTenantID(c.Request.Context(), tenantID))
+		}
+		if subject := c.GetHeader("X-Token-Subject"); subject != "" {
+			c.Request = c.Request.WithContext(mycontext.WithSubject(c.Request.Context(), subject))
+		}
+		c.Next()
+	})
+	r.Use(NewHTTPMiddleware("X-Tenant-ID", func(tenantID string) Quota {
+		if tenantID == "limited" {
+			return Quota{RequestsPerSecond: 0.001, Burst: 1}
+		}
+		return Quota{}
+	}))
+	r.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, mycontext.TenantID(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name        string
+		header      string
+		tokenTenant string
+		subject     string
+		wantStatus  int
+		wantTenant  string
+	}{
+		{name: "header", header: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "token", tokenTenant: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "header repeats token", header: "acme", tokenTenant: "acme", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "header differs from token", header: "globex", tokenTenant: "acme", wantStatus: http.StatusForbidden},
+		{name: "user with a tenant", header: "acme", tokenTenant: "acme", subject: "user-1", wantStatus: http.StatusOK, wantTenant: "acme"},
+		{name: "user without a tenant cannot pick one", header: "acme", subject: "user-1", wantStatus: http.StatusForbidden},
+		{name: "missing", wantStatus: http.StatusBadRequest},
+		{name: "invalid", header: "acme/other", wantStatus: http.StatusBadRequest},
+		{name: "within rate", header: "limited", wantStatus: http.StatusOK, wantTenant: "limited"},
+		{name: "over rate", header: "limited", wantStatus: http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Tenant-ID", tt.header)
+			}
+			if tt.tokenTenant != "" {
+				req.Header.Set("X-Token-Tenant", tt.tokenTenant)
+			}
+			if tt.subject != "" {
+				req.Header.Set("X-Token-Subject", tt.subject)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusOK && rec.Body.String() != tt.wantTenant {
+				t.Errorf("tenant = %q, want %q", rec.Body.String(), tt.wantTenant)
+			}
+			if tt.wantStatus == http.StatusTooManyRequests && rec.Header().Get("Retry-After") == "" {
+				t.Error("Retry-After header is missing")
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package testutil
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/contact"
+	"contact-service/contactmanaging"
+	"contact-service/inmem"
+	"contact-service/tenant"
+)
+
+// Fixture is the seed data of the stores of a test handler
+type Fixture struct {
+	Contacts []contact.Contact
+	Shares   []contact.Share
+	Books    []contact.Book
+	// Cached reads the contacts through a cache, which changes must not leave stale
+	Cached bool
+}
+
+// Stores are the in-memory repositories of a test handler and the contacts service over them
+type Stores struct {
+	Contacts contactmanaging.Repository
+	Shares   tenant.ShareRepository
+	Books    tenant.BookRepository
+	Service  contactmanaging.Service
+}
+
+// NewStores fills in-memory repositories with the fixture
+func NewStores(t *testing.T, f Fixture) Stores {
+	t.Helper()
+	ctx := context.Background()
+
+	var contacts contactmanaging.Repository = inmem.NewUserRepository()
+	if f.Cached {
+		contacts = inmem.NewLRUCacheRepository(inmem.NewUserRepository(), 100, 0, 0, NopLogger{})
+	}
+	for _, c := range f.Contacts {
+		if err := contacts.CreateContact(ctx, c); err != nil {
+			t.Fatalf("CreateContact() error = %v", err)
+		}
+	}
+
+	shares := inmem.NewShareRepository()
+	for _, s := range f.Shares {
+		if _, err := shares.SaveShare(ctx, s); err != nil {
+			t.Fatalf("SaveShare() error = %v", err)
+		}
+	}
+
+	books := inmem.NewBookRepository()
+	for _, b := range f.Books {
+		if err := books.CreateBook(ctx, b); err != nil {
+			t.Fatalf("CreateBook() error = %v", err)
+		}
+	}
+
+	return Stores{
+		Contacts: contacts,
+		Shares:   shares,
+		Books:    books,
+		Service:  contactmanaging.NewService(contacts, shares, inmem.NewLockCache(), NopLogger{}),
+	}
+}
+
+// NewHandler serves the contacts routes of the stores and the given routes to the caller named by UserHeader, and
+// the admin routes to AdminUser
+func NewHandler(s Stores, routes, adminRoutes []func(gin.IRouter)) http.Handler {
+	return contactmanaging.NewHTTPHandler(s.Service, contactmanaging.HTTPOptions{
+		AccessLogger:        NopLogger{},
+		Authentication:      Authentication,
+		Routes:              routes,
+		AdminAuthentication: AdminAuthentication,
+		AdminRoutes:         adminRoutes,
+	})
+}
@@ -0,0 +1,54 @@
+// Package testutil holds the fixtures shared by the HTTP tests of the services
+package testutil
+
+import (
+	"context"
+	"infrastructure/mycontext"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"contact-service/apikey"
+)
+
+const (
+	// UserHeader names the caller in place of a token
+	UserHeader = "X-Test-User"
+	// AdminUser is the only caller granted the admin scope by AdminAuthentication
+	AdminUser = "admin"
+)
+
+type NopLogger struct{}
+
+func (NopLogger) Info(context.Context, string, ...interface{})   {}
+func (NopLogger) Error(context.Context, error, ...interface{})   {}
+func (NopLogger) Warning(context.Context, error, ...interface{}) {}
+func (NopLogger) Debug(context.Context, string, ...interface{})  {}
+
+// Authentication makes the user named by UserHeader the caller
+func Authentication(c *gin.Context) {
+	c.Request = c.Request.WithContext(mycontext.WithSubject(c.Request.Context(), c.GetHeader(UserHeader)))
+	c.Next()
+}
+
+// AdminAuthentication grants the admin scope to AdminUser and no scope to other callers
+func AdminAuthentication(c *gin.Context) {
+	var scopes []string
+	if c.GetHeader(UserHeader) == AdminUser {
+		scopes = []string{apikey.ScopeAdmin}
+	}
+	c.Request = c.Request.WithContext(mycontext.WithScopes(c.Request.Context(), scopes))
+	c.Next()
+}
+
+// Do sends the request to the handler as the user
+func Do(handler http.Handler, user, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set(UserHeader, user)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
@@ -11,8 +11,27 @@ const (
 	BadRequestError
 	NotFoundError
 	ForbiddenError
+	UnauthorizedError
+	QuotaExceededError
 )
 
+func (t errorType) String() string {
+	switch t {
+	case BadRequestError:
+		return "bad_request"
+	case NotFoundError:
+		return "not_found"
+	case ForbiddenError:
+		return "forbidden"
+	case UnauthorizedError:
+		return "unauthorized"
+	case QuotaExceededError:
+		return "quota_exceeded"
+	default:
+		return "internal"
+	}
+}
+
 type MyError struct {
 	Message string
 	Type    errorType
@@ -64,6 +83,23 @@ func NewForbiddenError(format string, args ...interface{}) error {
 	}
 }
 
+func NewUnauthorizedError(format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	return MyError{
+		Message: message,
+		Type:    UnauthorizedError,
+	}
+}
+
+// NewQuotaExceededError reports a request refused because the caller used up a quota, such as a request rate
+func NewQuotaExceededError(format string, args ...interface{}) error {
+	message := fmt.Sprintf(format, args...)
+	return MyError{
+		Message: message,
+		Type:    QuotaExceededError,
+	}
+}
+
 func GetParsedError(err error) MyError {
 	if e, ok := err.(MyError); ok {
 		return e
@@ -2,6 +2,7 @@ package myhttp
 
 import (
 	"github.com/gin-gonic/gin"
+	"infrastructure/mycontext"
 	"infrastructure/myerror"
 	"net/http"
 )
@@ -11,7 +12,8 @@ type responseJSONSuccess struct {
 }
 
 type responseJSONError struct {
-	Error string `json:"error"`
+	Error     string `json:"error"`
+	RequestID string `json:"requestID,omitempty"`
 }
 
 func EncodeJSONSuccess(c *gin.Context, response interface{}) {
@@ -22,11 +24,22 @@ func EncodeJSONSuccess(c *gin.Context, response interface{}) {
 	c.JSON(http.StatusOK, res)
 }
 
+// EncodeJSONWithStatus wraps the response like EncodeJSONSuccess but with an explicit status, for responses that
+// carry data even when they are not successful, such as health reports
+func EncodeJSONWithStatus(c *gin.Context, status int, response interface{}) {
+	res := &responseJSONSuccess{
+		Data: response,
+	}
+
+	c.JSON(status, res)
+}
+
 func EncodeJSONError(c *gin.Context, err error) {
 	parsedErr := myerror.GetParsedError(err)
 
 	res := &responseJSONError{
-		Error: parsedErr.Message,
+		Error:     parsedErr.Message,
+		RequestID: mycontext.RequestID(c.Request.Context()),
 	}
 
 	c.JSON(getHTTPCode(parsedErr), res)
@@ -40,6 +53,10 @@ func getHTTPCode(err error) int {
 		return http.StatusNotFound
 	case myerror.ForbiddenError:
 		return http.StatusForbidden
+	case myerror.UnauthorizedError:
+		return http.StatusUnauthorized
+	case myerror.QuotaExceededError:
+		return http.StatusTooManyRequests
 	default:
 		return http.StatusInternalServerError
 	}